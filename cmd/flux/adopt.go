@@ -0,0 +1,226 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [kind/name]",
+	Short: "Adopt an object applied by hand into a Kustomization",
+	Long: `The adopt command writes the manifest of an object of the cluster to a directory of a local checkout
+of the repository of a Kustomization, without its status and the metadata set by the API server, and labels
+the object as applied by the Kustomization. Once the manifest is committed and pushed, the Kustomization
+applies it over the object at its next reconciliation, without recreating it.
+
+The object is not labelled when the Kustomization prunes, as its garbage collection would delete the
+object if it reconciled before the manifest is pushed. The Kustomization labels the object when it
+applies it.`,
+	Example: `  # Adopt a Deployment of the prod namespace into the apps Kustomization
+  flux adopt deployment/my-app -n prod \
+    --into=Kustomization/apps \
+    --output=./apps/my-app
+
+  # Adopt a cluster-scoped object, with its kind qualified by its API group
+  flux adopt clusterroles.rbac.authorization.k8s.io/my-app \
+    --into=Kustomization/infrastructure \
+    --output=./infrastructure/rbac
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: adoptCmdRun,
+}
+
+type adoptFlags struct {
+	into          string
+	intoNamespace string
+	output        string
+}
+
+var adoptArgs adoptFlags
+
+func init() {
+	adoptCmd.Flags().StringVar(&adoptArgs.into, "into", "",
+		"the Kustomization taking over the object, in the format '[Kustomization/]<name>'")
+	adoptCmd.Flags().StringVar(&adoptArgs.intoNamespace, "into-namespace", rootArgs.defaults.Namespace,
+		"the namespace of the Kustomization taking over the object")
+	adoptCmd.Flags().StringVar(&adoptArgs.output, "output", "",
+		"the local directory the manifest of the object is written to, which must be within the path of the Kustomization")
+
+	rootCmd.AddCommand(adoptCmd)
+}
+
+// adoptIgnoredAnnotations are the annotations set on the live object
+// by controllers, which don't belong to its desired state.
+var adoptIgnoredAnnotations = []string{
+	"deployment.kubernetes.io/revision",
+}
+
+func adoptCmdRun(cmd *cobra.Command, args []string) error {
+	kind, name := utils.ParseObjectKindName(args[0])
+	if kind == "" {
+		return fmt.Errorf("invalid object '%s', must be in the format '<kind>/<name>'", args[0])
+	}
+	if adoptArgs.into == "" {
+		return fmt.Errorf("--into is required")
+	}
+	intoKind, intoName := utils.ParseObjectKindName(adoptArgs.into)
+	if intoKind != "" && !strings.EqualFold(intoKind, kustomizev1.KustomizationKind) {
+		return fmt.Errorf("objects can only be adopted into a Kustomization, got %s", intoKind)
+	}
+	if adoptArgs.output == "" {
+		return fmt.Errorf("--output is required")
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	var kustomization kustomizev1.Kustomization
+	intoNamespacedName := types.NamespacedName{
+		Namespace: adoptArgs.intoNamespace,
+		Name:      intoName,
+	}
+	if err := kubeClient.Get(ctx, intoNamespacedName, &kustomization); err != nil {
+		return fmt.Errorf("Kustomization %s: %w", intoNamespacedName, err)
+	}
+
+	gvk, err := kubeClient.RESTMapper().KindFor(schema.ParseGroupResource(strings.ToLower(kind)).WithVersion(""))
+	if err != nil {
+		return fmt.Errorf("unknown kind %s: %w", kind, err)
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	if err := kubeClient.Get(ctx, namespacedName, obj); err != nil {
+		return err
+	}
+	if err := checkAdoptable(obj); err != nil {
+		return err
+	}
+
+	data, err := adoptManifest(obj)
+	if err != nil {
+		return err
+	}
+	fileName := fmt.Sprintf("%s-%s.yaml", strings.ToLower(gvk.Kind), name)
+	path := filepath.Join(adoptArgs.output, fileName)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s exists already", path)
+	}
+	if err := os.MkdirAll(adoptArgs.output, os.ModePerm); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	logger.Successf("manifest written to %s", path)
+	if hasKustomizationFile(adoptArgs.output) {
+		logger.Warningf("add %s to the resources of the kustomization file of %s", fileName, adoptArgs.output)
+	}
+
+	if kustomization.Spec.Prune {
+		logger.Warningf("%s/%s not labelled, as Kustomization %s prunes the objects labelled as applied by it", gvk.Kind, name, intoNamespacedName)
+	} else {
+		logger.Actionf("labelling %s/%s as applied by Kustomization %s", gvk.Kind, name, intoNamespacedName)
+		if err := labelAdopted(ctx, kubeClient, obj, &kustomization); err != nil {
+			return err
+		}
+		logger.Successf("%s/%s labelled", gvk.Kind, name)
+	}
+
+	logger.Actionf("commit and push %s, the Kustomization applies it from path %s of its source", path, kustomization.Spec.Path)
+	return nil
+}
+
+// checkAdoptable makes sure an object isn't managed already, by Flux or
+// by the controller of another object, which would revert or fight
+// over the changes of the Kustomization.
+func checkAdoptable(obj *unstructured.Unstructured) error {
+	labels := obj.GetLabels()
+	if owner, ok := labels[kustomizev1.GroupVersion.Group+"/name"]; ok {
+		return fmt.Errorf("%s/%s is applied by Kustomization %s/%s already", obj.GetKind(), obj.GetName(),
+			labels[kustomizev1.GroupVersion.Group+"/namespace"], owner)
+	}
+	if owner, ok := labels[helmv2.GroupVersion.Group+"/name"]; ok {
+		return fmt.Errorf("%s/%s is released by HelmRelease %s/%s", obj.GetKind(), obj.GetName(),
+			labels[helmv2.GroupVersion.Group+"/namespace"], owner)
+	}
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			return fmt.Errorf("%s/%s is controlled by %s/%s, adopt %s/%s instead", obj.GetKind(), obj.GetName(),
+				ref.Kind, ref.Name, ref.Kind, ref.Name)
+		}
+	}
+	return nil
+}
+
+// adoptManifest returns the desired state of a live object, as written
+// by hand: without its status, the metadata set by the API server and
+// the fields allocated by the cluster.
+func adoptManifest(obj *unstructured.Unstructured) ([]byte, error) {
+	manifest := obj.DeepCopy()
+	annotations := manifest.GetAnnotations()
+	for _, annotation := range adoptIgnoredAnnotations {
+		delete(annotations, annotation)
+	}
+	manifest.SetAnnotations(annotations)
+	if manifest.GetKind() == "Service" {
+		unstructured.RemoveNestedField(manifest.Object, "spec", "clusterIP")
+		unstructured.RemoveNestedField(manifest.Object, "spec", "clusterIPs")
+	}
+	return normaliseExport(manifest.Object)
+}
+
+// labelAdopted sets the labels the kustomize-controller sets on the
+// objects it applies, so that the Kustomization is seen as the manager
+// of the object until it applies it. The Kustomization must not prune,
+// as the object has none of its checksums.
+func labelAdopted(ctx context.Context, kubeClient client.Client, obj *unstructured.Unstructured, kustomization *kustomizev1.Kustomization) error {
+	patch := client.MergeFrom(obj.DeepCopy())
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[kustomizev1.GroupVersion.Group+"/name"] = kustomization.Name
+	labels[kustomizev1.GroupVersion.Group+"/namespace"] = kustomization.Namespace
+	obj.SetLabels(labels)
+	return kubeClient.Patch(ctx, obj, patch)
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/pkg/apply"
+)
+
+// applyManifests applies the objects of a multi-document YAML file,
+// or of the kustomize build of a directory, with server-side apply,
+// and writes the change made to each object to w. With dryRun,
+// nothing is persisted.
+func applyManifests(ctx context.Context, kubeClient client.Client, w io.Writer, path string, dryRun bool) error {
+	opts := apply.MakeDefaultOptions()
+	opts.DryRun = dryRun
+	opts.PollInterval = rootArgs.pollInterval
+	opts.Timeout = rootArgs.timeout
+
+	changes, err := apply.Path(ctx, kubeClient, path, opts)
+	for _, change := range changes {
+		fmt.Fprintln(w, change)
+	}
+	return err
+}
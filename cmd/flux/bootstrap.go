@@ -19,14 +19,19 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
@@ -35,13 +40,19 @@ import (
 	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
 	kus "github.com/fluxcd/flux2/pkg/manifestgen/kustomization"
+	"github.com/fluxcd/flux2/pkg/manifestgen/sopssecret"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sync"
+	"github.com/fluxcd/flux2/pkg/readiness"
 )
 
 var bootstrapCmd = &cobra.Command{
 	Use:   "bootstrap",
 	Short: "Bootstrap toolkit components",
-	Long:  "The bootstrap sub-commands bootstrap the toolkit components on the targeted Git provider.",
+	Long: `The bootstrap sub-commands bootstrap the toolkit components on the targeted Git provider.
+They time out after 10 minutes, unless set otherwise with --timeout.`,
+	Annotations: map[string]string{
+		timeoutAnnotation: "10m",
+	},
 }
 
 type bootstrapFlags struct {
@@ -50,7 +61,11 @@ type bootstrapFlags struct {
 	extraComponents    []string
 	registry           string
 	imagePullSecret    string
+	images             []string
+	httpsProxy         string
+	noProxy            []string
 	branch             string
+	tagSemVer          string
 	watchAllNamespaces bool
 	networkPolicy      bool
 	manifestsPath      string
@@ -60,17 +75,26 @@ type bootstrapFlags struct {
 	tokenAuth          bool
 	clusterDomain      string
 	tolerationKeys     []string
+	secretsEncryption  flags.SecretsEncryption
+	decryptionProvider flags.DecryptionProvider
+	decryptionSecret   string
+	concurrency        int
+	takeover           bool
+	flux1Namespace     string
+	flux1Deployment    string
+	clusterName        string
 }
 
 const (
 	bootstrapDefaultBranch = "main"
+	bootstrapSopsSecret    = "sops-age"
 )
 
 var bootstrapArgs = NewBootstrapFlags()
 
 func init() {
 	bootstrapCmd.PersistentFlags().StringVarP(&bootstrapArgs.version, "version", "v", "",
-		"toolkit version, when specified the manifests are downloaded from https://github.com/fluxcd/flux2/releases")
+		"toolkit version, the manifests of the version of the CLI are embedded in the binary, the ones of other versions are downloaded from https://github.com/fluxcd/flux2/releases")
 	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.defaultComponents, "components", rootArgs.defaults.Components,
 		"list of components, accepts comma-separated values")
 	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.extraComponents, "components-extra", nil,
@@ -79,9 +103,17 @@ func init() {
 		"container registry where the toolkit images are published")
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.imagePullSecret, "image-pull-secret", "",
 		"Kubernetes secret name used for pulling the toolkit images from a private registry")
+	bootstrapCmd.PersistentFlags().StringArrayVar(&bootstrapArgs.images, "image", nil,
+		"image of a component in the format '<component>=<image>', overriding the one from the registry, e.g. a mirrored image or a hotfix build, may be specified multiple times")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.httpsProxy, "https-proxy", "",
+		"URL of the HTTPS proxy the controllers reach the sources, registries and notification providers through, set as HTTPS_PROXY on the controllers, the Git provider API is reached through the proxy set by the HTTPS_PROXY environment variable of the CLI")
+	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.noProxy, "no-proxy", nil,
+		"list of hosts, domains or CIDRs the controllers reach without the proxy, in addition to the Kubernetes API and the services of the cluster, accepts comma-separated values")
 	bootstrapCmd.PersistentFlags().Var(&bootstrapArgs.arch, "arch", bootstrapArgs.arch.Description())
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.branch, "branch", bootstrapDefaultBranch,
 		"default branch (for GitHub this must match the default branch setting for the organization)")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.tagSemVer, "tag-semver", "",
+		"git tag semver range, when specified the cluster syncs from the latest tag matching the range instead of the branch")
 	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.watchAllNamespaces, "watch-all-namespaces", true,
 		"watch for custom resources in all namespaces, if set to false it will only watch the namespace where the toolkit is installed")
 	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.networkPolicy, "network-policy", true,
@@ -93,6 +125,19 @@ func init() {
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.clusterDomain, "cluster-domain", rootArgs.defaults.ClusterDomain, "internal cluster domain")
 	bootstrapCmd.PersistentFlags().StringSliceVar(&bootstrapArgs.tolerationKeys, "toleration-keys", nil,
 		"list of toleration keys used to schedule the components pods onto nodes with matching taints")
+	bootstrapCmd.PersistentFlags().Var(&bootstrapArgs.secretsEncryption, "secrets-encryption", bootstrapArgs.secretsEncryption.Description())
+	bootstrapCmd.PersistentFlags().Var(&bootstrapArgs.decryptionProvider, "decryption-provider",
+		bootstrapArgs.decryptionProvider.Description()+", used by the sync Kustomization to decrypt the manifests of the repository")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.decryptionSecret, "decryption-secret", "",
+		"the Kubernetes secret name that contains the age or OpenPGP private keys used by the sync Kustomization for sops decryption")
+	bootstrapCmd.PersistentFlags().IntVar(&bootstrapArgs.concurrency, "concurrency", defaultConcurrency,
+		"number of readiness checks to run concurrently while waiting for the components and the sync objects")
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapArgs.takeover, "takeover", false,
+		"take over from the Flux v1 deployment syncing the same repository, by scaling it down while the toolkit syncs and removing it once the sync succeeds")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.flux1Namespace, "flux1-namespace", "flux", "the namespace of the Flux v1 deployment to take over from")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.flux1Deployment, "flux1-deployment", "flux", "the name of the Flux v1 deployment to take over from")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapArgs.clusterName, "cluster-name", "",
+		"name of the cluster, when specified the cluster syncs the clusters/<cluster-name> path, which shares the base path with the other clusters of the repository")
 	bootstrapCmd.PersistentFlags().MarkHidden("manifests")
 	bootstrapCmd.PersistentFlags().MarkDeprecated("arch", "multi-arch container image is now available for AMD64, ARMv7 and ARM64")
 	rootCmd.AddCommand(bootstrapCmd)
@@ -120,10 +165,59 @@ func bootstrapValidate() error {
 	if err := utils.ValidateComponents(components); err != nil {
 		return err
 	}
+	if _, err := parseComponentImages(bootstrapArgs.images); err != nil {
+		return err
+	}
+	if err := validateProxy(bootstrapArgs.httpsProxy, bootstrapArgs.noProxy); err != nil {
+		return err
+	}
+
+	if bootstrapArgs.tagSemVer != "" {
+		if _, err := semver.NewConstraint(bootstrapArgs.tagSemVer); err != nil {
+			return fmt.Errorf("invalid tag semver range '%s': %w", bootstrapArgs.tagSemVer, err)
+		}
+	}
+
+	if bootstrapArgs.decryptionSecret != "" && bootstrapArgs.decryptionProvider == "" {
+		return fmt.Errorf("--decryption-secret requires --decryption-provider")
+	}
+	if bootstrapArgs.secretsEncryption != "" && bootstrapArgs.decryptionProvider != "" {
+		return fmt.Errorf("--secrets-encryption and --decryption-provider can't be set together, the sync Kustomization decrypts with the %s secret generated by --secrets-encryption",
+			bootstrapSopsSecret)
+	}
 
 	return nil
 }
 
+// bootstrapDecryption returns the decryption provider and secret of the
+// Kustomizations generated by bootstrap, which are empty when the
+// repository holds no encrypted manifests.
+func bootstrapDecryption() (string, string) {
+	if bootstrapArgs.secretsEncryption != "" {
+		return "sops", bootstrapSopsSecret
+	}
+	return bootstrapArgs.decryptionProvider.String(), bootstrapArgs.decryptionSecret
+}
+
+// checkDecryptionSecret warns when the decryption secret set with
+// --decryption-secret is missing, as the sync fails to decrypt the
+// manifests until it's created.
+func checkDecryptionSecret(ctx context.Context, kubeClient client.Client, namespace string) error {
+	if bootstrapArgs.decryptionSecret == "" {
+		return nil
+	}
+	var secret corev1.Secret
+	err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: bootstrapArgs.decryptionSecret}, &secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		logger.Warningf("decryption secret %s not found in namespace %s, create it with 'flux create secret sops-age' or 'flux create secret sops-gpg'",
+			bootstrapArgs.decryptionSecret, namespace)
+		return nil
+	default:
+		return err
+	}
+}
+
 func generateInstallManifests(targetPath, namespace, tmpDir string, localManifests string) (string, error) {
 	if ver, err := getVersion(bootstrapArgs.version); err != nil {
 		return "", err
@@ -131,6 +225,11 @@ func generateInstallManifests(targetPath, namespace, tmpDir string, localManifes
 		bootstrapArgs.version = ver
 	}
 
+	images, err := parseComponentImages(bootstrapArgs.images)
+	if err != nil {
+		return "", err
+	}
+
 	manifestsBase := ""
 	if isEmbeddedVersion(bootstrapArgs.version) {
 		if err := writeEmbeddedManifests(tmpDir); err != nil {
@@ -155,6 +254,9 @@ func generateInstallManifests(targetPath, namespace, tmpDir string, localManifes
 		TargetPath:             targetPath,
 		ClusterDomain:          bootstrapArgs.clusterDomain,
 		TolerationKeys:         bootstrapArgs.tolerationKeys,
+		ComponentImages:        images,
+		HTTPSProxy:             bootstrapArgs.httpsProxy,
+		NoProxy:                bootstrapArgs.noProxy,
 	}
 
 	if localManifests == "" {
@@ -170,16 +272,16 @@ func generateInstallManifests(targetPath, namespace, tmpDir string, localManifes
 	if err != nil {
 		return "", fmt.Errorf("generating install manifests failed: %w", err)
 	}
+	logger.Debugf("install manifests written to %s", filePath)
 	return filePath, nil
 }
 
-func applyInstallManifests(ctx context.Context, manifestPath string, components []string) error {
-	kubectlArgs := []string{"apply", "-f", manifestPath}
-	if _, err := utils.ExecKubectlCommand(ctx, utils.ModeOS, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
-		return fmt.Errorf("install failed")
+func applyInstallManifests(ctx context.Context, kubeClient client.Client, manifestPath string, components []string) error {
+	if err := applyManifests(ctx, kubeClient, os.Stdout, manifestPath, false); err != nil {
+		return fmt.Errorf("install failed: %w", err)
 	}
 
-	statusChecker, err := NewStatusChecker(time.Second, rootArgs.timeout)
+	statusChecker, err := NewStatusChecker(time.Second, rootArgs.timeout, bootstrapArgs.concurrency)
 	if err != nil {
 		return fmt.Errorf("install failed: %w", err)
 	}
@@ -198,11 +300,13 @@ func generateSyncManifests(url, branch, name, namespace, targetPath, tmpDir stri
 		Namespace:    namespace,
 		URL:          url,
 		Branch:       branch,
+		TagSemVer:    bootstrapArgs.tagSemVer,
 		Interval:     interval,
 		Secret:       namespace,
 		TargetPath:   targetPath,
 		ManifestFile: sync.MakeDefaultOptions().ManifestFile,
 	}
+	opts.DecryptionProvider, opts.DecryptionSecret = bootstrapDecryption()
 
 	manifest, err := sync.Generate(opts)
 	if err != nil {
@@ -223,34 +327,103 @@ func generateSyncManifests(url, branch, name, namespace, targetPath, tmpDir stri
 	if err != nil {
 		return "", err
 	}
-	if _, err = kustomization.WriteFile(tmpDir); err != nil {
+	kustomizationPath, err := kustomization.WriteFile(tmpDir)
+	if err != nil {
 		return "", err
 	}
+	logger.Debugf("sync manifests written to %s and %s", output, kustomizationPath)
 
 	return outputDir, nil
 }
 
 func applySyncManifests(ctx context.Context, kubeClient client.Client, name, namespace, manifestsPath string) error {
-	kubectlArgs := []string{"apply", "-k", manifestsPath}
-	if _, err := utils.ExecKubectlCommand(ctx, utils.ModeStderrOS, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
+	if err := applyManifests(ctx, kubeClient, os.Stderr, manifestsPath, false); err != nil {
 		return err
 	}
 
 	logger.Waitingf("waiting for cluster sync")
 
+	namespacedName := types.NamespacedName{Name: name, Namespace: namespace}
 	var gitRepository sourcev1.GitRepository
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isGitRepositoryReady(ctx, kubeClient, types.NamespacedName{Name: name, Namespace: namespace}, &gitRepository)); err != nil {
-		return err
+	var kustomization kustomizev1.Kustomization
+	checks := []readiness.Check{
+		{
+			Name:      fmt.Sprintf("GitRepository/%s", namespacedName),
+			Condition: isGitRepositoryReady(ctx, kubeClient, namespacedName, &gitRepository),
+		},
+		{
+			Name:      fmt.Sprintf("Kustomization/%s", namespacedName),
+			Condition: isKustomizationReady(ctx, kubeClient, namespacedName, &kustomization),
+		},
 	}
 
-	var kustomization kustomizev1.Kustomization
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isKustomizationReady(ctx, kubeClient, types.NamespacedName{Name: name, Namespace: namespace}, &kustomization)); err != nil {
-		return err
+	var errs []error
+	for i, err := range readiness.Wait(rootArgs.pollInterval, rootArgs.timeout, bootstrapArgs.concurrency, checks) {
+		if err != nil {
+			logger.Failuref("%s: %s", checks[i].Name, err.Error())
+			errs = append(errs, fmt.Errorf("%s: %w", checks[i].Name, err))
+		}
 	}
+	return utilerrors.NewAggregate(errs)
+}
 
-	return nil
+// bootstrapSecretsEncryption makes sure the cluster has an age identity
+// to decrypt secrets with, generating it when missing, and writes a
+// SOPS configuration encrypting for it at the root of the repository.
+// It returns whether the configuration was written, which it is not
+// when the repository has one already.
+func bootstrapSecretsEncryption(ctx context.Context, kubeClient client.Client, namespace, repoDir string) (bool, error) {
+	namespacedName := types.NamespacedName{
+		Namespace: namespace,
+		Name:      bootstrapSopsSecret,
+	}
+
+	var recipient string
+	var existing corev1.Secret
+	err := kubeClient.Get(ctx, namespacedName, &existing)
+	switch {
+	case err == nil:
+		recipient, err = sopssecret.AgeRecipient(string(existing.Data[sopssecret.AgeKeySecretKey]))
+		if err != nil {
+			return false, fmt.Errorf("reading the age key of secret %s failed: %w", bootstrapSopsSecret, err)
+		}
+	case apierrors.IsNotFound(err):
+		var identity string
+		identity, recipient, err = sopssecret.GenerateAgeKey()
+		if err != nil {
+			return false, err
+		}
+		secret, err := sopssecret.Generate(sopssecret.Options{
+			Name:      bootstrapSopsSecret,
+			Namespace: namespace,
+			AgeKey:    identity,
+		})
+		if err != nil {
+			return false, err
+		}
+		var s corev1.Secret
+		if err := yaml.Unmarshal([]byte(secret.Content), &s); err != nil {
+			return false, err
+		}
+		if err := upsertSecret(ctx, kubeClient, s); err != nil {
+			return false, err
+		}
+		logger.Successf("age key pair generated")
+	default:
+		return false, err
+	}
+	logger.Generatef("age recipient: %s", recipient)
+
+	config := fmt.Sprintf("creation_rules:\n  - encrypted_regex: ^(data|stringData)$\n    age: %s\n", recipient)
+	configPath := filepath.Join(repoDir, ".sops.yaml")
+	if _, err := os.Stat(configPath); err == nil {
+		logger.Warningf(".sops.yaml exists, add the age recipient to its creation rules:\n%s", config)
+		return false, nil
+	}
+	if err := ioutil.WriteFile(configPath, []byte(config), 0644); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func shouldInstallManifests(ctx context.Context, kubeClient client.Client, namespace string) bool {
@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/flags"
+)
+
+const (
+	// bootstrapClustersDir holds a directory per cluster bootstrapped
+	// with --cluster-name.
+	bootstrapClustersDir = "clusters"
+	// bootstrapBaseDir holds the manifests synced by all the clusters
+	// bootstrapped with --cluster-name.
+	bootstrapBaseDir = "base"
+	// bootstrapClusterIDFile records the cluster bootstrapped to a path.
+	bootstrapClusterIDFile = ".cluster-id"
+)
+
+// setBootstrapClusterPath sets the path of the cluster to
+// clusters/<cluster-name> when --cluster-name is set.
+func setBootstrapClusterPath(p *flags.SafeRelativePath) error {
+	if bootstrapArgs.clusterName == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(bootstrapArgs.clusterName); len(errs) > 0 {
+		return fmt.Errorf("invalid cluster name '%s': %s", bootstrapArgs.clusterName, strings.Join(errs, ", "))
+	}
+	if *p != "" {
+		return fmt.Errorf("--path and --cluster-name can't be set together, the path of the cluster is %s/<cluster-name>", bootstrapClustersDir)
+	}
+	return p.Set(path.Join(bootstrapClustersDir, bootstrapArgs.clusterName))
+}
+
+// checkBootstrapClusterID makes sure the path isn't synced by another
+// cluster, recording the UID of the kube-system namespace of the
+// cluster in the flux-system directory of the path on first bootstrap.
+func checkBootstrapClusterID(ctx context.Context, kubeClient client.Client, repoDir, targetPath string) error {
+	var ns corev1.Namespace
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: metav1.NamespaceSystem}, &ns); err != nil {
+		return fmt.Errorf("reading the cluster identity failed: %w", err)
+	}
+	clusterID := string(ns.UID)
+
+	idPath := filepath.Join(repoDir, targetPath, rootArgs.namespace, bootstrapClusterIDFile)
+	data, err := ioutil.ReadFile(idPath)
+	switch {
+	case err == nil:
+		if existing := strings.TrimSpace(string(data)); existing != clusterID {
+			return fmt.Errorf("path %s is synced by another cluster (%s), choose another path or, if the cluster was recreated, remove %s",
+				targetPath, existing, path.Join(targetPath, rootArgs.namespace, bootstrapClusterIDFile))
+		}
+		return nil
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(idPath), os.ModePerm); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(idPath, []byte(clusterID+"\n"), 0644)
+	default:
+		return err
+	}
+}
+
+// generateFleetBase writes the Kustomization syncing the base directory
+// shared by the clusters to the path of the cluster, and the base
+// directory itself when the repository has none. It returns the paths
+// to commit, relative to the repository root.
+func generateFleetBase(repoDir, targetPath string, interval time.Duration) ([]string, error) {
+	kustomization := kustomizev1.Kustomization{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       kustomizev1.KustomizationKind,
+			APIVersion: kustomizev1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstrapBaseDir,
+			Namespace: rootArgs.namespace,
+		},
+		Spec: kustomizev1.KustomizationSpec{
+			Interval: metav1.Duration{Duration: interval},
+			Path:     "./" + bootstrapBaseDir,
+			Prune:    true,
+			SourceRef: kustomizev1.CrossNamespaceSourceReference{
+				Kind: sourcev1.GitRepositoryKind,
+				Name: rootArgs.namespace,
+			},
+			Validation: "client",
+		},
+	}
+	if provider, secret := bootstrapDecryption(); provider != "" {
+		kustomization.Spec.Decryption = &kustomizev1.Decryption{
+			Provider: provider,
+		}
+		if secret != "" {
+			kustomization.Spec.Decryption.SecretRef = &meta.LocalObjectReference{Name: secret}
+		}
+	}
+	data, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return nil, err
+	}
+	kustomizationPath := path.Join(targetPath, bootstrapBaseDir+".yaml")
+	if err := ioutil.WriteFile(filepath.Join(repoDir, kustomizationPath), data, 0644); err != nil {
+		return nil, err
+	}
+	paths := []string{kustomizationPath}
+
+	basePath := filepath.Join(repoDir, bootstrapBaseDir)
+	if _, err := os.Stat(basePath); os.IsNotExist(err) {
+		if err := os.MkdirAll(basePath, os.ModePerm); err != nil {
+			return nil, err
+		}
+		content := "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources: []\n"
+		if err := ioutil.WriteFile(filepath.Join(basePath, "kustomization.yaml"), []byte(content), 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, bootstrapBaseDir)
+	}
+	return paths, nil
+}
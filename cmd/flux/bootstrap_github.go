@@ -32,7 +32,6 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -67,6 +66,15 @@ the bootstrap command will perform an upgrade if needed.`,
 
   # Run bootstrap for a an existing repository with a branch named main
   flux bootstrap github --owner=<organization> --repository=<repo name> --branch=main
+
+  # Run bootstrap with a generated age key pair for decrypting SOPS encrypted secrets
+  flux bootstrap github --owner=<organization> --repository=<repo name> --secrets-encryption=age
+
+  # Run bootstrap for a repository with SOPS encrypted secrets, decrypted with an existing OpenPGP key
+  flux bootstrap github --owner=<organization> --repository=<repo name> --decryption-provider=sops --decryption-secret=sops-gpg
+
+  # Run bootstrap for a cluster of a fleet sharing the repository
+  flux bootstrap github --owner=<organization> --repository=<repo name> --cluster-name=staging
 `,
 	RunE: bootstrapGitHubCmdRun,
 }
@@ -116,11 +124,14 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	if err := bootstrapValidate(); err != nil {
 		return err
 	}
+	if err := setBootstrapClusterPath(&githubArgs.path); err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
@@ -152,6 +163,11 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		repository.SSHHost = githubArgs.sshHostname
 	}
 
+	flux1, err := detectFlux1(ctx, kubeClient, repository.GetSSH(), repository.GetURL())
+	if err != nil {
+		return err
+	}
+
 	provider := &git.GithubProvider{
 		IsPrivate:  githubArgs.private,
 		IsPersonal: githubArgs.personal,
@@ -200,6 +216,10 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	logger.Successf("repository cloned")
 
+	if err := checkBootstrapClusterID(ctx, kubeClient, tmpDir, githubArgs.path.String()); err != nil {
+		return err
+	}
+
 	// generate install manifests
 	logger.Generatef("generating manifests")
 	installManifest, err := generateInstallManifests(
@@ -238,7 +258,7 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 	if isInstall {
 		// apply install manifests
 		logger.Actionf("installing components in %s namespace", rootArgs.namespace)
-		if err := applyInstallManifests(ctx, installManifest, bootstrapComponents()); err != nil {
+		if err := applyInstallManifests(ctx, kubeClient, installManifest, bootstrapComponents()); err != nil {
 			return err
 		}
 		logger.Successf("install completed")
@@ -293,6 +313,29 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// configure secrets decryption
+	if bootstrapArgs.secretsEncryption != "" {
+		logger.Actionf("configuring secrets decryption")
+		written, err := bootstrapSecretsEncryption(ctx, kubeClient, rootArgs.namespace, tmpDir)
+		if err != nil {
+			return err
+		}
+		if written {
+			if changed, err := repository.Commit(ctx, ".sops.yaml", "Add SOPS configuration"); err != nil {
+				return err
+			} else if changed {
+				if err := repository.Push(ctx); err != nil {
+					return err
+				}
+				logger.Successf("SOPS configuration pushed")
+			}
+		}
+	}
+
+	if err := checkDecryptionSecret(ctx, kubeClient, rootArgs.namespace); err != nil {
+		return err
+	}
+
 	// configure repo synchronization
 	logger.Actionf("generating sync manifests")
 	syncManifests, err := generateSyncManifests(
@@ -322,9 +365,33 @@ func bootstrapGitHubCmdRun(cmd *cobra.Command, args []string) error {
 		logger.Successf("sync manifests pushed")
 	}
 
+	// configure the base shared by the clusters of the repository
+	if bootstrapArgs.clusterName != "" {
+		paths, err := generateFleetBase(tmpDir, githubArgs.path.String(), githubArgs.interval)
+		if err != nil {
+			return err
+		}
+		changed := false
+		for _, p := range paths {
+			c, err := repository.Commit(ctx, p, fmt.Sprintf("Add %s base sync manifests", bootstrapArgs.clusterName))
+			if err != nil {
+				return err
+			}
+			changed = changed || c
+		}
+		if changed {
+			if err := repository.Push(ctx); err != nil {
+				return err
+			}
+			logger.Successf("base sync manifests pushed")
+		}
+	}
+
 	// apply manifests and waiting for sync
 	logger.Actionf("applying sync manifests")
-	if err := applySyncManifests(ctx, kubeClient, rootArgs.namespace, rootArgs.namespace, syncManifests); err != nil {
+	if err := flux1.handOver(ctx, kubeClient, func() error {
+		return applySyncManifests(ctx, kubeClient, rootArgs.namespace, rootArgs.namespace, syncManifests)
+	}); err != nil {
 		return err
 	}
 
@@ -34,7 +34,6 @@ import (
 	"github.com/fluxcd/pkg/git"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -66,6 +65,15 @@ the bootstrap command will perform an upgrade if needed.`,
 
   # Run bootstrap for a an existing repository with a branch named main
   flux bootstrap gitlab --owner=<organization> --repository=<repo name> --branch=main --token-auth
+
+  # Run bootstrap with a generated age key pair for decrypting SOPS encrypted secrets
+  flux bootstrap gitlab --owner=<group> --repository=<repo name> --secrets-encryption=age
+
+  # Run bootstrap for a repository with SOPS encrypted secrets, decrypted with an existing OpenPGP key
+  flux bootstrap gitlab --owner=<group> --repository=<repo name> --decryption-provider=sops --decryption-secret=sops-gpg
+
+  # Run bootstrap for a cluster of a fleet sharing the repository
+  flux bootstrap gitlab --owner=<group> --repository=<repo name> --cluster-name=staging
 `,
 	RunE: bootstrapGitLabCmdRun,
 }
@@ -117,11 +125,14 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 	if err := bootstrapValidate(); err != nil {
 		return err
 	}
+	if err := setBootstrapClusterPath(&gitlabArgs.path); err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
@@ -148,6 +159,11 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 		repository.SSHHost = gitlabArgs.sshHostname
 	}
 
+	flux1, err := detectFlux1(ctx, kubeClient, repository.GetSSH(), repository.GetURL())
+	if err != nil {
+		return err
+	}
+
 	tmpDir, err := ioutil.TempDir("", rootArgs.namespace)
 	if err != nil {
 		return err
@@ -175,6 +191,10 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	logger.Successf("repository cloned")
 
+	if err := checkBootstrapClusterID(ctx, kubeClient, tmpDir, gitlabArgs.path.String()); err != nil {
+		return err
+	}
+
 	// generate install manifests
 	logger.Generatef("generating manifests")
 	installManifest, err := generateInstallManifests(
@@ -213,7 +233,7 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 	if isInstall {
 		// apply install manifests
 		logger.Actionf("installing components in %s namespace", rootArgs.namespace)
-		if err := applyInstallManifests(ctx, installManifest, bootstrapComponents()); err != nil {
+		if err := applyInstallManifests(ctx, kubeClient, installManifest, bootstrapComponents()); err != nil {
 			return err
 		}
 		logger.Successf("install completed")
@@ -268,6 +288,29 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// configure secrets decryption
+	if bootstrapArgs.secretsEncryption != "" {
+		logger.Actionf("configuring secrets decryption")
+		written, err := bootstrapSecretsEncryption(ctx, kubeClient, rootArgs.namespace, tmpDir)
+		if err != nil {
+			return err
+		}
+		if written {
+			if changed, err := repository.Commit(ctx, ".sops.yaml", "Add SOPS configuration"); err != nil {
+				return err
+			} else if changed {
+				if err := repository.Push(ctx); err != nil {
+					return err
+				}
+				logger.Successf("SOPS configuration pushed")
+			}
+		}
+	}
+
+	if err := checkDecryptionSecret(ctx, kubeClient, rootArgs.namespace); err != nil {
+		return err
+	}
+
 	// configure repo synchronization
 	logger.Actionf("generating sync manifests")
 	syncManifests, err := generateSyncManifests(
@@ -297,9 +340,33 @@ func bootstrapGitLabCmdRun(cmd *cobra.Command, args []string) error {
 		logger.Successf("sync manifests pushed")
 	}
 
+	// configure the base shared by the clusters of the repository
+	if bootstrapArgs.clusterName != "" {
+		paths, err := generateFleetBase(tmpDir, gitlabArgs.path.String(), gitlabArgs.interval)
+		if err != nil {
+			return err
+		}
+		changed := false
+		for _, p := range paths {
+			c, err := repository.Commit(ctx, p, fmt.Sprintf("Add %s base sync manifests", bootstrapArgs.clusterName))
+			if err != nil {
+				return err
+			}
+			changed = changed || c
+		}
+		if changed {
+			if err := repository.Push(ctx); err != nil {
+				return err
+			}
+			logger.Successf("base sync manifests pushed")
+		}
+	}
+
 	// apply manifests and waiting for sync
 	logger.Actionf("applying sync manifests")
-	if err := applySyncManifests(ctx, kubeClient, rootArgs.namespace, rootArgs.namespace, syncManifests); err != nil {
+	if err := flux1.handOver(ctx, kubeClient, func() error {
+		return applySyncManifests(ctx, kubeClient, rootArgs.namespace, rootArgs.namespace, syncManifests)
+	}); err != nil {
 		return err
 	}
 
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/pkg/readiness"
+)
+
+// flux1RollbackTimeout bounds the scaling of Flux v1 back up, which
+// runs after the context of the bootstrap is done.
+const flux1RollbackTimeout = 30 * time.Second
+
+// flux1Installation is a Flux v1 deployment syncing the repository
+// being bootstrapped, which the toolkit takes over from.
+type flux1Installation struct {
+	namespacedName types.NamespacedName
+	url            string
+	replicas       int32
+}
+
+// detectFlux1 looks for the Flux v1 deployment set with
+// --flux1-namespace and --flux1-deployment. It returns an error when
+// the deployment syncs one of the given repository URLs and --takeover
+// is not set, as both would apply the same manifests.
+func detectFlux1(ctx context.Context, kubeClient client.Client, repoURLs ...string) (*flux1Installation, error) {
+	namespacedName := types.NamespacedName{
+		Namespace: bootstrapArgs.flux1Namespace,
+		Name:      bootstrapArgs.flux1Deployment,
+	}
+	var deployment appsv1.Deployment
+	if err := kubeClient.Get(ctx, namespacedName, &deployment); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("looking for Flux v1 failed: %w", err)
+	}
+
+	var gitURL string
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		if u := parseFlux1Args(c.Args).get("git-url"); u != "" {
+			gitURL = u
+		}
+	}
+	sameRepository := false
+	for _, u := range repoURLs {
+		if gitURL != "" && sameGitRepository(gitURL, u) {
+			sameRepository = true
+		}
+	}
+
+	if !sameRepository {
+		if gitURL == "" {
+			return nil, nil
+		}
+		logger.Warningf("Flux v1 is running in namespace %s, syncing %s", namespacedName.Namespace, gitURL)
+		return nil, nil
+	}
+	if !bootstrapArgs.takeover {
+		return nil, fmt.Errorf("Flux v1 deployment %s syncs %s already, set --takeover to hand the repository over to the toolkit",
+			namespacedName, gitURL)
+	}
+
+	logger.Actionf("taking over from Flux v1 deployment %s", namespacedName)
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return &flux1Installation{
+		namespacedName: namespacedName,
+		url:            gitURL,
+		replicas:       replicas,
+	}, nil
+}
+
+// sameGitRepository compares the host and path of two Git URLs, so
+// that the SSH and HTTPS addresses of a repository are the same.
+func sameGitRepository(a, b string) bool {
+	key := func(s string) string {
+		u, err := url.Parse(toSSHURL(s))
+		if err != nil {
+			return s
+		}
+		p := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+		return strings.ToLower(u.Hostname() + "/" + p)
+	}
+	return key(a) == key(b)
+}
+
+// handOver runs sync while Flux v1 is scaled down, so that the two
+// don't apply the manifests of the repository concurrently. Flux v1 is
+// removed once sync succeeds, and scaled back up if it fails. A nil
+// installation runs sync alone.
+func (f *flux1Installation) handOver(ctx context.Context, kubeClient client.Client, sync func() error) error {
+	if f == nil {
+		return sync()
+	}
+
+	if err := f.adoptNamespaceLabels(ctx, kubeClient); err != nil {
+		return err
+	}
+
+	logger.Actionf("scaling down Flux v1 deployment %s", f.namespacedName)
+	if err := f.scale(ctx, kubeClient, 0); err != nil {
+		return err
+	}
+	logger.Waitingf("waiting for Flux v1 to stop")
+	check := readiness.Check{
+		Name:      fmt.Sprintf("Deployment/%s", f.namespacedName),
+		Condition: f.isScaledDown(ctx, kubeClient),
+	}
+	if err := readiness.Wait(rootArgs.pollInterval, rootArgs.timeout, 1, []readiness.Check{check})[0]; err != nil {
+		return fmt.Errorf("waiting for Flux v1 to stop failed: %w", err)
+	}
+	logger.Successf("Flux v1 stopped")
+
+	if err := sync(); err != nil {
+		// the context of the bootstrap may have expired, or been
+		// cancelled on SIGINT, already
+		logger.Actionf("scaling Flux v1 deployment %s back up", f.namespacedName)
+		rollbackCtx, cancel := context.WithTimeout(context.Background(), flux1RollbackTimeout)
+		defer cancel()
+		if err := f.scale(rollbackCtx, kubeClient, f.replicas); err != nil {
+			logger.Failuref("scaling Flux v1 back up failed: %s", err.Error())
+		}
+		return err
+	}
+
+	deployment := &appsv1.Deployment{}
+	deployment.Namespace = f.namespacedName.Namespace
+	deployment.Name = f.namespacedName.Name
+	if err := kubeClient.Delete(ctx, deployment); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("removing Flux v1 failed: %w", err)
+	}
+	logger.Successf("Flux v1 deployment %s removed, the toolkit syncs %s", f.namespacedName, f.url)
+	logger.Actionf("once the sync is verified, remove the remaining Flux v1 objects of namespace %s, e.g. memcached and the flux-git-deploy secret",
+		f.namespacedName.Namespace)
+	return nil
+}
+
+func (f *flux1Installation) scale(ctx context.Context, kubeClient client.Client, replicas int32) error {
+	var deployment appsv1.Deployment
+	if err := kubeClient.Get(ctx, f.namespacedName, &deployment); err != nil {
+		return err
+	}
+	patch := client.MergeFrom(deployment.DeepCopy())
+	deployment.Spec.Replicas = &replicas
+	return kubeClient.Patch(ctx, &deployment, patch)
+}
+
+func (f *flux1Installation) isScaledDown(ctx context.Context, kubeClient client.Client) func() (bool, error) {
+	return func() (bool, error) {
+		var deployment appsv1.Deployment
+		if err := kubeClient.Get(ctx, f.namespacedName, &deployment); err != nil {
+			return false, err
+		}
+		return deployment.Status.Replicas == 0, nil
+	}
+}
+
+// adoptNamespaceLabels copies the labels of the Flux v1 namespace, which
+// network or admission policies may select, to the toolkit namespace.
+func (f *flux1Installation) adoptNamespaceLabels(ctx context.Context, kubeClient client.Client) error {
+	var flux1Namespace, namespace corev1.Namespace
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: f.namespacedName.Namespace}, &flux1Namespace); err != nil {
+		return err
+	}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: rootArgs.namespace}, &namespace); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(namespace.DeepCopy())
+	var adopted []string
+	for k, v := range flux1Namespace.Labels {
+		if strings.Contains(k, "kubernetes.io/") {
+			continue
+		}
+		if _, ok := namespace.Labels[k]; ok {
+			continue
+		}
+		if namespace.Labels == nil {
+			namespace.Labels = map[string]string{}
+		}
+		namespace.Labels[k] = v
+		adopted = append(adopted, k)
+	}
+	if len(adopted) == 0 {
+		return nil
+	}
+	sort.Strings(adopted)
+	if err := kubeClient.Patch(ctx, &namespace, patch); err != nil {
+		return fmt.Errorf("adopting the labels of namespace %s failed: %w", flux1Namespace.Name, err)
+	}
+	logger.Successf("namespace %s labeled with %s", rootArgs.namespace, strings.Join(adopted, ", "))
+	return nil
+}
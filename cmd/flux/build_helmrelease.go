@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var buildHrCmd = &cobra.Command{
+	Use:     "helmrelease [name]",
+	Aliases: []string{"hr"},
+	Short:   "Build a HelmRelease locally",
+	Long: `The build helmrelease command renders the chart of a HelmRelease with its merged values, and prints
+the resulting manifests. The chart is downloaded from the source-controller, from the artifact of
+the HelmChart the helm-controller created for the release, and is rendered with the Helm library,
+as a client-only dry-run of its install.`,
+	Example: `  # Render the manifests of a HelmRelease
+  flux build helmrelease podinfo
+`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: resourceNamesCompletionFunc(helmReleaseType),
+	RunE:              buildHrCmdRun,
+}
+
+func init() {
+	buildCmd.AddCommand(buildHrCmd)
+}
+
+func buildHrCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      args[0],
+	}
+	var helmRelease helmv2.HelmRelease
+	if err := kubeClient.Get(ctx, namespacedName, &helmRelease); err != nil {
+		return err
+	}
+
+	manifest, err := buildHelmRelease(ctx, kubeClient, &helmRelease)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.WriteString(manifest)
+	return err
+}
+
+// buildHelmRelease renders the chart of a HelmRelease with its merged
+// values, the way the helm-controller would install it.
+func buildHelmRelease(ctx context.Context, kubeClient client.Client, helmRelease *helmv2.HelmRelease) (string, error) {
+	// the chart is created by the helm-controller in the
+	// namespace of the source
+	sourceRef := helmRelease.Spec.Chart.Spec.SourceRef
+	sourceNamespace := helmRelease.Namespace
+	if sourceRef.Namespace != "" {
+		sourceNamespace = sourceRef.Namespace
+	}
+	chartName := types.NamespacedName{
+		Namespace: sourceNamespace,
+		Name:      fmt.Sprintf("%s-%s", helmRelease.Namespace, helmRelease.Name),
+	}
+	var chart sourcev1.HelmChart
+	if err := kubeClient.Get(ctx, chartName, &chart); err != nil {
+		return "", err
+	}
+	if chart.Status.Artifact == nil {
+		return "", fmt.Errorf("HelmChart %s/%s has no artifact", chart.Namespace, chart.Name)
+	}
+
+	data, err := downloadArtifact(ctx, chart.Status.Artifact.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download the chart: %w", err)
+	}
+	helmChart, err := loader.LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to load the chart: %w", err)
+	}
+
+	values, _, err := helmReleaseValues(ctx, kubeClient, helmRelease, false)
+	if err != nil {
+		return "", err
+	}
+
+	// a client-only dry-run renders the chart without the cluster,
+	// with the default capabilities of the Helm library
+	install := action.NewInstall(&action.Configuration{Log: logger.Debugf})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = helmRelease.GetReleaseName()
+	install.Namespace = helmRelease.GetReleaseNamespace()
+	install.IncludeCRDs = helmRelease.Spec.Install == nil || !helmRelease.Spec.Install.SkipCRDs
+	install.DisableHooks = helmRelease.Spec.Install != nil && helmRelease.Spec.Install.DisableHooks
+	release, err := install.Run(helmChart, values)
+	if err != nil {
+		return "", fmt.Errorf("failed to render the chart: %w", err)
+	}
+
+	// the hooks are written after the manifests, as helm template does
+	var manifests strings.Builder
+	manifests.WriteString(release.Manifest)
+	if !install.DisableHooks {
+		for _, hook := range release.Hooks {
+			fmt.Fprintf(&manifests, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+		}
+	}
+	return manifests.String(), nil
+}
+
+// downloadArtifact fetches an artifact from the source-controller,
+// through the service proxy of the API server, as its URL is only
+// reachable from within the cluster.
+func downloadArtifact(ctx context.Context, artifactURL string) ([]byte, error) {
+	u, err := url.Parse(artifactURL)
+	if err != nil {
+		return nil, err
+	}
+	// the host is <service>.<namespace>[.svc.cluster.local.]
+	host := strings.Split(u.Hostname(), ".")
+	if len(host) < 2 {
+		return nil, fmt.Errorf("unexpected artifact URL %s", artifactURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "http"
+	}
+
+	cfg, err := getKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return clientSet.CoreV1().Services(host[1]).ProxyGet(u.Scheme, host[0], port, u.Path, nil).DoRaw(ctx)
+}
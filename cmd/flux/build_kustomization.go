@@ -0,0 +1,291 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resid"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/yaml"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/flags"
+)
+
+var buildKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Build a Kustomization locally",
+	Long: `The build kustomization command renders the manifests of a local directory with the spec of a
+Kustomization from the cluster, and prints them as a multi-document YAML stream. As in the
+kustomize-controller, a kustomization.yaml is generated when the directory has none, the target
+namespace, images and patches of the Kustomization are applied, and the post build variables are
+substituted, except in the objects annotated with kustomize.toolkit.fluxcd.io/substitute: disabled.
+The variables set with --substitute and --substitute-from are added to the ones of the Kustomization.`,
+	Example: `  # Build the manifests of the local copy of a Kustomization's path
+  flux build kustomization my-app --path=./clusters/prod/my-app
+
+  # Preview the manifests with the variables of another environment
+  flux build kustomization my-app --path=./deploy/apps \
+    --substitute=cluster_env=production \
+    --substitute-from=ConfigMap/production-vars
+`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizationType),
+	RunE:              buildKsCmdRun,
+}
+
+type buildKsFlags struct {
+	path           string
+	substitute     []string
+	substituteFrom []string
+}
+
+var buildKsArgs buildKsFlags
+
+func init() {
+	buildKsCmd.Flags().StringVar(&buildKsArgs.path, "path", "", "path to the local directory of the manifests")
+	buildKsCmd.Flags().StringArrayVar(&buildKsArgs.substitute, "substitute", nil,
+		"variable substituted in the manifests, in the format '<name>=<value>', overriding the ones of the Kustomization")
+	buildKsCmd.Flags().StringSliceVar(&buildKsArgs.substituteFrom, "substitute-from", nil,
+		new(flags.KustomizationSubstituteFrom).Description()+", read after the ones of the Kustomization")
+
+	buildCmd.AddCommand(buildKsCmd)
+}
+
+func buildKsCmdRun(cmd *cobra.Command, args []string) error {
+	if buildKsArgs.path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      args[0],
+	}
+	var kustomization kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, namespacedName, &kustomization); err != nil {
+		return err
+	}
+
+	postBuild, err := makePostBuild(buildKsArgs.substitute, buildKsArgs.substituteFrom)
+	if err != nil {
+		return err
+	}
+	if postBuild != nil {
+		if kustomization.Spec.PostBuild == nil {
+			kustomization.Spec.PostBuild = &kustomizev1.PostBuild{}
+		}
+		kustomization.Spec.PostBuild.SubstituteFrom = append(kustomization.Spec.PostBuild.SubstituteFrom, postBuild.SubstituteFrom...)
+		for k, v := range postBuild.Substitute {
+			if kustomization.Spec.PostBuild.Substitute == nil {
+				kustomization.Spec.PostBuild.Substitute = map[string]string{}
+			}
+			kustomization.Spec.PostBuild.Substitute[k] = v
+		}
+	}
+
+	data, err := buildKustomization(ctx, kubeClient, &kustomization, buildKsArgs.path)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// buildKustomization renders the manifests in the given directory the
+// way the kustomize-controller would for the Kustomization.
+func buildKustomization(ctx context.Context, kubeClient client.Client, kustomization *kustomizev1.Kustomization, path string) ([]byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(abs); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+
+	// the spec of the Kustomization is applied by an overlay, so the
+	// local directory is left untouched
+	tmpDir, err := ioutil.TempDir("", kustomization.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	kus := kustypes.Kustomization{
+		TypeMeta: kustypes.TypeMeta{
+			APIVersion: kustypes.KustomizationVersion,
+			Kind:       kustypes.KustomizationKind,
+		},
+		Namespace: kustomization.Spec.TargetNamespace,
+	}
+	if hasKustomizationFile(abs) {
+		kus.Resources = []string{abs}
+	} else if kus.Resources, err = scanManifests(abs); err != nil {
+		return nil, err
+	}
+	for _, image := range kustomization.Spec.Images {
+		kus.Images = append(kus.Images, kustypes.Image{
+			Name:    image.Name,
+			NewName: image.NewName,
+			NewTag:  image.NewTag,
+			Digest:  image.Digest,
+		})
+	}
+	for _, patch := range kustomization.Spec.PatchesStrategicMerge {
+		kus.PatchesStrategicMerge = append(kus.PatchesStrategicMerge, kustypes.PatchStrategicMerge(patch.Raw))
+	}
+	for _, patch := range kustomization.Spec.PatchesJSON6902 {
+		data, err := json.Marshal(patch.Patch)
+		if err != nil {
+			return nil, err
+		}
+		kus.PatchesJson6902 = append(kus.PatchesJson6902, kustypes.Patch{
+			Patch: string(data),
+			Target: &kustypes.Selector{
+				Gvk: resid.Gvk{
+					Group:   patch.Target.Group,
+					Version: patch.Target.Version,
+					Kind:    patch.Target.Kind,
+				},
+				Namespace:          patch.Target.Namespace,
+				Name:               patch.Target.Name,
+				AnnotationSelector: patch.Target.AnnotationSelector,
+				LabelSelector:      patch.Target.LabelSelector,
+			},
+		})
+	}
+	kd, err := yaml.Marshal(kus)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, konfig.DefaultKustomizationFileName()), kd, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	opt := krusty.MakeDefaultOptions()
+	opt.DoLegacyResourceSort = true
+	opt.LoadRestrictions = kustypes.LoadRestrictionsNone
+	m, err := krusty.MakeKustomizer(filesys.MakeFsOnDisk(), opt).Run(tmpDir)
+	if err != nil {
+		return nil, fmt.Errorf("kustomize build failed: %w", err)
+	}
+
+	var variables map[string]string
+	if kustomization.Spec.PostBuild != nil {
+		resolved, err := kustomizationVariables(ctx, kubeClient, kustomization, false)
+		if err != nil {
+			return nil, err
+		}
+		variables = map[string]string{}
+		for k, v := range resolved {
+			variables[k] = v.Value
+		}
+	}
+
+	var docs []string
+	for _, res := range m.Resources() {
+		data, err := res.AsYAML()
+		if err != nil {
+			return nil, err
+		}
+		if variables != nil && res.GetAnnotations()[kustomizev1.GroupVersion.Group+"/substitute"] != "disabled" {
+			data = substituteVariables(data, variables)
+		}
+		docs = append(docs, string(data))
+	}
+	return []byte("---\n" + strings.Join(docs, "---\n")), nil
+}
+
+// hasKustomizationFile tells whether a directory has a kustomization
+// file of any of the recognised names.
+func hasKustomizationFile(dir string) bool {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// scanManifests lists the manifests in a directory which has no
+// kustomization file, as the kustomize-controller does: the YAML and
+// JSON files, and the sub-directories with a kustomization file,
+// which are not descended into.
+func scanManifests(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if info.IsDir() {
+			if hasKustomizationFile(path) {
+				paths = append(paths, path)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+var variableRegexp = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)(:=([^}]*))?\}`)
+
+// substituteVariables replaces the ${var} and ${var:=default}
+// references in a manifest. As in the kustomize-controller, undefined
+// variables without a default are replaced with an empty string.
+func substituteVariables(data []byte, variables map[string]string) []byte {
+	return variableRegexp.ReplaceAllFunc(data, func(ref []byte) []byte {
+		match := variableRegexp.FindSubmatch(ref)
+		if value, ok := variables[string(match[1])]; ok {
+			return []byte(value)
+		}
+		return match[3]
+	})
+}
@@ -17,20 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
-	"os/exec"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/apps/v1"
-	apimachineryversion "k8s.io/apimachinery/pkg/version"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1alpha1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
 	"github.com/fluxcd/pkg/version"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
@@ -40,13 +52,27 @@ var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check requirements and installation",
 	Long: `The check command will perform a series of checks to validate that
-the local environment is configured correctly and if the installed components are healthy.`,
+the local environment is configured correctly and if the installed components are healthy.
+The versions of the installed controllers and CRDs are compared with the ones supported by the CLI,
+with a warning for controllers of another version, and a failure for CRDs lacking an API version
+the CLI relies on. The source URLs given with --source-url are probed from the host and from a short-lived
+pod in the namespace of the controllers, to tell the network issues of the host from the egress issues
+of the cluster. The checks time out after 2 minutes, unless set otherwise with --timeout.`,
 	Example: `  # Run pre-installation checks
   flux check --pre
 
   # Run installation checks
   flux check
+
+  # Check that a Git repository is reachable from the host and from the cluster
+  flux check --pre --source-url=ssh://git@github.com/org/fleet-infra
+
+  # Run installation checks for a custom set of components
+  flux check --components=source-controller,kustomize-controller --components-extra=image-reflector-controller
 `,
+	Annotations: map[string]string{
+		timeoutAnnotation: "2m",
+	},
 	RunE: runCheckCmd,
 }
 
@@ -54,10 +80,8 @@ type checkFlags struct {
 	pre             bool
 	components      []string
 	extraComponents []string
-}
-
-type kubectlVersion struct {
-	ClientVersion *apimachineryversion.Info `json:"clientVersion"`
+	sourceURLs      []string
+	probeImage      string
 }
 
 var checkArgs checkFlags
@@ -69,24 +93,33 @@ func init() {
 		"list of components, accepts comma-separated values")
 	checkCmd.Flags().StringSliceVar(&checkArgs.extraComponents, "components-extra", nil,
 		"list of components in addition to those supplied or defaulted, accepts comma-separated values")
+	checkCmd.Flags().StringSliceVar(&checkArgs.sourceURLs, "source-url", nil,
+		"Git, Helm repository or bucket URLs to probe from the host and from a pod in the cluster, accepts comma-separated values")
+	checkCmd.Flags().StringVar(&checkArgs.probeImage, "probe-image", "busybox:1.33",
+		"container image of the pod probing the source URLs from the cluster, which must provide nc")
 	rootCmd.AddCommand(checkCmd)
 }
 
 func runCheckCmd(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
-	defer cancel()
+	components := append(checkArgs.components, checkArgs.extraComponents...)
+	if err := utils.ValidateComponents(components); err != nil {
+		return err
+	}
 
 	logger.Actionf("checking prerequisites")
 	checkFailed := false
 
 	fluxCheck()
 
-	if !kubectlCheck(ctx, ">=1.18.0-0") {
+	if !kubernetesCheck(">=1.16.0-0") {
 		checkFailed = true
 	}
 
-	if !kubernetesCheck(">=1.16.0-0") {
-		checkFailed = true
+	if len(checkArgs.sourceURLs) > 0 {
+		logger.Actionf("checking sources")
+		if !sourcesCheck(checkArgs.sourceURLs) {
+			checkFailed = true
+		}
 	}
 
 	if checkArgs.pre {
@@ -98,7 +131,12 @@ func runCheckCmd(cmd *cobra.Command, args []string) error {
 	}
 
 	logger.Actionf("checking controllers")
-	if !componentsCheck() {
+	if !componentsCheck(components) {
+		checkFailed = true
+	}
+
+	logger.Actionf("checking crds")
+	if !crdsCheck() {
 		checkFailed = true
 	}
 	if checkFailed {
@@ -130,105 +168,176 @@ func fluxCheck() {
 	}
 }
 
-func kubectlCheck(ctx context.Context, constraint string) bool {
-	_, err := exec.LookPath("kubectl")
+func kubernetesCheck(constraint string) bool {
+	cfg, err := getKubeConfig()
 	if err != nil {
-		logger.Failuref("kubectl not found")
+		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
 		return false
 	}
 
-	kubectlArgs := []string{"version", "--client", "--output", "json"}
-	output, err := utils.ExecKubectlCommand(ctx, utils.ModeCapture, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...)
+	clientSet, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
-		logger.Failuref("kubectl version can't be determined")
+		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
 		return false
 	}
 
-	kv := &kubectlVersion{}
-	if err = json.Unmarshal([]byte(output), kv); err != nil {
-		logger.Failuref("kubectl version output can't be unmarshalled")
+	kv, err := clientSet.Discovery().ServerVersion()
+	if err != nil {
+		logger.Failuref("Kubernetes API call failed: %s", err.Error())
 		return false
 	}
 
-	v, err := version.ParseVersion(kv.ClientVersion.GitVersion)
+	v, err := version.ParseVersion(kv.String())
 	if err != nil {
-		logger.Failuref("kubectl version can't be parsed")
+		logger.Failuref("Kubernetes version can't be determined")
 		return false
 	}
 
 	c, _ := semver.NewConstraint(constraint)
 	if !c.Check(v) {
-		logger.Failuref("kubectl version %s < %s", v.Original(), constraint)
+		logger.Failuref("Kubernetes version %s < %s", v.Original(), constraint)
 		return false
 	}
 
-	logger.Successf("kubectl %s %s", v.String(), constraint)
+	logger.Successf("Kubernetes %s %s", v.String(), constraint)
 	return true
 }
 
-func kubernetesCheck(constraint string) bool {
-	cfg, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext)
-	if err != nil {
-		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
-		return false
-	}
+func componentsCheck(components []string) bool {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
 
-	clientSet, err := kubernetes.NewForConfig(cfg)
+	kubeClient, err := getKubeClient()
 	if err != nil {
-		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
 		return false
 	}
 
-	kv, err := clientSet.Discovery().ServerVersion()
+	statusChecker, err := NewStatusChecker(time.Second, rootArgs.timeout, defaultConcurrency)
 	if err != nil {
-		logger.Failuref("Kubernetes API call failed: %s", err.Error())
 		return false
 	}
 
-	v, err := version.ParseVersion(kv.String())
+	// the embedded manifests hold the images this version installs
+	expectedImages, err := embeddedImages()
 	if err != nil {
-		logger.Failuref("Kubernetes version can't be determined")
-		return false
+		expectedImages = map[string]string{}
 	}
 
-	c, _ := semver.NewConstraint(constraint)
-	if !c.Check(v) {
-		logger.Failuref("Kubernetes version %s < %s", v.Original(), constraint)
-		return false
+	ok := true
+	for _, component := range components {
+		namespacedName := types.NamespacedName{
+			Namespace: rootArgs.namespace,
+			Name:      component,
+		}
+		var d v1.Deployment
+		if err := kubeClient.Get(ctx, namespacedName, &d); err != nil {
+			logger.Failuref("%s: deployment not found", component)
+			ok = false
+			continue
+		}
+		if err := statusChecker.Assess(d.Name); err != nil {
+			ok = false
+		} else {
+			logger.Successf("%s: healthy", d.Name)
+		}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			logger.Actionf(c.Image)
+			// the registry may have been changed at install time, so
+			// only the tags are compared
+			if expected, found := expectedImages[component]; found && imageTag(c.Image) != imageTag(expected) {
+				logger.Warningf("%s: version %s differs from %s, which is the version supported by flux %s",
+					component, imageTag(c.Image), imageTag(expected), VERSION)
+			}
+		}
 	}
-
-	logger.Successf("Kubernetes %s %s", v.String(), constraint)
-	return true
+	return ok
 }
 
-func componentsCheck() bool {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+// crdsCheck verifies that the installed toolkit CRDs serve the API
+// versions this version of the CLI uses.
+func crdsCheck() bool {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return false
 	}
 
-	statusChecker, err := NewStatusChecker(time.Second, rootArgs.timeout)
-	if err != nil {
+	required := map[string]string{
+		sourcev1.GroupVersion.Group:       sourcev1.GroupVersion.Version,
+		kustomizev1.GroupVersion.Group:    kustomizev1.GroupVersion.Version,
+		helmv2.GroupVersion.Group:         helmv2.GroupVersion.Version,
+		notificationv1.GroupVersion.Group: notificationv1.GroupVersion.Version,
+		imagev1.GroupVersion.Group:        imagev1.GroupVersion.Version,
+		autov1.GroupVersion.Group:         autov1.GroupVersion.Version,
+	}
+
+	selector := client.MatchingLabels{"app.kubernetes.io/instance": rootArgs.namespace}
+	var list apiextensionsv1.CustomResourceDefinitionList
+	if err := kubeClient.List(ctx, &list, selector); err != nil {
+		logger.Failuref("CRDs can't be listed: %s", err.Error())
 		return false
 	}
 
 	ok := true
-	selector := client.MatchingLabels{"app.kubernetes.io/instance": rootArgs.namespace}
-	var list v1.DeploymentList
-	if err := kubeClient.List(ctx, &list, client.InNamespace(rootArgs.namespace), selector); err == nil {
-		for _, d := range list.Items {
-			if err := statusChecker.Assess(d.Name); err != nil {
-				ok = false
-			} else {
-				logger.Successf("%s: healthy", d.Name)
-			}
-			for _, c := range d.Spec.Template.Spec.Containers {
-				logger.Actionf(c.Image)
+	for _, crd := range list.Items {
+		version, found := required[crd.Spec.Group]
+		if !found {
+			continue
+		}
+		served := false
+		for _, v := range crd.Spec.Versions {
+			if v.Name == version && v.Served {
+				served = true
 			}
 		}
+		if !served {
+			logger.Failuref("%s: %s is not served, it is required by flux %s", crd.Name, version, VERSION)
+			ok = false
+			continue
+		}
+		logger.Successf("%s: %s", crd.Name, version)
 	}
 	return ok
 }
+
+// embeddedImages returns the container image of each component, as
+// found in the manifests embedded in the CLI.
+func embeddedImages() (map[string]string, error) {
+	manifests, err := fs.ReadDir(embeddedManifests, "manifests")
+	if err != nil {
+		return nil, err
+	}
+
+	images := map[string]string{}
+	for _, manifest := range manifests {
+		data, err := fs.ReadFile(embeddedManifests, path.Join("manifests", manifest.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading file failed: %w", err)
+		}
+		decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+		for {
+			var d v1.Deployment
+			if err := decoder.Decode(&d); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, err
+			}
+			if d.Kind != "Deployment" || len(d.Spec.Template.Spec.Containers) == 0 {
+				continue
+			}
+			images[d.Name] = d.Spec.Template.Spec.Containers[0].Image
+		}
+	}
+	return images, nil
+}
+
+// imageTag returns the tag of a container image reference.
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
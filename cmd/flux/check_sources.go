@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sourceProbeTimeout is the timeout of the connection attempts of the
+// source probes.
+const sourceProbeTimeout = 5 * time.Second
+
+// sourceDefaultPorts are the ports of the source URLs without one, per
+// scheme.
+var sourceDefaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ssh":   "22",
+	"git":   "9418",
+}
+
+// sourceAddress returns the host:port address of a Git, Helm repository
+// or bucket URL.
+func sourceAddress(sourceURL string) (string, error) {
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid source URL '%s': %w", sourceURL, err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("invalid source URL '%s': no host, the URL must start with a scheme, e.g. https:// or ssh://", sourceURL)
+	}
+	port := u.Port()
+	if port == "" {
+		var ok bool
+		if port, ok = sourceDefaultPorts[u.Scheme]; !ok {
+			return "", fmt.Errorf("invalid source URL '%s': no port, and no default port for the scheme %s", sourceURL, u.Scheme)
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// sourcesCheck probes the source URLs from the host and from a pod in
+// the cluster, to tell local network issues from the egress issues of
+// the cluster.
+func sourcesCheck(sourceURLs []string) bool {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
+		return false
+	}
+
+	// the namespace of the controllers doesn't exist before the install
+	namespace := rootArgs.namespace
+	var ns corev1.Namespace
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: namespace}, &ns); apierrors.IsNotFound(err) {
+		logger.Warningf("namespace %s not found, probing from the default namespace", namespace)
+		namespace = "default"
+	}
+
+	ok := true
+	for _, sourceURL := range sourceURLs {
+		address, err := sourceAddress(sourceURL)
+		if err != nil {
+			logger.Failuref(err.Error())
+			ok = false
+			continue
+		}
+
+		hostErr := probeFromHost(address)
+		reachable, output, err := probeFromCluster(ctx, kubeClient, namespace, address)
+		if err != nil {
+			logger.Failuref("%s: probing from the cluster failed: %s", sourceURL, err.Error())
+			ok = false
+			continue
+		}
+		switch {
+		case hostErr == nil && reachable:
+			logger.Successf("%s: reachable from the host and the cluster", sourceURL)
+		case hostErr == nil:
+			logger.Failuref("%s: reachable from the host but not from the cluster, check the egress of the cluster, e.g. network policies, firewalls or proxies%s",
+				sourceURL, output)
+			ok = false
+		case reachable:
+			logger.Warningf("%s: reachable from the cluster but not from the host, check the network of the host: %s",
+				sourceURL, hostErr.Error())
+		default:
+			logger.Failuref("%s: unreachable from the host and the cluster, check the URL: %s", sourceURL, hostErr.Error())
+			ok = false
+		}
+	}
+	return ok
+}
+
+func probeFromHost(address string) error {
+	conn, err := net.DialTimeout("tcp", address, sourceProbeTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// probeFromCluster connects to the address from a short-lived pod, which
+// in the namespace of the controllers is subject to the same network
+// policies as the source-controller. It returns whether the connection
+// succeeded, with the output of the pod when it didn't.
+func probeFromCluster(ctx context.Context, kubeClient client.Client, namespace, address string) (bool, string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return false, "", err
+	}
+	nobody := int64(65534)
+	nonRoot := true
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "flux-source-probe-",
+			Namespace:    namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "flux-source-probe",
+				"app.kubernetes.io/managed-by": "flux",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:    "probe",
+					Image:   checkArgs.probeImage,
+					Command: []string{"nc", "-z", "-w", fmt.Sprintf("%.0f", sourceProbeTimeout.Seconds()), host, port},
+					SecurityContext: &corev1.SecurityContext{
+						RunAsUser:    &nobody,
+						RunAsNonRoot: &nonRoot,
+					},
+				},
+			},
+		},
+	}
+	if err := kubeClient.Create(ctx, &pod); err != nil {
+		return false, "", fmt.Errorf("creating the probe pod failed: %w", err)
+	}
+	defer func() {
+		background := metav1.DeletePropagationBackground
+		if err := kubeClient.Delete(context.Background(), &pod, &client.DeleteOptions{PropagationPolicy: &background}); err != nil {
+			logger.Warningf("deleting the probe pod %s failed: %s", pod.Name, err.Error())
+		}
+	}()
+	logger.Debugf("probing %s from pod %s/%s", address, pod.Namespace, pod.Name)
+
+	namespacedName := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout, func() (bool, error) {
+		if err := kubeClient.Get(ctx, namespacedName, &pod); err != nil {
+			return false, err
+		}
+		return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed, nil
+	}); err != nil {
+		return false, "", fmt.Errorf("the probe pod %s did not complete: %w", pod.Name, err)
+	}
+
+	if pod.Status.Phase == corev1.PodFailed {
+		return false, probeLogs(ctx, pod), nil
+	}
+	return true, "", nil
+}
+
+// probeLogs returns the output of the probe pod, as the suffix of an
+// error message.
+func probeLogs(ctx context.Context, pod corev1.Pod) string {
+	cfg, err := getKubeConfig()
+	if err != nil {
+		return ""
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return ""
+	}
+	stream, err := clientSet.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).Stream(ctx)
+	if err != nil {
+		return ""
+	}
+	defer stream.Close()
+	logs, err := ioutil.ReadAll(stream)
+	if err != nil || len(strings.TrimSpace(string(logs))) == 0 {
+		return ""
+	}
+	return ": " + strings.TrimSpace(string(logs))
+}
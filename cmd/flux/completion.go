@@ -17,7 +17,20 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var completionCmd = &cobra.Command{
@@ -29,3 +42,77 @@ var completionCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(completionCmd)
 }
+
+// completionTimeout bounds the calls to the Kubernetes API made to
+// complete a command line, so that the shell is never kept waiting.
+const completionTimeout = 5 * time.Second
+
+// resourceNamesCompletionFunc returns a function completing the first
+// argument of a command with the names of the objects of a kind found
+// in the namespace of the command.
+func resourceNamesCompletionFunc(t apiType) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		if !cmd.Flags().Changed("namespace") {
+			// the root pre-run is not run for the completion requests
+			setDefaultNamespace(cmd)
+		}
+
+		ctx, cancel := context.WithTimeout(rootCtx, completionTimeout)
+		defer cancel()
+
+		kubeClient, err := getKubeClient()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		gvk, err := toolkitKind(kubeClient.Scheme(), t.kind)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := kubeClient.List(ctx, list, client.InNamespace(rootArgs.namespace)); err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		var names []string
+		for _, item := range list.Items {
+			if strings.HasPrefix(item.GetName(), toComplete) {
+				names = append(names, item.GetName())
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// toolkitKind returns the group, version and kind of a toolkit kind
+// registered in the scheme.
+func toolkitKind(scheme *runtime.Scheme, kind string) (schema.GroupVersionKind, error) {
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.Kind == kind && strings.HasSuffix(gvk.Group, ".toolkit.fluxcd.io") {
+			return gvk, nil
+		}
+	}
+	return schema.GroupVersionKind{}, fmt.Errorf("unknown kind %s", kind)
+}
+
+// contextNamesCompletionFunc completes the --context flag with the
+// names of the contexts of the kubeconfig files in use.
+func contextNamesCompletionFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	rules := &clientcmd.ClientConfigLoadingRules{Precedence: utils.SplitKubeConfigPath(rootArgs.kubeconfig)}
+	config, err := rules.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var names []string
+	for name := range config.Contexts {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/oci"
+)
+
+// cosignVerifyProvider is the OCIRepository verification provider of
+// the artifacts signed with cosign.
+const cosignVerifyProvider = "cosign"
+
+// signArtifact signs the artifact at the given address with cosign,
+// with the private key at keyPath, or keyless when keyPath is empty.
+func signArtifact(ctx context.Context, url, keyPath string) error {
+	args := []string{"sign"}
+	if keyPath != "" {
+		args = append(args, "--key", keyPath)
+	}
+	args = append(args, strings.TrimPrefix(url, oci.URLPrefix))
+	_, err := utils.ExecCosignCommand(ctx, args...)
+	return err
+}
+
+// verifyArtifact verifies the cosign signature of the artifact at the
+// given address, with the public key at keyPath, or keyless when
+// keyPath is empty.
+func verifyArtifact(ctx context.Context, url, keyPath string) error {
+	args := []string{"verify"}
+	if keyPath != "" {
+		args = append(args, "--key", keyPath)
+	}
+	args = append(args, strings.TrimPrefix(url, oci.URLPrefix))
+	_, err := utils.ExecCosignCommand(ctx, args...)
+	return err
+}
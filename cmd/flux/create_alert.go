@@ -44,6 +44,14 @@ var createAlertCmd = &cobra.Command{
   --event-source Kustomization/flux-system \
   --provider-ref slack \
   flux-system
+
+  # Create an Alert definition on disk without applying it on the cluster
+  flux create alert \
+  --event-severity info \
+  --event-source Kustomization/flux-system \
+  --provider-ref slack \
+  flux-system \
+  --export > alert.yaml
 `,
 	RunE: createAlertCmdRun,
 }
@@ -116,13 +124,13 @@ func createAlertCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	if createArgs.export {
-		return exportAlert(alert)
+		return printExport(exportAlert(&alert))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
@@ -30,8 +30,6 @@ import (
 
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
 	"github.com/fluxcd/pkg/apis/meta"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var createAlertProviderCmd = &cobra.Command{
@@ -50,6 +48,13 @@ var createAlertProviderCmd = &cobra.Command{
   --type github \
   --address https://github.com/stefanprodan/podinfo \
   --secret-ref github-token
+
+  # Create a Provider definition on disk without applying it on the cluster
+  flux create alert-provider slack \
+  --type slack \
+  --channel general \
+  --secret-ref webhook-url \
+  --export > provider.yaml
 `,
 	RunE: createAlertProviderCmdRun,
 }
@@ -113,13 +118,13 @@ func createAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	if createArgs.export {
-		return exportAlertProvider(provider)
+		return printExport(exportAlertProvider(&provider))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
@@ -38,13 +38,14 @@ import (
 	"sigs.k8s.io/yaml"
 
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 )
 
 var createHelmReleaseCmd = &cobra.Command{
 	Use:     "helmrelease [name]",
 	Aliases: []string{"hr"},
 	Short:   "Create or update a HelmRelease resource",
-	Long:    "The helmrelease create command generates a HelmRelease resource for a given HelmRepository source.",
+	Long:    "The helmrelease create command generates a HelmRelease resource for a given HelmRepository, GitRepository or Bucket source.",
 	Example: `  # Create a HelmRelease with a chart from a HelmRepository source
   flux create hr podinfo \
     --interval=10m \
@@ -79,6 +80,12 @@ var createHelmReleaseCmd = &cobra.Command{
     --chart=podinfo \
     --values-from=Secret/my-secret-values
 
+  # Create a HelmRelease with values merged from a ConfigMap and a Secret
+  flux -n app create hr podinfo \
+    --source=HelmRepository/podinfo \
+    --chart=podinfo \
+    --values-from=ConfigMap/my-values,Secret/my-secret-values
+
   # Create a HelmRelease with a custom release name
   flux create hr podinfo \
     --release-name=podinfo-dev
@@ -91,6 +98,27 @@ var createHelmReleaseCmd = &cobra.Command{
     --source=HelmRepository/podinfo \
     --chart=podinfo
 
+  # Create a HelmRelease installed by a tenant, impersonating its service account
+  flux -n team-a create hr podinfo \
+    --source=HelmRepository/podinfo \
+    --chart=podinfo \
+    --target-namespace=team-a \
+    --service-account=team-a-reconciler
+
+  # Create a HelmRelease rolled back when an upgrade fails after two retries
+  flux create hr podinfo \
+    --source=HelmRepository/podinfo \
+    --chart=podinfo \
+    --upgrade-retries=2 \
+    --upgrade-remediation=rollback \
+    --remediate-last-failure
+
+  # Create a HelmRelease running the Helm tests of the chart after each install and upgrade
+  flux create hr podinfo \
+    --source=HelmRepository/podinfo \
+    --chart=podinfo \
+    --test-enable
+
   # Create a HelmRelease definition on disk without applying it on the cluster
   flux create hr podinfo \
     --source=HelmRepository/podinfo \
@@ -109,8 +137,16 @@ type helmReleaseFlags struct {
 	chartVersion    string
 	targetNamespace string
 	valuesFile      []string
-	valuesFrom      flags.HelmReleaseValuesFrom
+	valuesFrom      []string
 	saName          string
+
+	installRetries       int
+	upgradeRetries       int
+	upgradeRemediation   flags.RemediationStrategy
+	remediateLastFailure bool
+
+	testEnable         bool
+	testIgnoreFailures bool
 }
 
 var helmReleaseArgs helmReleaseFlags
@@ -124,7 +160,13 @@ func init() {
 	createHelmReleaseCmd.Flags().StringVar(&helmReleaseArgs.targetNamespace, "target-namespace", "", "namespace to install this release, defaults to the HelmRelease namespace")
 	createHelmReleaseCmd.Flags().StringVar(&helmReleaseArgs.saName, "service-account", "", "the name of the service account to impersonate when reconciling this HelmRelease")
 	createHelmReleaseCmd.Flags().StringArrayVar(&helmReleaseArgs.valuesFile, "values", nil, "local path to values.yaml files")
-	createHelmReleaseCmd.Flags().Var(&helmReleaseArgs.valuesFrom, "values-from", helmReleaseArgs.valuesFrom.Description())
+	createHelmReleaseCmd.Flags().StringSliceVar(&helmReleaseArgs.valuesFrom, "values-from", nil, new(flags.HelmReleaseValuesFrom).Description()+", also accepts comma-separated values")
+	createHelmReleaseCmd.Flags().IntVar(&helmReleaseArgs.installRetries, "install-retries", 0, "number of times a failed install is retried, -1 retries forever")
+	createHelmReleaseCmd.Flags().IntVar(&helmReleaseArgs.upgradeRetries, "upgrade-retries", 0, "number of times a failed upgrade is retried after its remediation, -1 retries forever")
+	createHelmReleaseCmd.Flags().Var(&helmReleaseArgs.upgradeRemediation, "upgrade-remediation", helmReleaseArgs.upgradeRemediation.Description())
+	createHelmReleaseCmd.Flags().BoolVar(&helmReleaseArgs.testEnable, "test-enable", false, "run the Helm tests of the chart after an install or upgrade, failing the release when they fail")
+	createHelmReleaseCmd.Flags().BoolVar(&helmReleaseArgs.testIgnoreFailures, "test-ignore-failures", false, "keep the release when its Helm tests fail, requires --test-enable")
+	createHelmReleaseCmd.Flags().BoolVar(&helmReleaseArgs.remediateLastFailure, "remediate-last-failure", false, "remediate the last failed install or upgrade once the retries are exhausted, leaving the release as it was before it")
 	createCmd.AddCommand(createHelmReleaseCmd)
 }
 
@@ -134,10 +176,33 @@ func createHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
+	if helmReleaseArgs.source.String() == "" {
+		return fmt.Errorf("source is required")
+	}
+
 	if helmReleaseArgs.chart == "" {
 		return fmt.Errorf("chart name or path is required")
 	}
 
+	if err := validateImpersonation(helmReleaseArgs.targetNamespace, helmReleaseArgs.saName); err != nil {
+		return err
+	}
+
+	if helmReleaseArgs.testIgnoreFailures && !helmReleaseArgs.testEnable {
+		return fmt.Errorf("--test-ignore-failures requires --test-enable")
+	}
+
+	// Charts from GitRepository and Bucket sources are referenced by their
+	// path relative to the root of the source artifact.
+	chart := helmReleaseArgs.chart
+	if helmReleaseArgs.source.Kind != sourcev1.HelmRepositoryKind {
+		var chartPath flags.SafeRelativePath
+		if err := chartPath.Set(chart); err != nil {
+			return fmt.Errorf("invalid chart path: %w", err)
+		}
+		chart = chartPath.String()
+	}
+
 	sourceLabels, err := parseLabels()
 	if err != nil {
 		return err
@@ -162,7 +227,7 @@ func createHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 			TargetNamespace: helmReleaseArgs.targetNamespace,
 			Chart: helmv2.HelmChartTemplate{
 				Spec: helmv2.HelmChartTemplateSpec{
-					Chart:   helmReleaseArgs.chart,
+					Chart:   chart,
 					Version: helmReleaseArgs.chartVersion,
 					SourceRef: helmv2.CrossNamespaceObjectReference{
 						Kind: helmReleaseArgs.source.Kind,
@@ -178,6 +243,15 @@ func createHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 		helmRelease.Spec.ServiceAccountName = helmReleaseArgs.saName
 	}
 
+	setHelmReleaseRemediation(&helmRelease)
+
+	if helmReleaseArgs.testEnable {
+		helmRelease.Spec.Test = &helmv2.Test{
+			Enable:         true,
+			IgnoreFailures: helmReleaseArgs.testIgnoreFailures,
+		}
+	}
+
 	if len(helmReleaseArgs.valuesFile) > 0 {
 		var valuesMap map[string]interface{}
 		for _, v := range helmReleaseArgs.valuesFile {
@@ -211,25 +285,33 @@ func createHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 		helmRelease.Spec.Values = &apiextensionsv1.JSON{Raw: jsonRaw}
 	}
 
-	if helmReleaseArgs.valuesFrom.String() != "" {
-		helmRelease.Spec.ValuesFrom = []helmv2.ValuesReference{{
-			Kind: helmReleaseArgs.valuesFrom.Kind,
-			Name: helmReleaseArgs.valuesFrom.Name,
-		}}
+	for _, v := range helmReleaseArgs.valuesFrom {
+		var ref flags.HelmReleaseValuesFrom
+		if err := ref.Set(v); err != nil {
+			return fmt.Errorf("invalid --values-from '%s': %w", v, err)
+		}
+		helmRelease.Spec.ValuesFrom = append(helmRelease.Spec.ValuesFrom, helmv2.ValuesReference{
+			Kind: ref.Kind,
+			Name: ref.Name,
+		})
 	}
 
 	if createArgs.export {
-		return exportHelmRelease(helmRelease)
+		return printExport(exportHelmRelease(&helmRelease))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
+	if err := checkServiceAccount(ctx, kubeClient, rootArgs.namespace, helmReleaseArgs.saName); err != nil {
+		return err
+	}
+
 	logger.Actionf("applying HelmRelease")
 	namespacedName, err := upsertHelmRelease(ctx, kubeClient, &helmRelease)
 	if err != nil {
@@ -247,6 +329,39 @@ func createHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// setHelmReleaseRemediation sets the install and upgrade remediation
+// of a HelmRelease from the remediation flags. Without them, a failed
+// upgrade is neither retried nor rolled back.
+func setHelmReleaseRemediation(helmRelease *helmv2.HelmRelease) {
+	var remediateLastFailure *bool
+	if helmReleaseArgs.remediateLastFailure {
+		remediateLastFailure = &helmReleaseArgs.remediateLastFailure
+	}
+
+	if helmReleaseArgs.installRetries != 0 || remediateLastFailure != nil {
+		helmRelease.Spec.Install = &helmv2.Install{
+			Remediation: &helmv2.InstallRemediation{
+				Retries:              helmReleaseArgs.installRetries,
+				RemediateLastFailure: remediateLastFailure,
+			},
+		}
+	}
+
+	if helmReleaseArgs.upgradeRetries != 0 || helmReleaseArgs.upgradeRemediation != "" || remediateLastFailure != nil {
+		remediation := &helmv2.UpgradeRemediation{
+			Retries:              helmReleaseArgs.upgradeRetries,
+			RemediateLastFailure: remediateLastFailure,
+		}
+		if helmReleaseArgs.upgradeRemediation != "" {
+			strategy := helmv2.RemediationStrategy(helmReleaseArgs.upgradeRemediation)
+			remediation.Strategy = &strategy
+		}
+		helmRelease.Spec.Upgrade = &helmv2.Upgrade{
+			Remediation: remediation,
+		}
+	}
+}
+
 func upsertHelmRelease(ctx context.Context, kubeClient client.Client,
 	helmRelease *helmv2.HelmRelease) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
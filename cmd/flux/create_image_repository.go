@@ -58,6 +58,11 @@ An ImageRepository object specifies an image repository to scan.`,
   flux create image repository app-repo \
     --cert-secret-ref client-cert \
     --image registry.example.com/private/app --interval 5m
+
+  # Create an ImageRepository definition on disk without applying it on the cluster
+  flux create image repository alpine-repo \
+    --image alpine --interval 20m \
+    --export > alpine-repo.yaml
 `,
 	RunE: createImageRepositoryRun,
 }
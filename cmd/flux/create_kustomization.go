@@ -19,6 +19,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -63,11 +64,44 @@ var createKsCmd = &cobra.Command{
     --interval=5m \
     --validation=client
 
+  # Create a Kustomization resource that depends on Kustomizations of other namespaces
+  flux create kustomization apps \
+    --depends-on=infra/crds,infra/policies \
+    --source=webapp \
+    --path="./deploy/overlays/dev" \
+    --prune=true \
+    --interval=5m
+
+  # Create a Kustomization resource reconciled as a tenant, in the tenant namespace
+  flux create kustomization team-a \
+    --namespace=team-a \
+    --source=GitRepository/team-a \
+    --path="./deploy" \
+    --target-namespace=team-a \
+    --service-account=team-a-reconciler
+
+  # Create a Kustomization resource substituting the variables of the cluster in the manifests
+  flux create kustomization apps \
+    --source=webapp \
+    --path="./deploy/apps" \
+    --prune=true \
+    --interval=5m \
+    --substitute=cluster_env=staging \
+    --substitute-from=ConfigMap/cluster-vars,Secret/cluster-secrets
+
   # Create a Kustomization resource that references a Bucket
   flux create kustomization secrets \
     --source=Bucket/secrets \
     --prune=true \
     --interval=5m
+
+  # Create a Kustomization definition on disk without applying it on the cluster
+  flux create kustomization webapp \
+    --source=webapp \
+    --path="./deploy/overlays/dev" \
+    --prune=true \
+    --interval=5m \
+    --export > webapp-kustomization.yaml
 `,
 	RunE: createKsCmdRun,
 }
@@ -84,6 +118,9 @@ type kustomizationFlags struct {
 	decryptionProvider flags.DecryptionProvider
 	decryptionSecret   string
 	targetNamespace    string
+	kubeConfigSecret   string
+	substitute         []string
+	substituteFrom     []string
 }
 
 var kustomizationArgs = NewKustomizationFlags()
@@ -95,11 +132,17 @@ func init() {
 	createKsCmd.Flags().StringArrayVar(&kustomizationArgs.healthCheck, "health-check", nil, "workload to be included in the health assessment, in the format '<kind>/<name>.<namespace>'")
 	createKsCmd.Flags().DurationVar(&kustomizationArgs.healthTimeout, "health-check-timeout", 2*time.Minute, "timeout of health checking operations")
 	createKsCmd.Flags().StringVar(&kustomizationArgs.validation, "validation", "", "validate the manifests before applying them on the cluster, can be 'client' or 'server'")
-	createKsCmd.Flags().StringArrayVar(&kustomizationArgs.dependsOn, "depends-on", nil, "Kustomization that must be ready before this Kustomization can be applied, supported formats '<name>' and '<namespace>/<name>'")
+	createKsCmd.Flags().StringArrayVar(&kustomizationArgs.dependsOn, "depends-on", nil, "Kustomizations that must be ready before this Kustomization can be applied, supported formats '<name>' and '<namespace>/<name>', accepts comma-separated values")
 	createKsCmd.Flags().StringVar(&kustomizationArgs.saName, "service-account", "", "the name of the service account to impersonate when reconciling this Kustomization")
 	createKsCmd.Flags().Var(&kustomizationArgs.decryptionProvider, "decryption-provider", kustomizationArgs.decryptionProvider.Description())
-	createKsCmd.Flags().StringVar(&kustomizationArgs.decryptionSecret, "decryption-secret", "", "set the Kubernetes secret name that contains the OpenPGP private keys used for sops decryption")
+	createKsCmd.Flags().StringVar(&kustomizationArgs.decryptionSecret, "decryption-secret", "", "set the Kubernetes secret name that contains the age or OpenPGP private keys used for sops decryption")
 	createKsCmd.Flags().StringVar(&kustomizationArgs.targetNamespace, "target-namespace", "", "overrides the namespace of all Kustomization objects reconciled by this Kustomization")
+	createKsCmd.Flags().StringVar(&kustomizationArgs.kubeConfigSecret, "kubeconfig-secret-ref", "",
+		"the name of the Kubernetes secret holding the kubeconfig of the remote cluster the manifests are applied on, as created by 'flux create secret kubeconfig'")
+	createKsCmd.Flags().StringArrayVar(&kustomizationArgs.substitute, "substitute", nil,
+		"variable substituted in the manifests after the build, in the format '<name>=<value>', overriding the ones read from --substitute-from")
+	createKsCmd.Flags().StringSliceVar(&kustomizationArgs.substituteFrom, "substitute-from", nil,
+		new(flags.KustomizationSubstituteFrom).Description()+", also accepts comma-separated values, read in the given order")
 	createCmd.AddCommand(createKsCmd)
 }
 
@@ -121,6 +164,12 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 	if !strings.HasPrefix(kustomizationArgs.path.String(), "./") {
 		return fmt.Errorf("path must begin with ./")
 	}
+	if err := validateImpersonation(kustomizationArgs.targetNamespace, kustomizationArgs.saName); err != nil {
+		return err
+	}
+	if kustomizationArgs.decryptionSecret != "" && kustomizationArgs.decryptionProvider == "" {
+		return fmt.Errorf("--decryption-secret requires --decryption-provider")
+	}
 
 	if !createArgs.export {
 		logger.Generatef("generating Kustomization")
@@ -209,18 +258,37 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	postBuild, err := makePostBuild(kustomizationArgs.substitute, kustomizationArgs.substituteFrom)
+	if err != nil {
+		return err
+	}
+	kustomization.Spec.PostBuild = postBuild
+
+	if kustomizationArgs.kubeConfigSecret != "" {
+		kustomization.Spec.KubeConfig = &kustomizev1.KubeConfig{
+			SecretRef: meta.LocalObjectReference{Name: kustomizationArgs.kubeConfigSecret},
+		}
+	}
+
 	if createArgs.export {
-		return exportKs(kustomization)
+		return printExport(exportKustomization(&kustomization))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
+	if err := validateDependsOn(ctx, kubeClient, &kustomization); err != nil {
+		return err
+	}
+	if err := checkServiceAccount(ctx, kubeClient, rootArgs.namespace, kustomizationArgs.saName); err != nil {
+		return err
+	}
+
 	logger.Actionf("applying Kustomization")
 	namespacedName, err := upsertKustomization(ctx, kubeClient, &kustomization)
 	if err != nil {
@@ -293,3 +361,115 @@ func isKustomizationReady(ctx context.Context, kubeClient client.Client,
 		return false, nil
 	}
 }
+
+// validateDependsOn checks that the dependencies of a Kustomization
+// exist, and that depending on them doesn't make a cycle, which would
+// keep the Kustomizations of the cycle from ever being applied.
+func validateDependsOn(ctx context.Context, kubeClient client.Client, kustomization *kustomizev1.Kustomization) error {
+	if len(kustomization.Spec.DependsOn) == 0 {
+		return nil
+	}
+
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return err
+	}
+	graph := map[types.NamespacedName][]types.NamespacedName{}
+	for _, item := range list.Items {
+		graph[types.NamespacedName{Namespace: item.Namespace, Name: item.Name}] = kustomizationDependsOn(&item)
+	}
+
+	self := types.NamespacedName{Namespace: kustomization.Namespace, Name: kustomization.Name}
+	deps := kustomizationDependsOn(kustomization)
+	for _, dep := range deps {
+		if dep == self {
+			return fmt.Errorf("Kustomization %s can't depend on itself", self)
+		}
+		if _, ok := graph[dep]; !ok {
+			return fmt.Errorf("dependency %s not found", dep)
+		}
+	}
+	graph[self] = deps
+
+	// a cycle goes through the new dependencies, back to the Kustomization
+	var path []types.NamespacedName
+	visited := map[types.NamespacedName]bool{}
+	var visit func(name types.NamespacedName) bool
+	visit = func(name types.NamespacedName) bool {
+		path = append(path, name)
+		for _, dep := range graph[name] {
+			if dep == self {
+				path = append(path, dep)
+				return true
+			}
+			if !visited[dep] {
+				visited[dep] = true
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if visit(self) {
+		var names []string
+		for _, name := range path {
+			names = append(names, name.String())
+		}
+		return fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+	}
+	return nil
+}
+
+// kustomizationDependsOn returns the names of the dependencies of a
+// Kustomization, which default to its namespace.
+func kustomizationDependsOn(kustomization *kustomizev1.Kustomization) []types.NamespacedName {
+	var deps []types.NamespacedName
+	for _, dep := range kustomization.Spec.DependsOn {
+		name := types.NamespacedName{Namespace: dep.Namespace, Name: dep.Name}
+		if name.Namespace == "" {
+			name.Namespace = kustomization.Namespace
+		}
+		deps = append(deps, name)
+	}
+	return deps
+}
+
+var variableNameRegexp = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*$`)
+
+// makePostBuild returns the post build variable substitution of a
+// Kustomization from the given '<name>=<value>' variables and
+// '<kind>/<name>' references, nil when there are none.
+func makePostBuild(substitute, substituteFrom []string) (*kustomizev1.PostBuild, error) {
+	if len(substitute) == 0 && len(substituteFrom) == 0 {
+		return nil, nil
+	}
+
+	postBuild := &kustomizev1.PostBuild{}
+	for _, v := range substitute {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid --substitute '%s', must be in the format '<name>=<value>'", v)
+		}
+		if !variableNameRegexp.MatchString(parts[0]) {
+			return nil, fmt.Errorf("invalid variable name '%s', must start with a letter or an underscore, followed by letters, digits or underscores", parts[0])
+		}
+		if postBuild.Substitute == nil {
+			postBuild.Substitute = map[string]string{}
+		}
+		postBuild.Substitute[parts[0]] = parts[1]
+	}
+
+	for _, v := range substituteFrom {
+		var ref flags.KustomizationSubstituteFrom
+		if err := ref.Set(v); err != nil {
+			return nil, fmt.Errorf("invalid --substitute-from '%s': %w", v, err)
+		}
+		postBuild.SubstituteFrom = append(postBuild.SubstituteFrom, kustomizev1.SubstituteReference{
+			Kind: ref.Kind,
+			Name: ref.Name,
+		})
+	}
+	return postBuild, nil
+}
@@ -18,9 +18,13 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +50,22 @@ var createReceiverCmd = &cobra.Command{
 	--secret-ref webhook-token \
 	--resource GitRepository/webapp \
 	--resource HelmRepository/webapp
+
+  # Create a Receiver, generating its token secret, and print its public webhook URL
+  flux create receiver github-receiver \
+	--type github \
+	--event push \
+	--secret-ref webhook-token \
+	--resource GitRepository/webapp \
+	--ingress-host flux-webhook.example.com
+
+  # Create a Receiver definition on disk without applying it on the cluster
+  flux create receiver github-receiver \
+	--type github \
+	--event push \
+	--secret-ref webhook-token \
+	--resource GitRepository/webapp \
+	--export > receiver.yaml
 `,
 	RunE: createReceiverCmdRun,
 }
@@ -55,6 +75,7 @@ type receiverFlags struct {
 	secretRef    string
 	events       []string
 	resources    []string
+	ingressHost  string
 }
 
 var receiverArgs receiverFlags
@@ -64,6 +85,8 @@ func init() {
 	createReceiverCmd.Flags().StringVar(&receiverArgs.secretRef, "secret-ref", "", "")
 	createReceiverCmd.Flags().StringArrayVar(&receiverArgs.events, "event", []string{}, "")
 	createReceiverCmd.Flags().StringArrayVar(&receiverArgs.resources, "resource", []string{}, "")
+	createReceiverCmd.Flags().StringVar(&receiverArgs.ingressHost, "ingress-host", "",
+		"the host the webhook receiver is exposed on, e.g. with an Ingress, to print the full webhook URL")
 	createCmd.AddCommand(createReceiverCmd)
 }
 
@@ -125,17 +148,22 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	if createArgs.export {
-		return exportReceiver(receiver)
+		return printExport(exportReceiver(&receiver))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
+	secretName := types.NamespacedName{Namespace: rootArgs.namespace, Name: receiverArgs.secretRef}
+	if err := ensureReceiverToken(ctx, kubeClient, secretName); err != nil {
+		return err
+	}
+
 	logger.Actionf("applying Receiver")
 	namespacedName, err := upsertReceiver(ctx, kubeClient, &receiver)
 	if err != nil {
@@ -149,10 +177,68 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	logger.Successf("Receiver %s is ready", name)
 
-	logger.Successf("generated webhook URL %s", receiver.Status.URL)
+	logger.Successf("generated webhook URL %s", receiverWebhookURL(receiverArgs.ingressHost, receiver.Status.URL))
+	return nil
+}
+
+// receiverTokenKey is the key of the token in the secret of a Receiver.
+const receiverTokenKey = "token"
+
+// ensureReceiverToken creates the token secret of a Receiver when it
+// doesn't exist, and prints the generated token for configuring the
+// webhook on the Git provider.
+func ensureReceiverToken(ctx context.Context, kubeClient client.Client, namespacedName types.NamespacedName) error {
+	var existing corev1.Secret
+	err := kubeClient.Get(ctx, namespacedName, &existing)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+	token, err := setReceiverToken(ctx, kubeClient, namespacedName)
+	if err != nil {
+		return err
+	}
+	logger.Successf("secret %s created with the webhook token %s", namespacedName.Name, token)
 	return nil
 }
 
+// setReceiverToken writes a newly generated token to the secret of a
+// Receiver, and returns it.
+func setReceiverToken(ctx context.Context, kubeClient client.Client, namespacedName types.NamespacedName) (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating the webhook token failed: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namespacedName.Name,
+			Namespace: namespacedName.Namespace,
+		},
+		StringData: map[string]string{
+			receiverTokenKey: token,
+		},
+	}
+	if err := upsertSecret(ctx, kubeClient, secret); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// receiverWebhookURL returns the URL of the webhook of a Receiver on
+// the given host, or its path alone when the host is not known.
+func receiverWebhookURL(host, path string) string {
+	if host == "" || path == "" {
+		return path
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return strings.TrimSuffix(host, "/") + path
+}
+
 func upsertReceiver(ctx context.Context, kubeClient client.Client,
 	receiver *notificationv1.Receiver) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
@@ -18,6 +18,10 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
@@ -32,10 +36,42 @@ var createSecretCmd = &cobra.Command{
 	Long:  "The create source sub-commands generate Kubernetes secrets specific to Flux.",
 }
 
+// The environment variables the basic authentication credentials are
+// read from when they are not given as flags.
+const (
+	gitUsernameEnvVar  = "GIT_USERNAME"
+	gitPasswordEnvVar  = "GIT_PASSWORD"
+	helmUsernameEnvVar = "HELM_REPO_USERNAME"
+	helmPasswordEnvVar = "HELM_REPO_PASSWORD"
+	ociUsernameEnvVar  = "REGISTRY_USERNAME"
+	ociPasswordEnvVar  = "REGISTRY_PASSWORD"
+)
+
 func init() {
 	createCmd.AddCommand(createSecretCmd)
 }
 
+// readCredential returns the value of a credential flag, or when it is
+// not set, the credential read from stdin if fromStdin is set, or else
+// the value of the environment variable. This keeps the credentials
+// out of the shell history and the CI logs.
+func readCredential(flag, value string, fromStdin bool, envVar string) (string, error) {
+	if fromStdin {
+		if value != "" {
+			return "", fmt.Errorf("--%s and --%s-stdin are mutually exclusive", flag, flag)
+		}
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the %s from stdin: %w", flag, err)
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+	}
+	if value == "" && envVar != "" {
+		return os.Getenv(envVar), nil
+	}
+	return value, nil
+}
+
 func upsertSecret(ctx context.Context, kubeClient client.Client, secret corev1.Secret) error {
 	namespacedName := types.NamespacedName{
 		Namespace: secret.GetNamespace(),
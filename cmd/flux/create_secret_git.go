@@ -27,7 +27,6 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -66,26 +65,34 @@ For Git over HTTP/S, the provided basic authentication credentials are stored in
 
   sops --encrypt --encrypted-regex '^(data|stringData)$' \
     --in-place podinfo-auth.yaml
+
+  # Create a secret for a Git repository, reading the password from stdin
+  echo $GITHUB_TOKEN | flux create secret git podinfo-auth \
+    --url=https://github.com/stefanprodan/podinfo \
+    --username=username \
+    --password-stdin
 `,
 	RunE: createSecretGitCmdRun,
 }
 
 type secretGitFlags struct {
-	url          string
-	username     string
-	password     string
-	keyAlgorithm flags.PublicKeyAlgorithm
-	rsaBits      flags.RSAKeyBits
-	ecdsaCurve   flags.ECDSACurve
-	caFile       string
+	url           string
+	username      string
+	password      string
+	passwordStdin bool
+	keyAlgorithm  flags.PublicKeyAlgorithm
+	rsaBits       flags.RSAKeyBits
+	ecdsaCurve    flags.ECDSACurve
+	caFile        string
 }
 
 var secretGitArgs = NewSecretGitFlags()
 
 func init() {
 	createSecretGitCmd.Flags().StringVar(&secretGitArgs.url, "url", "", "git address, e.g. ssh://git@host/org/repository")
-	createSecretGitCmd.Flags().StringVarP(&secretGitArgs.username, "username", "u", "", "basic authentication username")
-	createSecretGitCmd.Flags().StringVarP(&secretGitArgs.password, "password", "p", "", "basic authentication password")
+	createSecretGitCmd.Flags().StringVarP(&secretGitArgs.username, "username", "u", "", "basic authentication username, defaults to $"+gitUsernameEnvVar)
+	createSecretGitCmd.Flags().StringVarP(&secretGitArgs.password, "password", "p", "", "basic authentication password, defaults to $"+gitPasswordEnvVar)
+	createSecretGitCmd.Flags().BoolVar(&secretGitArgs.passwordStdin, "password-stdin", false, "read the basic authentication password from stdin")
 	createSecretGitCmd.Flags().Var(&secretGitArgs.keyAlgorithm, "ssh-key-algorithm", secretGitArgs.keyAlgorithm.Description())
 	createSecretGitCmd.Flags().Var(&secretGitArgs.rsaBits, "ssh-rsa-bits", secretGitArgs.rsaBits.Description())
 	createSecretGitCmd.Flags().Var(&secretGitArgs.ecdsaCurve, "ssh-ecdsa-curve", secretGitArgs.ecdsaCurve.Description())
@@ -134,11 +141,19 @@ func createSecretGitCmdRun(cmd *cobra.Command, args []string) error {
 		opts.RSAKeyBits = int(secretGitArgs.rsaBits)
 		opts.ECDSACurve = secretGitArgs.ecdsaCurve.Curve
 	case "http", "https":
-		if secretGitArgs.username == "" || secretGitArgs.password == "" {
+		username, err := readCredential("username", secretGitArgs.username, false, gitUsernameEnvVar)
+		if err != nil {
+			return err
+		}
+		password, err := readCredential("password", secretGitArgs.password, secretGitArgs.passwordStdin, gitPasswordEnvVar)
+		if err != nil {
+			return err
+		}
+		if username == "" || password == "" {
 			return fmt.Errorf("for Git over HTTP/S the username and password are required")
 		}
-		opts.Username = secretGitArgs.username
-		opts.Password = secretGitArgs.password
+		opts.Username = username
+		opts.Password = password
 		opts.CAFilePath = secretGitArgs.caFile
 	default:
 		return fmt.Errorf("git URL scheme '%s' not supported, can be: ssh, http and https", u.Scheme)
@@ -163,9 +178,9 @@ func createSecretGitCmdRun(cmd *cobra.Command, args []string) error {
 		logger.Generatef("deploy key: %s", ppk)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
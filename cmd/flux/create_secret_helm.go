@@ -24,7 +24,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
 
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -56,16 +55,18 @@ The create secret helm command generates a Kubernetes secret with basic authenti
 }
 
 type secretHelmFlags struct {
-	username string
-	password string
+	username      string
+	password      string
+	passwordStdin bool
 	secretTLSFlags
 }
 
 var secretHelmArgs secretHelmFlags
 
 func init() {
-	createSecretHelmCmd.Flags().StringVarP(&secretHelmArgs.username, "username", "u", "", "basic authentication username")
-	createSecretHelmCmd.Flags().StringVarP(&secretHelmArgs.password, "password", "p", "", "basic authentication password")
+	createSecretHelmCmd.Flags().StringVarP(&secretHelmArgs.username, "username", "u", "", "basic authentication username, defaults to $"+helmUsernameEnvVar)
+	createSecretHelmCmd.Flags().StringVarP(&secretHelmArgs.password, "password", "p", "", "basic authentication password, defaults to $"+helmPasswordEnvVar)
+	createSecretHelmCmd.Flags().BoolVar(&secretHelmArgs.passwordStdin, "password-stdin", false, "read the basic authentication password from stdin")
 	initSecretTLSFlags(createSecretHelmCmd.Flags(), &secretHelmArgs.secretTLSFlags)
 	createSecretCmd.AddCommand(createSecretHelmCmd)
 }
@@ -81,12 +82,21 @@ func createSecretHelmCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	username, err := readCredential("username", secretHelmArgs.username, false, helmUsernameEnvVar)
+	if err != nil {
+		return err
+	}
+	password, err := readCredential("password", secretHelmArgs.password, secretHelmArgs.passwordStdin, helmPasswordEnvVar)
+	if err != nil {
+		return err
+	}
+
 	opts := sourcesecret.Options{
 		Name:         name,
 		Namespace:    rootArgs.namespace,
 		Labels:       labels,
-		Username:     secretHelmArgs.username,
-		Password:     secretHelmArgs.password,
+		Username:     username,
+		Password:     password,
 		CAFilePath:   secretHelmArgs.caFile,
 		CertFilePath: secretHelmArgs.certFile,
 		KeyFilePath:  secretHelmArgs.keyFile,
@@ -101,9 +111,9 @@ func createSecretHelmCmdRun(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+)
+
+// kubeConfigSecretKey is the key of the kubeconfig in the secret, as
+// read by the kustomize-controller.
+const kubeConfigSecretKey = "value"
+
+var createSecretKubeConfigCmd = &cobra.Command{
+	Use:   "kubeconfig [name]",
+	Short: "Create or update a Kubernetes secret with a kubeconfig",
+	Long: `
+The create secret kubeconfig command generates a Kubernetes secret with the kubeconfig of a remote cluster,
+stored under the value key, which the kustomize-controller uses to apply the manifests of the Kustomizations
+referencing it with --kubeconfig-secret-ref on that cluster.
+The kubeconfig is reduced to a single context, and the files it references are embedded.`,
+	Example: `  # Create a secret with the kubeconfig of the staging context
+  flux create secret kubeconfig staging-kubeconfig \
+    --kubeconfig-file=./staging.kubeconfig \
+    --kubeconfig-context=staging
+
+  # Apply the manifests of a Kustomization on the staging cluster
+  flux create kustomization podinfo \
+    --source=podinfo \
+    --path="./kustomize" \
+    --kubeconfig-secret-ref=staging-kubeconfig
+
+  # Create a kubeconfig secret on disk and encrypt it with Mozilla SOPS
+  flux create secret kubeconfig staging-kubeconfig \
+    --kubeconfig-file=./staging.kubeconfig \
+    --server=https://staging.example.com:6443 \
+    --export > staging-kubeconfig.yaml
+
+  sops --encrypt --encrypted-regex '^(data|stringData)$' \
+    --in-place staging-kubeconfig.yaml
+`,
+	RunE: createSecretKubeConfigCmdRun,
+}
+
+type secretKubeConfigFlags struct {
+	file    string
+	context string
+	server  string
+}
+
+var secretKubeConfigArgs secretKubeConfigFlags
+
+func init() {
+	createSecretKubeConfigCmd.Flags().StringVar(&secretKubeConfigArgs.file, "kubeconfig-file", "", "path to the kubeconfig of the remote cluster")
+	createSecretKubeConfigCmd.Flags().StringVar(&secretKubeConfigArgs.context, "kubeconfig-context", "",
+		"context of the kubeconfig to keep, defaults to its current context")
+	createSecretKubeConfigCmd.Flags().StringVar(&secretKubeConfigArgs.server, "server", "",
+		"address of the API server of the remote cluster, overriding the one of the kubeconfig when it's not reachable from the cluster, e.g. 127.0.0.1")
+
+	createSecretCmd.AddCommand(createSecretKubeConfigCmd)
+}
+
+func createSecretKubeConfigCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("secret name is required")
+	}
+	name := args[0]
+
+	if secretKubeConfigArgs.file == "" {
+		return fmt.Errorf("--kubeconfig-file is required")
+	}
+
+	kubeConfig, err := remoteKubeConfig(secretKubeConfigArgs.file, secretKubeConfigArgs.context, secretKubeConfigArgs.server)
+	if err != nil {
+		return err
+	}
+
+	labels, err := parseLabels()
+	if err != nil {
+		return err
+	}
+
+	secret := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: rootArgs.namespace,
+			Labels:    labels,
+		},
+		StringData: map[string]string{
+			kubeConfigSecretKey: string(kubeConfig),
+		},
+	}
+
+	if createArgs.export {
+		data, err := yaml.Marshal(secret)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("---\n%s\n", resourceToString(data))
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+	if err := upsertSecret(ctx, kubeClient, secret); err != nil {
+		return err
+	}
+	logger.Actionf("secret '%s' created in '%s' namespace", name, rootArgs.namespace)
+
+	return nil
+}
+
+// remoteKubeConfig returns a kubeconfig holding only the given context
+// of a kubeconfig file, with the files it references embedded, as the
+// kustomize-controller has no access to them.
+func remoteKubeConfig(path, contextName, server string) ([]byte, error) {
+	config, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfig %s failed: %w", path, err)
+	}
+	if contextName != "" {
+		if _, ok := config.Contexts[contextName]; !ok {
+			return nil, fmt.Errorf("context %s not found in kubeconfig %s", contextName, path)
+		}
+		config.CurrentContext = contextName
+	}
+	if err := clientcmdapi.MinifyConfig(config); err != nil {
+		return nil, fmt.Errorf("kubeconfig %s: %w", path, err)
+	}
+	if err := clientcmdapi.FlattenConfig(config); err != nil {
+		return nil, fmt.Errorf("embedding the files of kubeconfig %s failed: %w", path, err)
+	}
+
+	for _, cluster := range config.Clusters {
+		if server != "" {
+			cluster.Server = server
+		}
+	}
+	for name, authInfo := range config.AuthInfos {
+		if authInfo.Exec != nil || authInfo.AuthProvider != nil {
+			logger.Warningf("user %s authenticates with a plugin, which is not available to the kustomize-controller, use a token or a client certificate instead", name)
+		}
+	}
+
+	return clientcmd.Write(*config)
+}
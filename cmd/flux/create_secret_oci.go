@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var createSecretOCICmd = &cobra.Command{
+	Use:   "oci [name]",
+	Short: "Create or update a Kubernetes secret for container registry authentication",
+	Long: `
+The create secret oci command generates a Kubernetes secret of type kubernetes.io/dockerconfigjson
+with the credentials of a container registry. The secret can be used to pull the toolkit images
+with --image-pull-secret, and to scan private registries with the image-reflector-controller.`,
+	Example: `  # Create a secret for scanning the images of a private registry
+  echo $GITHUB_TOKEN | flux create secret oci ghcr-auth \
+    --url=ghcr.io \
+    --username=username \
+    --password-stdin
+
+  flux create image repository podinfo \
+    --image=ghcr.io/stefanprodan/podinfo \
+    --secret-ref=ghcr-auth
+
+  # Create a registry secret on disk and encrypt it with Mozilla SOPS
+  flux create secret oci ghcr-auth \
+    --namespace=flux-system \
+    --url=ghcr.io \
+    --username=username \
+    --password=password \
+    --export > ghcr-auth.yaml
+
+  sops --encrypt --encrypted-regex '^(data|stringData)$' \
+    --in-place ghcr-auth.yaml
+`,
+	RunE: createSecretOCICmdRun,
+}
+
+type secretOCIFlags struct {
+	url           string
+	username      string
+	password      string
+	passwordStdin bool
+}
+
+var secretOCIArgs secretOCIFlags
+
+func init() {
+	createSecretOCICmd.Flags().StringVar(&secretOCIArgs.url, "url", "", "the registry address, e.g. ghcr.io")
+	createSecretOCICmd.Flags().StringVarP(&secretOCIArgs.username, "username", "u", "", "registry username, defaults to $"+ociUsernameEnvVar)
+	createSecretOCICmd.Flags().StringVarP(&secretOCIArgs.password, "password", "p", "", "registry password or token, defaults to $"+ociPasswordEnvVar)
+	createSecretOCICmd.Flags().BoolVar(&secretOCIArgs.passwordStdin, "password-stdin", false, "read the registry password from stdin")
+
+	createSecretCmd.AddCommand(createSecretOCICmd)
+}
+
+func createSecretOCICmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("secret name is required")
+	}
+	name := args[0]
+	if secretOCIArgs.url == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	username, err := readCredential("username", secretOCIArgs.username, false, ociUsernameEnvVar)
+	if err != nil {
+		return err
+	}
+	password, err := readCredential("password", secretOCIArgs.password, secretOCIArgs.passwordStdin, ociPasswordEnvVar)
+	if err != nil {
+		return err
+	}
+	if username == "" || password == "" {
+		return fmt.Errorf("the username and password are required")
+	}
+
+	labels, err := parseLabels()
+	if err != nil {
+		return err
+	}
+
+	dockerConfig, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			secretOCIArgs.url: map[string]string{
+				"username": username,
+				"password": password,
+				"auth":     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	secret := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: rootArgs.namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		StringData: map[string]string{
+			corev1.DockerConfigJsonKey: string(dockerConfig),
+		},
+	}
+
+	if createArgs.export {
+		data, err := yaml.Marshal(secret)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("---\n%s\n", resourceToString(data))
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+	if err := upsertSecret(ctx, kubeClient, secret); err != nil {
+		return err
+	}
+	logger.Actionf("secret '%s' created in '%s' namespace", name, rootArgs.namespace)
+
+	return nil
+}
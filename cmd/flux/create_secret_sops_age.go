@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen/sopssecret"
+)
+
+var createSecretSopsAgeCmd = &cobra.Command{
+	Use:   "sops-age [name]",
+	Short: "Create or update a Kubernetes secret with an age key for SOPS decryption",
+	Long: `
+The create secret sops-age command generates a Kubernetes secret with an age identity,
+stored under the identity.agekey key, which the kustomize-controller uses to decrypt
+the SOPS encrypted manifests of the Kustomizations referencing it with --decryption-secret.`,
+	Example: `  # Create a decryption secret from an age identity file
+  age-keygen -o age.agekey
+  flux create secret sops-age sops-age --age-key-file=age.agekey
+
+  # Reference it from a Kustomization
+  flux create kustomization my-secrets \
+    --source=GitRepository/my-secrets \
+    --path="./secrets" \
+    --decryption-provider=sops \
+    --decryption-secret=sops-age
+`,
+	RunE: createSecretSopsAgeCmdRun,
+}
+
+type secretSopsAgeFlags struct {
+	keyFile string
+}
+
+var secretSopsAgeArgs secretSopsAgeFlags
+
+func init() {
+	createSecretSopsAgeCmd.Flags().StringVar(&secretSopsAgeArgs.keyFile, "age-key-file", "", "age identity file path")
+	createSecretCmd.AddCommand(createSecretSopsAgeCmd)
+}
+
+func createSecretSopsAgeCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("secret name is required")
+	}
+	name := args[0]
+
+	if secretSopsAgeArgs.keyFile == "" {
+		return fmt.Errorf("--age-key-file is required")
+	}
+
+	labels, err := parseLabels()
+	if err != nil {
+		return err
+	}
+
+	opts := sopssecret.Options{
+		Name:       name,
+		Namespace:  rootArgs.namespace,
+		Labels:     labels,
+		AgeKeyPath: secretSopsAgeArgs.keyFile,
+	}
+	secret, err := sopssecret.Generate(opts)
+	if err != nil {
+		return err
+	}
+
+	if createArgs.export {
+		fmt.Println(secret.Content)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+	var s corev1.Secret
+	if err := yaml.Unmarshal([]byte(secret.Content), &s); err != nil {
+		return err
+	}
+	if err := upsertSecret(ctx, kubeClient, s); err != nil {
+		return err
+	}
+
+	logger.Actionf("secret '%s' created in '%s' namespace", name, rootArgs.namespace)
+	return nil
+}
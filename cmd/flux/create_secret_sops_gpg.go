@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen/sopssecret"
+)
+
+var createSecretSopsGPGCmd = &cobra.Command{
+	Use:   "sops-gpg [name]",
+	Short: "Create or update a Kubernetes secret with an OpenPGP key for SOPS decryption",
+	Long: `
+The create secret sops-gpg command generates a Kubernetes secret with an OpenPGP private key,
+stored under the identity.asc key, which the kustomize-controller uses to decrypt
+the SOPS encrypted manifests of the Kustomizations referencing it with --decryption-secret.`,
+	Example: `  # Create a decryption secret from an exported OpenPGP private key
+  gpg --export-secret-keys --armor "${KEY_FP}" > sops.asc
+  flux create secret sops-gpg sops-gpg --gpg-key-file=sops.asc
+
+  # Reference it from a Kustomization
+  flux create kustomization my-secrets \
+    --source=GitRepository/my-secrets \
+    --path="./secrets" \
+    --decryption-provider=sops \
+    --decryption-secret=sops-gpg
+`,
+	RunE: createSecretSopsGPGCmdRun,
+}
+
+type secretSopsGPGFlags struct {
+	keyFile string
+}
+
+var secretSopsGPGArgs secretSopsGPGFlags
+
+func init() {
+	createSecretSopsGPGCmd.Flags().StringVar(&secretSopsGPGArgs.keyFile, "gpg-key-file", "", "ASCII armored OpenPGP private key file path")
+	createSecretCmd.AddCommand(createSecretSopsGPGCmd)
+}
+
+func createSecretSopsGPGCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("secret name is required")
+	}
+	name := args[0]
+
+	if secretSopsGPGArgs.keyFile == "" {
+		return fmt.Errorf("--gpg-key-file is required")
+	}
+
+	labels, err := parseLabels()
+	if err != nil {
+		return err
+	}
+
+	opts := sopssecret.Options{
+		Name:       name,
+		Namespace:  rootArgs.namespace,
+		Labels:     labels,
+		GPGKeyPath: secretSopsGPGArgs.keyFile,
+	}
+	secret, err := sopssecret.Generate(opts)
+	if err != nil {
+		return err
+	}
+
+	if createArgs.export {
+		fmt.Println(secret.Content)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+	var s corev1.Secret
+	if err := yaml.Unmarshal([]byte(secret.Content), &s); err != nil {
+		return err
+	}
+	if err := upsertSecret(ctx, kubeClient, s); err != nil {
+		return err
+	}
+
+	logger.Actionf("secret '%s' created in '%s' namespace", name, rootArgs.namespace)
+	return nil
+}
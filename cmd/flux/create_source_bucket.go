@@ -34,7 +34,6 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var createSourceBucketCmd = &cobra.Command{
@@ -59,29 +58,46 @@ For Buckets with static authentication, the credentials are stored in a Kubernet
     --endpoint=s3.amazonaws.com \
 	--region=us-east-1 \
     --interval=10m
+
+  # Create a source definition on disk without applying it on the cluster
+  flux create source bucket podinfo \
+    --bucket-name=podinfo \
+    --provider=aws \
+    --endpoint=s3.amazonaws.com \
+    --region=us-east-1 \
+    --export > podinfo-source.yaml
 `,
 	RunE: createSourceBucketCmdRun,
 }
 
 type sourceBucketFlags struct {
-	name      string
-	provider  flags.SourceBucketProvider
-	endpoint  string
-	accessKey string
-	secretKey string
-	region    string
-	insecure  bool
-	secretRef string
+	name           string
+	provider       flags.SourceBucketProvider
+	endpoint       string
+	accessKey      string
+	secretKey      string
+	secretKeyStdin bool
+	region         string
+	insecure       bool
+	secretRef      string
 }
 
+// the environment variables the credentials are read from when
+// they are not given as flags
+const (
+	awsAccessKeyEnvVar = "AWS_ACCESS_KEY_ID"
+	awsSecretKeyEnvVar = "AWS_SECRET_ACCESS_KEY"
+)
+
 var sourceBucketArgs = NewSourceBucketFlags()
 
 func init() {
 	createSourceBucketCmd.Flags().Var(&sourceBucketArgs.provider, "provider", sourceBucketArgs.provider.Description())
 	createSourceBucketCmd.Flags().StringVar(&sourceBucketArgs.name, "bucket-name", "", "the bucket name")
 	createSourceBucketCmd.Flags().StringVar(&sourceBucketArgs.endpoint, "endpoint", "", "the bucket endpoint address")
-	createSourceBucketCmd.Flags().StringVar(&sourceBucketArgs.accessKey, "access-key", "", "the bucket access key")
-	createSourceBucketCmd.Flags().StringVar(&sourceBucketArgs.secretKey, "secret-key", "", "the bucket secret key")
+	createSourceBucketCmd.Flags().StringVar(&sourceBucketArgs.accessKey, "access-key", "", "the bucket access key, defaults to $"+awsAccessKeyEnvVar)
+	createSourceBucketCmd.Flags().StringVar(&sourceBucketArgs.secretKey, "secret-key", "", "the bucket secret key, defaults to $"+awsSecretKeyEnvVar)
+	createSourceBucketCmd.Flags().BoolVar(&sourceBucketArgs.secretKeyStdin, "secret-key-stdin", false, "read the bucket secret key from stdin")
 	createSourceBucketCmd.Flags().StringVar(&sourceBucketArgs.region, "region", "", "the bucket region")
 	createSourceBucketCmd.Flags().BoolVar(&sourceBucketArgs.insecure, "insecure", false, "for when connecting to a non-TLS S3 HTTP endpoint")
 	createSourceBucketCmd.Flags().StringVar(&sourceBucketArgs.secretRef, "secret-ref", "", "the name of an existing secret containing credentials")
@@ -109,6 +125,17 @@ func createSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("endpoint is required")
 	}
 
+	accessKey, err := readCredential("access-key", sourceBucketArgs.accessKey, false, awsAccessKeyEnvVar)
+	if err != nil {
+		return err
+	}
+	sourceBucketArgs.accessKey = accessKey
+	secretKey, err := readCredential("secret-key", sourceBucketArgs.secretKey, sourceBucketArgs.secretKeyStdin, awsSecretKeyEnvVar)
+	if err != nil {
+		return err
+	}
+	sourceBucketArgs.secretKey = secretKey
+
 	sourceLabels, err := parseLabels()
 	if err != nil {
 		return err
@@ -137,20 +164,20 @@ func createSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
 			},
 		},
 	}
-	if sourceHelmArgs.secretRef != "" {
+	if sourceBucketArgs.secretRef != "" {
 		bucket.Spec.SecretRef = &meta.LocalObjectReference{
 			Name: sourceBucketArgs.secretRef,
 		}
 	}
 
 	if createArgs.export {
-		return exportBucket(*bucket)
+		return printExport(exportBucket(bucket))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
@@ -24,6 +24,7 @@ import (
 	"net/url"
 	"os"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/fluxcd/pkg/apis/meta"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/manifoldco/promptui"
@@ -38,7 +39,6 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/internal/flags"
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -49,6 +49,7 @@ type sourceGitFlags struct {
 	semver            string
 	username          string
 	password          string
+	passwordStdin     bool
 	caFile            string
 	keyAlgorithm      flags.PublicKeyAlgorithm
 	keyRSABits        flags.RSAKeyBits
@@ -97,6 +98,12 @@ For private Git repositories, the basic authentication credentials are stored in
     --url=https://github.com/stefanprodan/podinfo \
     --username=username \
     --password=password
+
+  # Create a source definition on disk without applying it on the cluster
+  flux create source git podinfo \
+    --url=https://github.com/stefanprodan/podinfo \
+    --branch=master \
+    --export > podinfo-source.yaml
 `,
 	RunE: createSourceGitCmdRun,
 }
@@ -107,9 +114,10 @@ func init() {
 	createSourceGitCmd.Flags().StringVar(&sourceGitArgs.url, "url", "", "git address, e.g. ssh://git@host/org/repository")
 	createSourceGitCmd.Flags().StringVar(&sourceGitArgs.branch, "branch", "master", "git branch")
 	createSourceGitCmd.Flags().StringVar(&sourceGitArgs.tag, "tag", "", "git tag")
-	createSourceGitCmd.Flags().StringVar(&sourceGitArgs.semver, "tag-semver", "", "git tag semver range")
-	createSourceGitCmd.Flags().StringVarP(&sourceGitArgs.username, "username", "u", "", "basic authentication username")
-	createSourceGitCmd.Flags().StringVarP(&sourceGitArgs.password, "password", "p", "", "basic authentication password")
+	createSourceGitCmd.Flags().StringVar(&sourceGitArgs.semver, "tag-semver", "", "git tag semver range, takes precedence over --tag and --branch")
+	createSourceGitCmd.Flags().StringVarP(&sourceGitArgs.username, "username", "u", "", "basic authentication username, defaults to $"+gitUsernameEnvVar)
+	createSourceGitCmd.Flags().StringVarP(&sourceGitArgs.password, "password", "p", "", "basic authentication password, defaults to $"+gitPasswordEnvVar)
+	createSourceGitCmd.Flags().BoolVar(&sourceGitArgs.passwordStdin, "password-stdin", false, "read the basic authentication password from stdin")
 	createSourceGitCmd.Flags().Var(&sourceGitArgs.keyAlgorithm, "ssh-key-algorithm", sourceGitArgs.keyAlgorithm.Description())
 	createSourceGitCmd.Flags().Var(&sourceGitArgs.keyRSABits, "ssh-rsa-bits", sourceGitArgs.keyRSABits.Description())
 	createSourceGitCmd.Flags().Var(&sourceGitArgs.keyECDSACurve, "ssh-ecdsa-curve", sourceGitArgs.keyECDSACurve.Description())
@@ -137,6 +145,12 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("url is required")
 	}
 
+	if sourceGitArgs.semver != "" {
+		if _, err := semver.NewConstraint(sourceGitArgs.semver); err != nil {
+			return fmt.Errorf("invalid tag semver range '%s': %w", sourceGitArgs.semver, err)
+		}
+	}
+
 	if sourceGitArgs.gitImplementation.String() != sourcev1.LibGit2Implementation && sourceGitArgs.caFile != "" {
 		return fmt.Errorf("specifing a CA file requires --git-implementation=%s", sourcev1.LibGit2Implementation)
 	}
@@ -194,13 +208,13 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	if createArgs.export {
-		return exportGit(gitRepository)
+		return printExport(exportGitRepository(&gitRepository))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
@@ -219,8 +233,12 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 			secretOpts.RSAKeyBits = int(sourceGitArgs.keyRSABits)
 			secretOpts.ECDSACurve = sourceGitArgs.keyECDSACurve.Curve
 		case "https":
-			secretOpts.Username = sourceGitArgs.username
-			secretOpts.Password = sourceGitArgs.password
+			if secretOpts.Username, err = readCredential("username", sourceGitArgs.username, false, gitUsernameEnvVar); err != nil {
+				return err
+			}
+			if secretOpts.Password, err = readCredential("password", sourceGitArgs.password, sourceGitArgs.passwordStdin, gitPasswordEnvVar); err != nil {
+				return err
+			}
 			secretOpts.CAFilePath = sourceGitArgs.caFile
 		}
 		secret, err := sourcesecret.Generate(secretOpts)
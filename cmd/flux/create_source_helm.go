@@ -36,7 +36,6 @@ import (
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 
-	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/sourcesecret"
 )
 
@@ -63,26 +62,33 @@ For private Helm repositories, the basic authentication credentials are stored i
     --cert-file=./cert.crt \
     --key-file=./key.crt \
     --ca-file=./ca.crt
+
+  # Create a source definition on disk without applying it on the cluster
+  flux create source helm podinfo \
+    --url=https://stefanprodan.github.io/podinfo \
+    --export > podinfo-source.yaml
 `,
 	RunE: createSourceHelmCmdRun,
 }
 
 type sourceHelmFlags struct {
-	url       string
-	username  string
-	password  string
-	certFile  string
-	keyFile   string
-	caFile    string
-	secretRef string
+	url           string
+	username      string
+	password      string
+	passwordStdin bool
+	certFile      string
+	keyFile       string
+	caFile        string
+	secretRef     string
 }
 
 var sourceHelmArgs sourceHelmFlags
 
 func init() {
 	createSourceHelmCmd.Flags().StringVar(&sourceHelmArgs.url, "url", "", "Helm repository address")
-	createSourceHelmCmd.Flags().StringVarP(&sourceHelmArgs.username, "username", "u", "", "basic authentication username")
-	createSourceHelmCmd.Flags().StringVarP(&sourceHelmArgs.password, "password", "p", "", "basic authentication password")
+	createSourceHelmCmd.Flags().StringVarP(&sourceHelmArgs.username, "username", "u", "", "basic authentication username, defaults to $"+helmUsernameEnvVar)
+	createSourceHelmCmd.Flags().StringVarP(&sourceHelmArgs.password, "password", "p", "", "basic authentication password, defaults to $"+helmPasswordEnvVar)
+	createSourceHelmCmd.Flags().BoolVar(&sourceHelmArgs.passwordStdin, "password-stdin", false, "read the basic authentication password from stdin")
 	createSourceHelmCmd.Flags().StringVar(&sourceHelmArgs.certFile, "cert-file", "", "TLS authentication cert file path")
 	createSourceHelmCmd.Flags().StringVar(&sourceHelmArgs.keyFile, "key-file", "", "TLS authentication key file path")
 	createSourceHelmCmd.Flags().StringVar(&sourceHelmArgs.caFile, "ca-file", "", "TLS authentication CA file path")
@@ -137,25 +143,33 @@ func createSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
 	}
 
 	if createArgs.export {
-		return exportHelmRepository(*helmRepository)
+		return printExport(exportHelmRepository(helmRepository))
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
 	logger.Generatef("generating HelmRepository source")
 	if sourceHelmArgs.secretRef == "" {
+		username, err := readCredential("username", sourceHelmArgs.username, false, helmUsernameEnvVar)
+		if err != nil {
+			return err
+		}
+		password, err := readCredential("password", sourceHelmArgs.password, sourceHelmArgs.passwordStdin, helmPasswordEnvVar)
+		if err != nil {
+			return err
+		}
 		secretName := fmt.Sprintf("helm-%s", name)
 		secretOpts := sourcesecret.Options{
 			Name:         secretName,
 			Namespace:    rootArgs.namespace,
-			Username:     sourceHelmArgs.username,
-			Password:     sourceHelmArgs.password,
+			Username:     username,
+			Password:     password,
 			CertFilePath: sourceHelmArgs.certFile,
 			KeyFilePath:  sourceHelmArgs.keyFile,
 			CAFilePath:   sourceHelmArgs.caFile,
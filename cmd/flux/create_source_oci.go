@@ -0,0 +1,283 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/pkg/readiness"
+)
+
+var createSourceOCICmd = &cobra.Command{
+	Use:   "oci [name]",
+	Short: "Create or update an OCIRepository source",
+	Long: `
+The create source oci command generates an OCIRepository resource and waits for the artifact to be pulled.
+The OCIRepository API is served by the source-controller versions supporting OCI artifacts, which
+must be installed in the cluster unless the source is exported. With --verify, source-controller
+verifies the cosign signature of the artifacts before making them available.`,
+	Example: `  # Create a source from the latest artifact tagged v1 in GitHub Container Registry
+  flux create source oci podinfo \
+    --url=oci://ghcr.io/stefanprodan/manifests/podinfo \
+    --tag=v1 \
+    --interval=10m
+
+  # Create a source from the latest artifact whose tag matches a semver range
+  flux create source oci podinfo \
+    --url=oci://ghcr.io/stefanprodan/manifests/podinfo \
+    --semver=">=6.0.0 <7.0.0"
+
+  # Create a source from a private registry, with the credentials of an existing secret
+  flux create source oci podinfo \
+    --url=oci://registry.example.com/manifests/podinfo \
+    --tag=stable \
+    --secret-ref=registry-credentials
+
+  # Create a source whose artifacts are verified with the cosign public keys of a secret
+  flux create source oci podinfo \
+    --url=oci://ghcr.io/org/manifests/podinfo \
+    --tag=stable \
+    --verify \
+    --verify-secret-ref=cosign-pub
+`,
+	RunE: createSourceOCICmdRun,
+}
+
+type sourceOCIFlags struct {
+	url             string
+	tag             string
+	semver          string
+	digest          string
+	secretRef       string
+	verify          bool
+	verifySecretRef string
+}
+
+var sourceOCIArgs sourceOCIFlags
+
+func init() {
+	createSourceOCICmd.Flags().StringVar(&sourceOCIArgs.url, "url", "", "the OCI repository address, in the format oci://<host>/<org>/<repo>")
+	createSourceOCICmd.Flags().StringVar(&sourceOCIArgs.tag, "tag", "", "the artifact tag, defaults to latest when no other reference is given")
+	createSourceOCICmd.Flags().StringVar(&sourceOCIArgs.semver, "semver", "", "the semver range the artifact tags are matched against, the greatest matching tag is pulled")
+	createSourceOCICmd.Flags().StringVar(&sourceOCIArgs.digest, "digest", "", "the artifact digest, in the format sha256:<hash>")
+	createSourceOCICmd.Flags().StringVar(&sourceOCIArgs.secretRef, "secret-ref", "",
+		"the name of an existing docker-registry secret holding the credentials of the registry")
+
+	createSourceOCICmd.Flags().BoolVar(&sourceOCIArgs.verify, "verify", false,
+		"verify the cosign signature of the artifacts before they are made available")
+	createSourceOCICmd.Flags().StringVar(&sourceOCIArgs.verifySecretRef, "verify-secret-ref", "",
+		"the name of an existing secret holding the cosign public keys the signatures are verified with, the signatures are verified keyless when not set")
+
+	createSourceCmd.AddCommand(createSourceOCICmd)
+}
+
+// ociRepositoryGVK is the kind of the OCIRepository sources. Their API
+// is not part of the source-controller API module this version of the
+// CLI is built with, so they are handled as unstructured objects.
+var ociRepositoryGVK = schema.GroupVersionKind{
+	Group:   sourcev1.GroupVersion.Group,
+	Version: "v1beta2",
+	Kind:    "OCIRepository",
+}
+
+// ociRepositorySpec is the subset of the OCIRepository spec the CLI
+// sets.
+type ociRepositorySpec struct {
+	URL       string                     `json:"url"`
+	Reference *ociRepositoryRef          `json:"ref,omitempty"`
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+	Verify    *ociRepositoryVerification `json:"verify,omitempty"`
+	Interval  metav1.Duration            `json:"interval"`
+}
+
+// ociRepositoryVerification configures the verification of the
+// artifact signatures.
+type ociRepositoryVerification struct {
+	Provider  string                     `json:"provider"`
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// ociRepositoryRef selects the artifact to pull.
+type ociRepositoryRef struct {
+	Tag    string `json:"tag,omitempty"`
+	SemVer string `json:"semver,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+func createSourceOCICmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("OCIRepository source name is required")
+	}
+	name := args[0]
+
+	if sourceOCIArgs.url == "" {
+		return fmt.Errorf("url is required")
+	}
+	if !strings.HasPrefix(sourceOCIArgs.url, "oci://") {
+		return fmt.Errorf("url must be in the format oci://<host>/<org>/<repo>")
+	}
+
+	ref := &ociRepositoryRef{
+		Tag:    sourceOCIArgs.tag,
+		SemVer: sourceOCIArgs.semver,
+		Digest: sourceOCIArgs.digest,
+	}
+	switch {
+	case ref.Tag != "" && ref.SemVer != "":
+		return fmt.Errorf("only one of --tag and --semver can be given")
+	case ref.Tag == "" && ref.SemVer == "" && ref.Digest == "":
+		ref.Tag = "latest"
+	}
+	if sourceOCIArgs.verifySecretRef != "" && !sourceOCIArgs.verify {
+		return fmt.Errorf("--verify-secret-ref requires --verify")
+	}
+
+	sourceLabels, err := parseLabels()
+	if err != nil {
+		return err
+	}
+
+	spec := ociRepositorySpec{
+		URL:       sourceOCIArgs.url,
+		Reference: ref,
+		Interval: metav1.Duration{
+			Duration: createArgs.interval,
+		},
+	}
+	if sourceOCIArgs.secretRef != "" {
+		spec.SecretRef = &meta.LocalObjectReference{
+			Name: sourceOCIArgs.secretRef,
+		}
+	}
+	if sourceOCIArgs.verify {
+		spec.Verify = &ociRepositoryVerification{
+			Provider: cosignVerifyProvider,
+		}
+		if sourceOCIArgs.verifySecretRef != "" {
+			spec.Verify.SecretRef = &meta.LocalObjectReference{
+				Name: sourceOCIArgs.verifySecretRef,
+			}
+		}
+	}
+	ociRepository, err := newOCIRepository(name, rootArgs.namespace, sourceLabels, spec)
+	if err != nil {
+		return err
+	}
+
+	if createArgs.export {
+		return printExport(ociRepository.Object)
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	logger.Generatef("generating OCIRepository source")
+	logger.Actionf("applying OCIRepository source")
+	namespacedName, err := upsertOCIRepository(ctx, kubeClient, ociRepository)
+	if err != nil {
+		return err
+	}
+
+	logger.Waitingf("waiting for OCIRepository source reconciliation")
+	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+		readiness.ObjectReady(ctx, kubeClient, ociRepositoryGVK, namespacedName)); err != nil {
+		return err
+	}
+	logger.Successf("OCIRepository source reconciliation completed")
+
+	if err := kubeClient.Get(ctx, namespacedName, ociRepository); err != nil {
+		return err
+	}
+	revision, found, _ := unstructured.NestedString(ociRepository.Object, "status", "artifact", "revision")
+	if !found {
+		return fmt.Errorf("OCIRepository source reconciliation completed but no artifact was found")
+	}
+	logger.Successf("fetched revision: %s", revision)
+	return nil
+}
+
+// newOCIRepository returns an OCIRepository with the given spec.
+func newOCIRepository(name, namespace string, labels map[string]string, spec ociRepositorySpec) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ociRepositoryGVK)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	if len(labels) > 0 {
+		obj.SetLabels(labels)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		return nil, err
+	}
+	obj.Object["spec"] = content
+	return obj, nil
+}
+
+func upsertOCIRepository(ctx context.Context, kubeClient client.Client,
+	ociRepository *unstructured.Unstructured) (types.NamespacedName, error) {
+	namespacedName := types.NamespacedName{
+		Namespace: ociRepository.GetNamespace(),
+		Name:      ociRepository.GetName(),
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(ociRepositoryGVK)
+	err := kubeClient.Get(ctx, namespacedName, existing)
+	if err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return namespacedName, fmt.Errorf("the OCIRepository API is not installed in the cluster, upgrade source-controller to a version supporting OCI artifacts")
+		}
+		if apierrors.IsNotFound(err) {
+			if err := kubeClient.Create(ctx, ociRepository); err != nil {
+				return namespacedName, err
+			} else {
+				logger.Successf("OCIRepository source created")
+				return namespacedName, nil
+			}
+		}
+		return namespacedName, err
+	}
+
+	existing.SetLabels(ociRepository.GetLabels())
+	existing.Object["spec"] = ociRepository.Object["spec"]
+	if err := kubeClient.Update(ctx, existing); err != nil {
+		return namespacedName, err
+	}
+	logger.Successf("OCIRepository source updated")
+	return namespacedName, nil
+}
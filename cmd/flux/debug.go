@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Debug the reconciliation of resources",
+	Long:  "The debug sub-commands print the inputs of the reconciliation of a resource, as resolved from the cluster.",
+}
+
+type debugFlags struct {
+	showSecrets bool
+}
+
+var debugArgs debugFlags
+
+func init() {
+	debugCmd.PersistentFlags().BoolVar(&debugArgs.showSecrets, "show-secrets", false,
+		"print the values read from secrets and the inline credentials instead of redacting them")
+
+	rootCmd.AddCommand(debugCmd)
+}
+
+// writeDebugSection writes a titled section of the debug output, with
+// the given value serialised as indented YAML.
+func writeDebugSection(w io.Writer, title string, value interface{}) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/fluxcd/pkg/runtime/transform"
+)
+
+var debugHrCmd = &cobra.Command{
+	Use:     "helmrelease [name]",
+	Aliases: []string{"hr"},
+	Short:   "Debug a HelmRelease resource",
+	Long: `The debug helmrelease command prints the values a HelmRelease passes to Helm, as merged by the
+helm-controller from the ConfigMaps and Secrets in valuesFrom, in order, and from the inline values.
+The values read from secrets and the credentials written inline, e.g. passwords, tokens and the
+passwords in URLs, are redacted unless --show-secrets is given.`,
+	Example: `  # Print the merged values of a HelmRelease
+  flux debug helmrelease podinfo
+
+  # Print the merged values, including the ones read from secrets
+  flux debug hr podinfo --show-secrets
+`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: resourceNamesCompletionFunc(helmReleaseType),
+	RunE:              debugHrCmdRun,
+}
+
+func init() {
+	debugCmd.AddCommand(debugHrCmd)
+}
+
+func debugHrCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      args[0],
+	}
+	var helmRelease helmv2.HelmRelease
+	if err := kubeClient.Get(ctx, namespacedName, &helmRelease); err != nil {
+		return err
+	}
+
+	values, sources, err := helmReleaseValues(ctx, kubeClient, &helmRelease, !debugArgs.showSecrets)
+	if err != nil {
+		return err
+	}
+	if err := writeDebugSection(os.Stdout, "Values sources", sources); err != nil {
+		return err
+	}
+	return writeDebugSection(os.Stdout, "Values", values)
+}
+
+// helmReleaseValues merges the values of a HelmRelease the way the
+// helm-controller does, and returns them along with a description of
+// where they were read from. The values read from secrets and the
+// credentials written inline are redacted when redact is set.
+func helmReleaseValues(ctx context.Context, kubeClient client.Client, helmRelease *helmv2.HelmRelease, redact bool) (map[string]interface{}, []string, error) {
+	values := map[string]interface{}{}
+	sources := []string{}
+
+	for _, ref := range helmRelease.Spec.ValuesFrom {
+		namespacedName := types.NamespacedName{
+			Namespace: helmRelease.Namespace,
+			Name:      ref.Name,
+		}
+		source := fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+
+		var data []byte
+		var found, secret bool
+		switch ref.Kind {
+		case "ConfigMap":
+			var configMap corev1.ConfigMap
+			if err := kubeClient.Get(ctx, namespacedName, &configMap); err != nil {
+				if apierrors.IsNotFound(err) && ref.Optional {
+					sources = append(sources, source+" (optional, not found)")
+					continue
+				}
+				return nil, nil, fmt.Errorf("failed to get %s: %w", source, err)
+			}
+			var value string
+			value, found = configMap.Data[ref.GetValuesKey()]
+			data = []byte(value)
+		case "Secret":
+			var s corev1.Secret
+			if err := kubeClient.Get(ctx, namespacedName, &s); err != nil {
+				if apierrors.IsNotFound(err) && ref.Optional {
+					sources = append(sources, source+" (optional, not found)")
+					continue
+				}
+				return nil, nil, fmt.Errorf("failed to get %s: %w", source, err)
+			}
+			data, found = s.Data[ref.GetValuesKey()]
+			secret = true
+		default:
+			return nil, nil, fmt.Errorf("unsupported values source kind '%s'", ref.Kind)
+		}
+		if !found {
+			if ref.Optional {
+				sources = append(sources, fmt.Sprintf("%s key %s (optional, not found)", source, ref.GetValuesKey()))
+				continue
+			}
+			return nil, nil, fmt.Errorf("missing key '%s' in %s", ref.GetValuesKey(), source)
+		}
+		sources = append(sources, fmt.Sprintf("%s key %s", source, ref.GetValuesKey()))
+
+		var refValues map[string]interface{}
+		if ref.TargetPath != "" {
+			var value interface{} = string(data)
+			if secret && redact {
+				value = redactedValue
+			}
+			refValues = map[string]interface{}{}
+			setValuePath(refValues, ref.TargetPath, value)
+		} else {
+			if err := yaml.Unmarshal(data, &refValues); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse the values in %s: %w", source, err)
+			}
+			if secret && redact {
+				redactValues(refValues)
+			}
+		}
+		values = transform.MergeMaps(values, refValues)
+	}
+
+	if helmRelease.Spec.Values != nil {
+		var inline map[string]interface{}
+		if err := json.Unmarshal(helmRelease.Spec.Values.Raw, &inline); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse the inline values: %w", err)
+		}
+		if redact {
+			redactCredentials(inline)
+		}
+		sources = append(sources, "inline values")
+		values = transform.MergeMaps(values, inline)
+	}
+	return values, sources, nil
+}
+
+// setValuePath sets a value at a dot-separated path, creating the
+// intermediate maps as needed.
+func setValuePath(values map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := values[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			values[key] = next
+		}
+		values = next
+	}
+	values[keys[len(keys)-1]] = value
+}
+
+// redactValues replaces the leaf values of a values document,
+// recursively.
+func redactValues(values map[string]interface{}) {
+	for key, value := range values {
+		if v, ok := value.(map[string]interface{}); ok {
+			redactValues(v)
+			continue
+		}
+		values[key] = redactedValue
+	}
+}
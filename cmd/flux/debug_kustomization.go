@@ -0,0 +1,202 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+)
+
+var debugKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Debug a Kustomization resource",
+	Long: `The debug kustomization command prints the spec of a Kustomization, the variables substituted in
+its manifests with the source of each of them, its position in the dependency graph, and the status of
+its last reconciliation. Variables read from secrets are redacted unless --show-secrets is given.`,
+	Example: `  # Print the reconciliation inputs of a Kustomization
+  flux debug kustomization podinfo
+
+  # Print the reconciliation inputs, including the variables read from secrets
+  flux debug kustomization podinfo --show-secrets
+`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizationType),
+	RunE:              debugKsCmdRun,
+}
+
+func init() {
+	debugCmd.AddCommand(debugKsCmd)
+}
+
+func debugKsCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      args[0],
+	}
+	var kustomization kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, namespacedName, &kustomization); err != nil {
+		return err
+	}
+
+	if err := writeDebugSection(os.Stdout, "Spec", kustomization.Spec); err != nil {
+		return err
+	}
+
+	variables, err := kustomizationVariables(ctx, kubeClient, &kustomization, !debugArgs.showSecrets)
+	if err != nil {
+		return err
+	}
+	if err := writeDebugSection(os.Stdout, "Variables", variables); err != nil {
+		return err
+	}
+
+	dependencies, dependents, err := kustomizationDependencies(ctx, kubeClient, &kustomization)
+	if err != nil {
+		return err
+	}
+	if err := writeDebugSection(os.Stdout, "Depends on", dependencies); err != nil {
+		return err
+	}
+	if err := writeDebugSection(os.Stdout, "Required by", dependents); err != nil {
+		return err
+	}
+
+	status := map[string]string{
+		"lastAppliedRevision":   kustomization.Status.LastAppliedRevision,
+		"lastAttemptedRevision": kustomization.Status.LastAttemptedRevision,
+	}
+	if c := apimeta.FindStatusCondition(kustomization.Status.Conditions, meta.ReadyCondition); c != nil {
+		status["ready"] = string(c.Status)
+		status["reason"] = c.Reason
+		status["message"] = c.Message
+	}
+	return writeDebugSection(os.Stdout, "Status", status)
+}
+
+// debugVariable is a substitution variable, and where it is set.
+type debugVariable struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// kustomizationVariables resolves the variables substituted in the
+// manifests of a Kustomization. As in the kustomize-controller, the
+// variables set inline take precedence over the ones read from
+// ConfigMaps and Secrets, which are read in order. When redact is
+// set, the values read from Secrets are replaced.
+func kustomizationVariables(ctx context.Context, kubeClient client.Client, kustomization *kustomizev1.Kustomization,
+	redact bool) (map[string]debugVariable, error) {
+	variables := map[string]debugVariable{}
+	if kustomization.Spec.PostBuild == nil {
+		return variables, nil
+	}
+
+	for _, ref := range kustomization.Spec.PostBuild.SubstituteFrom {
+		namespacedName := types.NamespacedName{
+			Namespace: kustomization.Namespace,
+			Name:      ref.Name,
+		}
+		source := fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+		switch ref.Kind {
+		case "ConfigMap":
+			var configMap corev1.ConfigMap
+			if err := kubeClient.Get(ctx, namespacedName, &configMap); err != nil {
+				return nil, fmt.Errorf("failed to get %s: %w", source, err)
+			}
+			for k, v := range configMap.Data {
+				variables[k] = debugVariable{Value: v, Source: source}
+			}
+		case "Secret":
+			var secret corev1.Secret
+			if err := kubeClient.Get(ctx, namespacedName, &secret); err != nil {
+				return nil, fmt.Errorf("failed to get %s: %w", source, err)
+			}
+			for k, v := range secret.Data {
+				value := string(v)
+				if redact {
+					value = redactedValue
+				}
+				variables[k] = debugVariable{Value: value, Source: source}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported substitution source kind '%s'", ref.Kind)
+		}
+	}
+	for k, v := range kustomization.Spec.PostBuild.Substitute {
+		variables[k] = debugVariable{Value: v, Source: kustomizev1.KustomizationKind}
+	}
+	return variables, nil
+}
+
+// kustomizationDependencies returns the readiness of the dependencies
+// of a Kustomization, and the Kustomizations depending on it.
+func kustomizationDependencies(ctx context.Context, kubeClient client.Client, kustomization *kustomizev1.Kustomization) (map[string]string, []string, error) {
+	dependencies := map[string]string{}
+	for _, dep := range kustomization.Spec.DependsOn {
+		namespacedName := types.NamespacedName{
+			Namespace: kustomization.Namespace,
+			Name:      dep.Name,
+		}
+		if dep.Namespace != "" {
+			namespacedName.Namespace = dep.Namespace
+		}
+		var dependency kustomizev1.Kustomization
+		if err := kubeClient.Get(ctx, namespacedName, &dependency); err != nil {
+			dependencies[namespacedName.String()] = err.Error()
+			continue
+		}
+		status, message := statusAndMessage(dependency.Status.Conditions)
+		dependencies[namespacedName.String()] = fmt.Sprintf("ready %s: %s", status, message)
+	}
+
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return nil, nil, err
+	}
+	dependents := []string{}
+	for _, item := range list.Items {
+		for _, dep := range item.Spec.DependsOn {
+			namespace := dep.Namespace
+			if namespace == "" {
+				namespace = item.Namespace
+			}
+			if dep.Name == kustomization.Name && namespace == kustomization.Namespace {
+				dependents = append(dependents, fmt.Sprintf("%s/%s", item.Namespace, item.Name))
+			}
+		}
+	}
+	return dependencies, dependents, nil
+}
@@ -22,9 +22,10 @@ import (
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
-
-	"github.com/fluxcd/flux2/internal/utils"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var deleteCmd = &cobra.Command{
@@ -34,50 +35,91 @@ var deleteCmd = &cobra.Command{
 }
 
 type deleteFlags struct {
-	silent bool
+	labelSelector string
 }
 
 var deleteArgs deleteFlags
 
 func init() {
-	deleteCmd.PersistentFlags().BoolVarP(&deleteArgs.silent, "silent", "s", false,
-		"delete resource without asking for confirmation")
+	deleteCmd.PersistentFlags().StringVarP(&deleteArgs.labelSelector, "selector", "l", "",
+		"delete the objects matching this label selector (e.g. team=payments) instead of the named one")
 
 	rootCmd.AddCommand(deleteCmd)
 }
 
+// deletionWarner is for adapters of objects whose deletion has
+// consequences beyond the object itself, e.g., the removal of
+// workloads, that users need to know about before confirming.
+type deletionWarner interface {
+	deletionWarning() string
+}
+
 type deleteCommand struct {
 	apiType
-	object adapter // for getting the value, and later deleting it
+	object adapter     // for getting the value, and later deleting it
+	list   listAdapter // for selecting objects by label
 }
 
 func (del deleteCommand) run(cmd *cobra.Command, args []string) error {
-	if len(args) < 1 {
+	selection := objectSelection{labelSelector: deleteArgs.labelSelector}
+	if len(args) < 1 && selection.isEmpty() {
 		return fmt.Errorf("%s name is required", del.humanKind)
 	}
-	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
-	namespacedName := types.NamespacedName{
-		Namespace: rootArgs.namespace,
-		Name:      name,
+	// objects are deleted in reverse layers of dependencies, so that
+	// the objects depending on others are gone before them
+	var layers [][]types.NamespacedName
+	if len(args) == 1 && selection.isEmpty() && !isNamePattern(args[0]) {
+		layers = [][]types.NamespacedName{{{Namespace: rootArgs.namespace, Name: args[0]}}}
+	} else {
+		layers, err = selectObjectLayers(ctx, kubeClient, del.list, args, selection)
+		if err != nil {
+			return err
+		}
+	}
+	if len(layers) == 0 {
+		logger.Failuref("no %s objects found matching the selection", del.kind)
+		return nil
 	}
 
-	err = kubeClient.Get(ctx, namespacedName, del.object.asClientObject())
+	for i := len(layers) - 1; i >= 0; i-- {
+		for _, namespacedName := range layers[i] {
+			if err := del.delete(ctx, kubeClient, namespacedName); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// delete deletes a single object, once confirmed, and waits for it
+// to be finalized.
+func (del deleteCommand) delete(ctx context.Context, kubeClient client.Client, namespacedName types.NamespacedName) error {
+	name, namespace := namespacedName.Name, namespacedName.Namespace
+	resetObject(del.object)
+	err := kubeClient.Get(ctx, namespacedName, del.object.asClientObject())
 	if err != nil {
 		return err
 	}
 
-	if !deleteArgs.silent {
+	if warner, ok := del.object.(deletionWarner); ok {
+		if warning := warner.deletionWarning(); warning != "" {
+			logger.Warningf("%s", warning)
+		}
+	}
+
+	if !rootArgs.silent {
 		prompt := promptui.Prompt{
-			Label:     "Are you sure you want to delete this " + del.humanKind,
+			Label:     fmt.Sprintf("Are you sure you want to delete the %s %s", del.humanKind, name),
 			IsConfirm: true,
 		}
 		if _, err := prompt.Run(); err != nil {
@@ -85,12 +127,30 @@ func (del deleteCommand) run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	logger.Actionf("deleting %s %s in %s namespace", del.humanKind, name, rootArgs.namespace)
+	logger.Actionf("deleting %s %s in %s namespace", del.humanKind, name, namespace)
 	err = kubeClient.Delete(ctx, del.object.asClientObject())
 	if err != nil {
 		return err
 	}
-	logger.Successf("%s deleted", del.humanKind)
 
+	// the controllers may have finalizers to run, e.g. for garbage
+	// collection, before the object is gone
+	logger.Waitingf("waiting for %s %s to be finalized", del.humanKind, name)
+	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+		isDeleted(ctx, kubeClient, namespacedName, del.object)); err != nil {
+		return err
+	}
+	logger.Successf("%s deleted", del.humanKind)
 	return nil
 }
+
+func isDeleted(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, object adapter) wait.ConditionFunc {
+	return func() (bool, error) {
+		err := kubeClient.Get(ctx, namespacedName, object.asClientObject())
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
+
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	"github.com/spf13/cobra"
 )
@@ -25,16 +27,27 @@ var deleteHelmReleaseCmd = &cobra.Command{
 	Use:     "helmrelease [name]",
 	Aliases: []string{"hr"},
 	Short:   "Delete a HelmRelease resource",
-	Long:    "The delete helmrelease command removes the given HelmRelease from the cluster.",
+	Long:    "The delete helmrelease command removes the given HelmRelease from the cluster. Unless the HelmRelease is suspended, the Helm release is uninstalled too.",
 	Example: `  # Delete a Helm release and the Kubernetes resources created by it
   flux delete hr podinfo
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(helmReleaseType),
 	RunE: deleteCommand{
 		apiType: helmReleaseType,
-		object:  universalAdapter{&helmv2.HelmRelease{}},
+		object:  helmReleaseAdapter{&helmv2.HelmRelease{}},
+		list:    helmReleaseListAdapter{&helmv2.HelmReleaseList{}},
 	}.run,
 }
 
 func init() {
 	deleteCmd.AddCommand(deleteHelmReleaseCmd)
 }
+
+func (obj helmReleaseAdapter) deletionWarning() string {
+	// the controller does not uninstall suspended releases
+	if obj.HelmRelease.Spec.Suspend {
+		return ""
+	}
+	return fmt.Sprintf("the Helm release %s will be uninstalled, deleting the workloads it installed",
+		obj.HelmRelease.GetReleaseName())
+}
@@ -29,9 +29,11 @@ var deleteImagePolicyCmd = &cobra.Command{
 	Example: `  # Delete an image policy
   flux delete image policy alpine3.x
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imagePolicyType),
 	RunE: deleteCommand{
 		apiType: imagePolicyType,
 		object:  universalAdapter{&imagev1.ImagePolicy{}},
+		list:    imagePolicyListAdapter{&imagev1.ImagePolicyList{}},
 	}.run,
 }
 
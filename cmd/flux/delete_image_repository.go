@@ -29,9 +29,11 @@ var deleteImageRepositoryCmd = &cobra.Command{
 	Example: `  # Delete an image repository
   flux delete image repository alpine
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageRepositoryType),
 	RunE: deleteCommand{
 		apiType: imageRepositoryType,
 		object:  universalAdapter{&imagev1.ImageRepository{}},
+		list:    imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
 	}.run,
 }
 
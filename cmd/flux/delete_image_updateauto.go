@@ -29,9 +29,11 @@ var deleteImageUpdateCmd = &cobra.Command{
 	Example: `  # Delete an image update automation
   flux delete image update latest-images
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageUpdateAutomationType),
 	RunE: deleteCommand{
 		apiType: imageUpdateAutomationType,
 		object:  universalAdapter{&autov1.ImageUpdateAutomation{}},
+		list:    imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
 	}.run,
 }
 
@@ -25,16 +25,28 @@ var deleteKsCmd = &cobra.Command{
 	Use:     "kustomization [name]",
 	Aliases: []string{"ks"},
 	Short:   "Delete a Kustomization resource",
-	Long:    "The delete kustomization command deletes the given Kustomization from the cluster.",
+	Long:    "The delete kustomization command deletes the given Kustomization from the cluster. If pruning is enabled, the objects applied by the Kustomization are deleted too.",
 	Example: `  # Delete a kustomization and the Kubernetes resources created by it
   flux delete kustomization podinfo
+
+  # Delete a Kustomization without asking for confirmation
+  flux delete kustomization podinfo --silent
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizationType),
 	RunE: deleteCommand{
 		apiType: kustomizationType,
-		object:  universalAdapter{&kustomizev1.Kustomization{}},
+		object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
+		list:    kustomizationListAdapter{&kustomizev1.KustomizationList{}},
 	}.run,
 }
 
 func init() {
 	deleteCmd.AddCommand(deleteKsCmd)
 }
+
+func (obj kustomizationAdapter) deletionWarning() string {
+	if !obj.Kustomization.Spec.Prune {
+		return ""
+	}
+	return "the Kustomization has pruning enabled, the objects it applied, including workloads, will be deleted with it"
+}
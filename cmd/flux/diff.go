@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/flags"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Diff local resources against the cluster",
+	Long: `The diff sub-commands build resources locally and compare them with the objects in the cluster.
+With --exit-code, they exit with 0 when there are no differences, 1 when there are, and 2 on errors.`,
+}
+
+type diffFlags struct {
+	exitCode bool
+	output   flags.DiffFormat
+}
+
+var diffArgs = diffFlags{
+	output: flags.TextDiffFormat,
+}
+
+func init() {
+	diffCmd.PersistentFlags().BoolVar(&diffArgs.exitCode, "exit-code", false,
+		"exit with 1 when differences are found and 2 on errors")
+	diffCmd.PersistentFlags().VarP(&diffArgs.output, "output", "o", diffArgs.output.Description())
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+// exitCodeError is an error which makes the CLI exit with a specific
+// code.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// objectDiff is the difference between the live and desired state of
+// an object, the live state being empty when it does not exist and the
+// desired state being empty when it is to be deleted.
+type objectDiff struct {
+	object  string
+	live    string
+	desired string
+}
+
+func (d objectDiff) title() string {
+	switch {
+	case d.live == "":
+		return d.object + " created"
+	case d.desired == "":
+		return d.object + " deleted"
+	default:
+		return d.object + " changed"
+	}
+}
+
+// diffResult prints the differences found for a subject, and returns
+// the error the diff command exits with.
+func diffResult(subject string, diffs []objectDiff, err error) error {
+	if err != nil {
+		if diffArgs.exitCode {
+			return &exitCodeError{code: 2, err: err}
+		}
+		return err
+	}
+
+	if diffArgs.output == flags.MarkdownDiffFormat {
+		writeMarkdownDiffs(os.Stdout, subject, diffs)
+	} else {
+		for _, d := range diffs {
+			writeDiff(os.Stdout, d)
+		}
+	}
+
+	if len(diffs) == 0 {
+		logger.Successf("no differences found for %s", subject)
+		return nil
+	}
+	err = fmt.Errorf("%d object(s) of %s differ", len(diffs), subject)
+	if diffArgs.exitCode {
+		return &exitCodeError{code: 1, err: err}
+	}
+	return err
+}
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines computes the line diff of two texts from their longest
+// common subsequence.
+func diffLines(a, b string) []diffLine {
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+	if a == "" {
+		x = nil
+	}
+	if b == "" {
+		y = nil
+	}
+
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			lines = append(lines, diffLine{' ', x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', x[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		lines = append(lines, diffLine{'-', x[i]})
+	}
+	for ; j < len(y); j++ {
+		lines = append(lines, diffLine{'+', y[j]})
+	}
+	return lines
+}
+
+// writeDiff writes the diff of an object, with the removed lines in red
+// and the added ones in green when w supports colors.
+func writeDiff(w io.Writer, d objectDiff) {
+	color := colorEnabled(w)
+	fmt.Fprintf(w, "%s\n", d.title())
+	for _, line := range diffLines(d.live, d.desired) {
+		text := fmt.Sprintf("%c %s", line.op, line.text)
+		switch {
+		case color && line.op == '-':
+			text = colorize(colorRed, text)
+		case color && line.op == '+':
+			text = colorize(colorGreen, text)
+		}
+		fmt.Fprintln(w, text)
+	}
+}
+
+// writeMarkdownDiffs writes the diffs as a collapsible summary,
+// suitable for a pull request comment.
+func writeMarkdownDiffs(w io.Writer, subject string, diffs []objectDiff) {
+	fmt.Fprintf(w, "### Diff of %s\n\n", subject)
+	if len(diffs) == 0 {
+		fmt.Fprintf(w, "No differences found.\n")
+		return
+	}
+	fmt.Fprintf(w, "%d object(s) differ.\n\n", len(diffs))
+	for _, d := range diffs {
+		fmt.Fprintf(w, "<details>\n<summary><code>%s</code></summary>\n\n```diff\n", d.title())
+		for _, line := range diffLines(d.live, d.desired) {
+			fmt.Fprintf(w, "%c %s\n", line.op, line.text)
+		}
+		fmt.Fprintf(w, "```\n\n</details>\n\n")
+	}
+}
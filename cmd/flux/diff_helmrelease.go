@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+
+	"github.com/fluxcd/flux2/pkg/apply"
+)
+
+var diffHrCmd = &cobra.Command{
+	Use:     "helmrelease [name]",
+	Aliases: []string{"hr"},
+	Short:   "Diff a HelmRelease against its deployed release",
+	Long: `The diff helmrelease command renders the chart of a HelmRelease as the build helmrelease command
+does, and compares the result with the manifest of the latest Helm release, to preview the changes
+of a chart or values update before it is merged. The command fails when they differ.`,
+	Example: `  # Preview the changes of a HelmRelease
+  flux diff helmrelease podinfo
+`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: resourceNamesCompletionFunc(helmReleaseType),
+	RunE:              diffHrCmdRun,
+}
+
+func init() {
+	diffCmd.AddCommand(diffHrCmd)
+}
+
+func diffHrCmdRun(cmd *cobra.Command, args []string) error {
+	subject := fmt.Sprintf("HelmRelease %s/%s", rootArgs.namespace, args[0])
+	diffs, err := diffHelmRelease(args[0])
+	return diffResult(subject, diffs, err)
+}
+
+func diffHelmRelease(name string) ([]objectDiff, error) {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	var helmRelease helmv2.HelmRelease
+	if err := kubeClient.Get(ctx, namespacedName, &helmRelease); err != nil {
+		return nil, err
+	}
+
+	rendered, err := buildHelmRelease(ctx, kubeClient, &helmRelease)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := apply.DecodeObjects([]byte(rendered))
+	if err != nil {
+		return nil, err
+	}
+	deployed, err := helmReleaseManifest(ctx, kubeClient, &helmRelease)
+	if err != nil {
+		return nil, err
+	}
+	live, err := apply.DecodeObjects([]byte(deployed))
+	if err != nil {
+		return nil, err
+	}
+	return diffManifests(live, desired)
+}
+
+// diffManifests returns the differences between two sets of objects,
+// matched by kind, namespace and name. Hooks are left out, as they are
+// not part of the manifest of a Helm release.
+func diffManifests(live, desired []*unstructured.Unstructured) ([]objectDiff, error) {
+	objects := map[string][2]string{}
+	for i, set := range [][]*unstructured.Unstructured{live, desired} {
+		for _, obj := range set {
+			if _, ok := obj.GetAnnotations()["helm.sh/hook"]; ok {
+				continue
+			}
+			data, err := normaliseExport(obj)
+			if err != nil {
+				return nil, err
+			}
+			state := objects[apply.ObjectName(obj)]
+			state[i] = string(data)
+			objects[apply.ObjectName(obj)] = state
+		}
+	}
+
+	var names []string
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diffs []objectDiff
+	for _, name := range names {
+		state := objects[name]
+		if state[0] != state[1] {
+			diffs = append(diffs, objectDiff{object: name, live: state[0], desired: state[1]})
+		}
+	}
+	return diffs, nil
+}
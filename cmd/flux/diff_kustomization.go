@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/pkg/apply"
+)
+
+var diffKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Diff a Kustomization against the cluster",
+	Long: `The diff kustomization command builds the manifests of a local directory as the build kustomization
+command does, and compares them with the objects in the cluster. The desired state of each object
+is computed with a server-side dry-run apply, so that the defaults and the admission webhooks of the
+cluster are taken into account. The command fails when the cluster has drifted from the manifests.`,
+	Example: `  # Preview the changes of a local copy of a Kustomization's path
+  flux diff kustomization my-app --path=./clusters/prod/my-app
+
+  # Write the changes as a pull request comment, failing on errors only
+  flux diff kustomization my-app --path=./clusters/prod/my-app \
+    --exit-code --output=markdown > diff.md || [ $? -eq 1 ]
+`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizationType),
+	RunE:              diffKsCmdRun,
+}
+
+type diffKsFlags struct {
+	path string
+}
+
+var diffKsArgs diffKsFlags
+
+func init() {
+	diffKsCmd.Flags().StringVar(&diffKsArgs.path, "path", "", "path to the local directory of the manifests")
+
+	diffCmd.AddCommand(diffKsCmd)
+}
+
+func diffKsCmdRun(cmd *cobra.Command, args []string) error {
+	subject := fmt.Sprintf("Kustomization %s/%s", rootArgs.namespace, args[0])
+	diffs, err := diffKustomization(args[0])
+	return diffResult(subject, diffs, err)
+}
+
+func diffKustomization(name string) ([]objectDiff, error) {
+	if diffKsArgs.path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	var kustomization kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, namespacedName, &kustomization); err != nil {
+		return nil, err
+	}
+
+	data, err := buildKustomization(ctx, kubeClient, &kustomization, diffKsArgs.path)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := apply.DecodeObjects(data)
+	if err != nil {
+		return nil, err
+	}
+	return diffObjects(ctx, kubeClient, objects)
+}
+
+// diffObjects returns the differences between the objects and their
+// state in the cluster.
+func diffObjects(ctx context.Context, kubeClient client.Client, objects []*unstructured.Unstructured) ([]objectDiff, error) {
+	var diffs []objectDiff
+	for _, obj := range objects {
+		mapping, err := kubeClient.RESTMapper().RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+		if err != nil {
+			return nil, err
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace && obj.GetNamespace() == "" {
+			obj.SetNamespace("default")
+		}
+
+		live, desired, err := diffState(ctx, kubeClient, obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", apply.ObjectName(obj), err)
+		}
+		if live != desired {
+			diffs = append(diffs, objectDiff{object: apply.ObjectName(obj), live: live, desired: desired})
+		}
+	}
+	return diffs, nil
+}
+
+// diffState returns the normalised live state of an object, empty when
+// it does not exist, and the state it would have once applied.
+func diffState(ctx context.Context, kubeClient client.Client, obj *unstructured.Unstructured) (string, string, error) {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", "", err
+	}
+
+	var live []byte
+	if err == nil {
+		if live, err = normaliseExport(existing); err != nil {
+			return "", "", err
+		}
+	}
+
+	dryRun := obj.DeepCopy()
+	if err := kubeClient.Patch(ctx, dryRun, client.Apply, client.DryRunAll,
+		client.ForceOwnership, client.FieldOwner(kustomizev1.GroupVersion.Group)); err != nil {
+		return "", "", err
+	}
+	desired, err := normaliseExport(dryRun)
+	if err != nil {
+		return "", "", err
+	}
+	return string(live), string(desired), nil
+}
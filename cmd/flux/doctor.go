@@ -0,0 +1,280 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Collect diagnostics into an archive for bug reports",
+	Long: `The doctor command collects the versions of the CLI, the cluster and the controllers, the toolkit
+CRDs, the toolkit objects in all namespaces, the logs of the controllers and the events of the toolkit
+objects, into a gzipped tarball that can be attached to bug reports.
+
+Secrets are never collected, and the credentials written inline in the toolkit objects, e.g. the
+passwords in URLs and in HelmRelease values, are redacted unless --show-secrets is given. The
+toolkit objects are collected without their managed fields and last applied configuration, but
+they may still hold information you would rather not share, so review the archive before
+attaching it.`,
+	Example: `  # Collect the diagnostics into flux-doctor.tar.gz
+  flux doctor
+
+  # Collect the diagnostics with the controller logs of the last hour
+  flux doctor --since=1h --output=bundle.tar.gz
+`,
+	Args: cobra.NoArgs,
+	RunE: doctorCmdRun,
+}
+
+type doctorFlags struct {
+	output      string
+	since       time.Duration
+	showSecrets bool
+}
+
+var doctorArgs doctorFlags
+
+func init() {
+	doctorCmd.Flags().StringVarP(&doctorArgs.output, "output", "o", "flux-doctor.tar.gz", "path of the archive to write")
+	doctorCmd.Flags().DurationVar(&doctorArgs.since, "since", 24*time.Hour, "only collect the controller logs newer than this relative duration")
+	doctorCmd.Flags().BoolVar(&doctorArgs.showSecrets, "show-secrets", false,
+		"collect the credentials written inline in the toolkit objects instead of redacting them")
+
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorArchive writes files to a gzipped tarball.
+type doctorArchive struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (a *doctorArchive) add(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := a.tw.Write(data)
+	return err
+}
+
+func (a *doctorArchive) addYAML(name string, value interface{}) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return a.add(name, data)
+}
+
+func (a *doctorArchive) close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+func doctorCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+	cfg, err := getKubeConfig()
+	if err != nil {
+		return err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(doctorArgs.output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	archive := &doctorArchive{gz: gz, tw: tar.NewWriter(gz)}
+
+	logger.Actionf("collecting versions")
+	versions := map[string]interface{}{
+		"flux": VERSION,
+	}
+	if kv, err := clientSet.Discovery().ServerVersion(); err == nil {
+		versions["kubernetes"] = kv.String()
+	} else {
+		logger.Failuref("Kubernetes version can't be determined: %s", err.Error())
+	}
+	var deployments appsv1.DeploymentList
+	selector := client.MatchingLabels{"app.kubernetes.io/instance": rootArgs.namespace}
+	if err := kubeClient.List(ctx, &deployments, client.InNamespace(rootArgs.namespace), selector); err != nil {
+		return err
+	}
+	controllers := map[string]string{}
+	for _, d := range deployments.Items {
+		for _, c := range d.Spec.Template.Spec.Containers {
+			controllers[d.Name] = c.Image
+		}
+	}
+	versions["controllers"] = controllers
+	if err := archive.addYAML("versions.yaml", versions); err != nil {
+		return err
+	}
+
+	logger.Actionf("collecting CRDs")
+	var crds apiextensionsv1.CustomResourceDefinitionList
+	if err := kubeClient.List(ctx, &crds, selector); err != nil {
+		return err
+	}
+	crdVersions := map[string][]string{}
+	for _, crd := range crds.Items {
+		for _, v := range crd.Spec.Versions {
+			if v.Served {
+				crdVersions[crd.Name] = append(crdVersions[crd.Name], v.Name)
+			}
+		}
+	}
+	if err := archive.addYAML("crds.yaml", crdVersions); err != nil {
+		return err
+	}
+
+	logger.Actionf("collecting toolkit objects")
+	for _, export := range allExportCommands() {
+		if err := kubeClient.List(ctx, export.list.asClientList()); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				// the CRD is not installed, e.g. an optional component
+				continue
+			}
+			return err
+		}
+		items, err := apimeta.ExtractList(export.list.asClientList())
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			continue
+		}
+		gvk, err := apiutil.GVKForObject(items[0], kubeClient.Scheme())
+		if err != nil {
+			return err
+		}
+		objects := make([]map[string]interface{}, 0, len(items))
+		for _, item := range items {
+			item.GetObjectKind().SetGroupVersionKind(gvk)
+			accessor, err := apimeta.Accessor(item)
+			if err != nil {
+				return err
+			}
+			accessor.SetManagedFields(nil)
+			annotations := accessor.GetAnnotations()
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			accessor.SetAnnotations(annotations)
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+			if err != nil {
+				return err
+			}
+			if !doctorArgs.showSecrets {
+				redactObject(obj)
+			}
+			objects = append(objects, obj)
+		}
+		if err := archive.addYAML(fmt.Sprintf("objects/%s.yaml", strings.ToLower(gvk.Kind)), objects); err != nil {
+			return err
+		}
+	}
+
+	logger.Actionf("collecting events")
+	var events corev1.EventList
+	if err := kubeClient.List(ctx, &events); err != nil {
+		return err
+	}
+	var toolkitEvents []corev1.Event
+	for _, event := range events.Items {
+		if _, ok := controllerForKind[event.InvolvedObject.Kind]; ok {
+			if !doctorArgs.showSecrets {
+				event.Message = redactURLPassword(event.Message)
+			}
+			toolkitEvents = append(toolkitEvents, event)
+		}
+	}
+	if err := archive.addYAML("events.yaml", mergeEvents(toolkitEvents)); err != nil {
+		return err
+	}
+
+	logger.Actionf("collecting controller logs")
+	pods, err := clientSet.CoreV1().Pods(rootArgs.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	since := int64(doctorArgs.since.Seconds())
+	for _, pod := range pods.Items {
+		for _, c := range pod.Spec.Containers {
+			stream, err := clientSet.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+				Container:    c.Name,
+				SinceSeconds: &since,
+			}).Stream(ctx)
+			if err != nil {
+				logger.Failuref("failed to get the logs of pod %s: %s", pod.Name, err.Error())
+				continue
+			}
+			var buf bytes.Buffer
+			_, err = io.Copy(&buf, stream)
+			stream.Close()
+			if err != nil {
+				return err
+			}
+			if err := archive.add(fmt.Sprintf("logs/%s/%s.log", pod.Name, c.Name), buf.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := archive.close(); err != nil {
+		return err
+	}
+	logger.Successf("diagnostics written to %s", doctorArgs.output)
+	return nil
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Display the Kubernetes events of the toolkit objects",
+	Long: `The events command displays the Kubernetes events emitted by the toolkit controllers, sorted by
+time, with the repeated events merged. With --for, only the events of the given object are displayed,
+along with the events of its source, and of the chart of a HelmRelease.`,
+	Example: `  # Display the events of the toolkit objects in the flux-system namespace
+  flux events
+
+  # Display the events of the toolkit objects in all namespaces
+  flux events --all-namespaces
+
+  # Display the events of a Kustomization and of its source
+  flux events --for Kustomization/podinfo
+`,
+	Args: cobra.NoArgs,
+	RunE: eventsCmdRun,
+}
+
+type eventsFlags struct {
+	forObject     string
+	allNamespaces bool
+}
+
+var eventsArgs eventsFlags
+
+func init() {
+	eventsCmd.Flags().StringVar(&eventsArgs.forObject, "for", "",
+		"only display the events of the object in the format '<kind>/<name>', and of its source")
+	eventsCmd.Flags().BoolVarP(&eventsArgs.allNamespaces, "all-namespaces", "A", false,
+		"display the events in all namespaces, when --for is not used")
+
+	rootCmd.AddCommand(eventsCmd)
+}
+
+// eventTarget identifies an object whose events are displayed.
+type eventTarget struct {
+	kind string
+	types.NamespacedName
+}
+
+func eventsCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	var events []corev1.Event
+	if eventsArgs.forObject != "" {
+		kind, name := utils.ParseObjectKindName(eventsArgs.forObject)
+		if kind == "" {
+			return fmt.Errorf("invalid object '%s', must be in the format '<kind>/<name>'", eventsArgs.forObject)
+		}
+		targets, err := eventTargets(ctx, kubeClient, eventTarget{
+			kind: kind,
+			NamespacedName: types.NamespacedName{
+				Namespace: rootArgs.namespace,
+				Name:      name,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		events, err = listTargetEvents(ctx, kubeClient, targets)
+		if err != nil {
+			return err
+		}
+	} else {
+		var listOpts []client.ListOption
+		if !eventsArgs.allNamespaces {
+			listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
+		}
+		var list corev1.EventList
+		if err := kubeClient.List(ctx, &list, listOpts...); err != nil {
+			return err
+		}
+		for _, event := range list.Items {
+			if _, ok := controllerForKind[event.InvolvedObject.Kind]; ok {
+				events = append(events, event)
+			}
+		}
+	}
+
+	events = mergeEvents(events)
+	if len(events) == 0 {
+		logger.Failuref("no events found")
+		return nil
+	}
+
+	allNamespaces := eventsArgs.allNamespaces && eventsArgs.forObject == ""
+	header := []string{"Last Seen", "Type", "Reason", "Object", "Message"}
+	if allNamespaces {
+		header = append([]string{"Namespace"}, header...)
+	}
+	var rows [][]string
+	for _, event := range events {
+		row := []string{
+			eventTime(event).Format(time.RFC3339),
+			event.Type,
+			event.Reason,
+			fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name),
+			event.Message,
+		}
+		if event.Count > 1 {
+			row[4] = fmt.Sprintf("%s (x%d)", event.Message, event.Count)
+		}
+		if allNamespaces {
+			row = append([]string{event.Namespace}, row...)
+		}
+		rows = append(rows, row)
+	}
+	utils.PrintTable(os.Stdout, header, rows)
+	return nil
+}
+
+// eventTargets returns the given object, followed by the objects it
+// gets its source from.
+func eventTargets(ctx context.Context, kubeClient client.Client, target eventTarget) ([]eventTarget, error) {
+	targets := []eventTarget{target}
+
+	var object reconcileWithSource
+	switch target.kind {
+	case kustomizev1.KustomizationKind:
+		object = kustomizationAdapter{&kustomizev1.Kustomization{}}
+	case helmv2.HelmReleaseKind:
+		object = helmReleaseAdapter{&helmv2.HelmRelease{}}
+	default:
+		return targets, nil
+	}
+
+	if err := kubeClient.Get(ctx, target.NamespacedName, object.asClientObject()); err != nil {
+		return nil, err
+	}
+	source, sourceName, err := object.getSource()
+	if err != nil {
+		return nil, err
+	}
+	if target.kind == helmv2.HelmReleaseKind {
+		// the chart is created by the helm-controller in the namespace
+		// of the source
+		targets = append(targets, eventTarget{
+			kind: sourcev1.HelmChartKind,
+			NamespacedName: types.NamespacedName{
+				Namespace: sourceName.Namespace,
+				Name:      fmt.Sprintf("%s-%s", target.Namespace, target.Name),
+			},
+		})
+	}
+	return append(targets, eventTarget{kind: source.kind, NamespacedName: sourceName}), nil
+}
+
+// listTargetEvents returns the events of the given objects.
+func listTargetEvents(ctx context.Context, kubeClient client.Client, targets []eventTarget) ([]corev1.Event, error) {
+	var events []corev1.Event
+	for _, target := range targets {
+		var list corev1.EventList
+		err := kubeClient.List(ctx, &list, client.InNamespace(target.Namespace), client.MatchingFields{
+			"involvedObject.kind": target.kind,
+			"involvedObject.name": target.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, list.Items...)
+	}
+	return events, nil
+}
+
+// mergeEvents merges the events that have the same object, reason
+// and message, and sorts the result by time.
+func mergeEvents(events []corev1.Event) []corev1.Event {
+	type key struct {
+		uid     types.UID
+		reason  string
+		message string
+	}
+	var merged []corev1.Event
+	index := map[key]int{}
+	for _, event := range events {
+		k := key{event.InvolvedObject.UID, event.Reason, event.Message}
+		i, ok := index[k]
+		if !ok {
+			if event.Count == 0 {
+				event.Count = 1
+			}
+			index[k] = len(merged)
+			merged = append(merged, event)
+			continue
+		}
+		count := event.Count
+		if count == 0 {
+			count = 1
+		}
+		if eventTime(event).After(eventTime(merged[i])) {
+			count += merged[i].Count
+			merged[i] = event
+			merged[i].Count = count
+		} else {
+			merged[i].Count += count
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return eventTime(merged[i]).Before(eventTime(merged[j]))
+	})
+	return merged
+}
+
+// eventTime returns the time an event was last seen.
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
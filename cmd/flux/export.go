@@ -19,30 +19,74 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/yaml"
 
-	"github.com/fluxcd/flux2/internal/utils"
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1alpha1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 )
 
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export resources in YAML format",
-	Long:  "The export sub-commands export resources in YAML format.",
+	Long: `The export sub-commands export resources in YAML format.
+
+The status of the resources and the metadata managed by the cluster are left out, as are the spec
+fields set to the value the API or the controllers default them to, and the fields are written in
+a fixed order, so that exporting unchanged resources always gives the same minimal output.
+
+When run with --all and without a sub-command, export writes every toolkit object in scope as a
+single bundle, ordered so that it can be restored with kubectl apply. Sources come first, followed
+by Kustomizations, HelmReleases, notification and image automation objects. The secrets referred
+to by alert providers and receivers are not exported.
+
+The exported objects are meant to be shared, so the values of secrets and the credentials written
+inline in objects, e.g. the passwords in URLs, are redacted unless --show-secrets is given.`,
+	Example: `  # Export all the toolkit objects in all namespaces, e.g. for disaster recovery
+  flux export --all --all-namespaces > backup.yaml
+
+  # Export all the toolkit objects in all namespaces to a directory tree,
+  # with a file per kind in a directory per namespace
+  flux export --all --all-namespaces --output-dir=./backup
+`,
+	Args: cobra.NoArgs,
+	RunE: exportAllCmdRun,
 }
 
 type exportFlags struct {
-	all bool
+	all           bool
+	allNamespaces bool
+	outputDir     string
+	showSecrets   bool
 }
 
 var exportArgs exportFlags
 
 func init() {
 	exportCmd.PersistentFlags().BoolVar(&exportArgs.all, "all", false, "select all resources")
+	exportCmd.PersistentFlags().BoolVarP(&exportArgs.allNamespaces, "all-namespaces", "A", false,
+		"select the resources in all namespaces, when used with --all")
+	exportCmd.PersistentFlags().BoolVar(&exportArgs.showSecrets, "show-secrets", false,
+		"export the values of secrets and the inline credentials instead of redacting them")
+	exportCmd.Flags().StringVar(&exportArgs.outputDir, "output-dir", "",
+		"write the resources to a directory tree, with a directory per namespace and a file per kind")
 
 	rootCmd.AddCommand(exportCmd)
 }
@@ -61,6 +105,18 @@ type exportableList interface {
 	exportItem(i int) interface{}
 }
 
+// credentialed represents an exportable type that may refer to a
+// secret holding credentials, which can be exported alongside it.
+type credentialed interface {
+	secretRef() *types.NamespacedName
+}
+
+// credentialedList represents a type that has a list of values, each
+// of which may refer to a secret holding credentials.
+type credentialedList interface {
+	secretRefItem(i int) *types.NamespacedName
+}
+
 type exportCommand struct {
 	object exportable
 	list   exportableList
@@ -71,27 +127,26 @@ func (export exportCommand) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("name is required")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
 	if exportArgs.all {
-		err = kubeClient.List(ctx, export.list.asClientList(), client.InNamespace(rootArgs.namespace))
-		if err != nil {
+		if err = export.fetch(ctx, kubeClient); err != nil {
 			return err
 		}
 
 		if export.list.len() == 0 {
-			logger.Failuref("no objects found in %s namespace", rootArgs.namespace)
+			logger.Failuref("no objects found in %s", exportScopeDescription())
 			return nil
 		}
 
 		for i := 0; i < export.list.len(); i++ {
-			if err = printExport(export.list.exportItem(i)); err != nil {
+			if err = export.writeItem(ctx, kubeClient, os.Stdout, i); err != nil {
 				return err
 			}
 		}
@@ -105,21 +160,396 @@ func (export exportCommand) run(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		return printExport(export.object.export())
+		if err = writeExport(os.Stdout, export.object.export(), !exportArgs.showSecrets); err != nil {
+			return err
+		}
+		if creds, ok := export.object.(credentialed); ok && exportSourceWithCred {
+			return exportCredentials(ctx, kubeClient, os.Stdout, creds.secretRef())
+		}
+	}
+	return nil
+}
+
+// fetch lists the objects in scope into the list adapter.
+func (export exportCommand) fetch(ctx context.Context, kubeClient client.Client) error {
+	var listOpts []client.ListOption
+	if !exportArgs.allNamespaces {
+		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
+	}
+	return kubeClient.List(ctx, export.list.asClientList(), listOpts...)
+}
+
+// writeItem writes the i-th fetched object to w, followed by the
+// secret holding its credentials when these were asked for.
+func (export exportCommand) writeItem(ctx context.Context, kubeClient client.Client, w io.Writer, i int) error {
+	if err := writeExport(w, export.list.exportItem(i), !exportArgs.showSecrets); err != nil {
+		return err
+	}
+	if creds, ok := export.list.(credentialedList); ok && exportSourceWithCred {
+		return exportCredentials(ctx, kubeClient, w, creds.secretRefItem(i))
+	}
+	return nil
+}
+
+// allExportCommands returns the export commands for every kind, in
+// the order in which they can be applied to a cluster.
+func allExportCommands() []exportCommand {
+	return []exportCommand{
+		{
+			object: gitRepositoryAdapter{&sourcev1.GitRepository{}},
+			list:   gitRepositoryListAdapter{&sourcev1.GitRepositoryList{}},
+		},
+		{
+			object: helmRepositoryAdapter{&sourcev1.HelmRepository{}},
+			list:   helmRepositoryListAdapter{&sourcev1.HelmRepositoryList{}},
+		},
+		{
+			object: bucketAdapter{&sourcev1.Bucket{}},
+			list:   bucketListAdapter{&sourcev1.BucketList{}},
+		},
+		{
+			object: kustomizationAdapter{&kustomizev1.Kustomization{}},
+			list:   kustomizationListAdapter{&kustomizev1.KustomizationList{}},
+		},
+		{
+			object: helmReleaseAdapter{&helmv2.HelmRelease{}},
+			list:   helmReleaseListAdapter{&helmv2.HelmReleaseList{}},
+		},
+		{
+			object: alertProviderAdapter{&notificationv1.Provider{}},
+			list:   alertProviderListAdapter{&notificationv1.ProviderList{}},
+		},
+		{
+			object: alertAdapter{&notificationv1.Alert{}},
+			list:   alertListAdapter{&notificationv1.AlertList{}},
+		},
+		{
+			object: receiverAdapter{&notificationv1.Receiver{}},
+			list:   receiverListAdapter{&notificationv1.ReceiverList{}},
+		},
+		{
+			object: imageRepositoryAdapter{&imagev1.ImageRepository{}},
+			list:   imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
+		},
+		{
+			object: imagePolicyAdapter{&imagev1.ImagePolicy{}},
+			list:   imagePolicyListAdapter{&imagev1.ImagePolicyList{}},
+		},
+		{
+			object: imageUpdateAutomationAdapter{&autov1.ImageUpdateAutomation{}},
+			list:   imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
+		},
+	}
+}
+
+func exportAllCmdRun(cmd *cobra.Command, args []string) error {
+	if !exportArgs.all {
+		return cmd.Help()
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	// with --output-dir, the objects are collected per file and
+	// written once everything has been fetched
+	var paths []string
+	files := map[string]*bytes.Buffer{}
+
+	count := 0
+	for _, export := range allExportCommands() {
+		if err := export.fetch(ctx, kubeClient); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				// the CRD is not installed, e.g. an optional component
+				continue
+			}
+			return err
+		}
+
+		items, err := apimeta.ExtractList(export.list.asClientList())
+		if err != nil {
+			return err
+		}
+		for i, item := range items {
+			if exportArgs.outputDir == "" {
+				if err := export.writeItem(ctx, kubeClient, os.Stdout, i); err != nil {
+					return err
+				}
+				count++
+				continue
+			}
+
+			gvk, err := apiutil.GVKForObject(item, kubeClient.Scheme())
+			if err != nil {
+				return err
+			}
+			accessor, err := apimeta.Accessor(item)
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(exportArgs.outputDir, accessor.GetNamespace(), strings.ToLower(gvk.Kind)+".yaml")
+			if _, ok := files[path]; !ok {
+				paths = append(paths, path)
+				files[path] = &bytes.Buffer{}
+			}
+			if err := export.writeItem(ctx, kubeClient, files[path], i); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+
+	if count == 0 {
+		logger.Failuref("no objects found in %s", exportScopeDescription())
+		return nil
+	}
+
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, files[path].Bytes(), 0666); err != nil {
+			return err
+		}
+	}
+	if exportArgs.outputDir != "" {
+		logger.Successf("exported %d objects to %s", count, exportArgs.outputDir)
 	}
 	return nil
 }
 
+// exportScopeDescription returns a human readable description of the
+// namespace scope of an export operation.
+func exportScopeDescription() string {
+	if exportArgs.allNamespaces {
+		return "any namespace"
+	}
+	return fmt.Sprintf("%s namespace", rootArgs.namespace)
+}
+
 func printExport(export interface{}) error {
-	data, err := yaml.Marshal(export)
+	return writeExport(os.Stdout, export, false)
+}
+
+// writeExport writes the YAML serialisation of an exported object to
+// w, as a document of a multi-document stream. Secret values and
+// inline credentials are redacted when redact is set.
+func writeExport(w io.Writer, export interface{}, redact bool) error {
+	obj, err := normaliseExportObject(export)
+	if err != nil {
+		return err
+	}
+	if redact {
+		redactObject(obj)
+	}
+	// maps are marshalled with their keys sorted
+	data, err := yaml.Marshal(obj)
 	if err != nil {
 		return err
 	}
-	fmt.Println("---")
-	fmt.Println(resourceToString(data))
+	fmt.Fprintln(w, "---")
+	fmt.Fprintln(w, string(data))
 	return nil
 }
 
+// exportIgnoredAnnotations are the annotations written by tools
+// rather than by users, which would make repeated exports differ
+// when the desired state has not changed.
+var exportIgnoredAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	meta.ReconcileRequestAnnotation,
+}
+
+// exportDefault is a spec field left out of the exported objects when
+// isDefault tells that its value is the one it would be defaulted to,
+// given the rest of the spec.
+type exportDefault struct {
+	path      string
+	isDefault func(value interface{}, spec map[string]interface{}) bool
+}
+
+// exportDefaults are the spec fields of each kind defaulted by the
+// CRDs, or by the controllers when they are not set.
+var exportDefaults = map[string][]exportDefault{
+	sourcev1.GitRepositoryKind: {
+		{"timeout", sameDuration("20s")},
+		{"gitImplementation", equalTo(sourcev1.GoGitImplementation)},
+		{"ref.branch", equalTo("master")},
+	},
+	sourcev1.BucketKind: {
+		{"provider", equalTo(sourcev1.GenericBucketProvider)},
+		{"timeout", sameDuration("20s")},
+	},
+	sourcev1.HelmRepositoryKind: {
+		{"timeout", sameDuration("60s")},
+	},
+	sourcev1.HelmChartKind: {
+		{"version", equalTo("*")},
+	},
+	kustomizev1.KustomizationKind: {
+		{"force", equalTo(false)},
+		{"timeout", sameDurationAsField("interval")},
+		{"retryInterval", sameDurationAsField("interval")},
+	},
+	helmv2.HelmReleaseKind: {
+		{"chart.spec.version", equalTo("*")},
+		{"timeout", sameDuration("5m")},
+		{"maxHistory", equalTo(float64(10))},
+	},
+	notificationv1.AlertKind: {
+		{"eventSeverity", equalTo("info")},
+	},
+	imagev1.ImagePolicyKind: {
+		{"policy.alphabetical.order", equalTo("asc")},
+		{"policy.numerical.order", equalTo("asc")},
+	},
+	autov1.ImageUpdateAutomationKind: {
+		{"update.strategy", equalTo(string(autov1.UpdateStrategySetters))},
+	},
+}
+
+func equalTo(def interface{}) func(interface{}, map[string]interface{}) bool {
+	return func(value interface{}, _ map[string]interface{}) bool {
+		return value == def
+	}
+}
+
+func sameDuration(def string) func(interface{}, map[string]interface{}) bool {
+	return func(value interface{}, _ map[string]interface{}) bool {
+		return durationsEqual(value, def)
+	}
+}
+
+func sameDurationAsField(field string) func(interface{}, map[string]interface{}) bool {
+	return func(value interface{}, spec map[string]interface{}) bool {
+		return durationsEqual(value, spec[field])
+	}
+}
+
+// durationsEqual tells whether two decoded values are the same
+// duration, however they are written, e.g. 5m and 5m0s.
+func durationsEqual(a, b interface{}) bool {
+	as, ok := a.(string)
+	if !ok {
+		return false
+	}
+	bs, ok := b.(string)
+	if !ok {
+		return false
+	}
+	ad, err := time.ParseDuration(as)
+	if err != nil {
+		return false
+	}
+	bd, err := time.ParseDuration(bs)
+	return err == nil && ad == bd
+}
+
+// removeDefaults removes the spec fields of a decoded object that are
+// set to their default, and the maps left empty by their removal.
+func removeDefaults(obj map[string]interface{}) {
+	kind, _ := obj["kind"].(string)
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, def := range exportDefaults[kind] {
+		path := strings.Split(def.path, ".")
+		parents := []map[string]interface{}{spec}
+		for _, key := range path[:len(path)-1] {
+			next, ok := parents[len(parents)-1][key].(map[string]interface{})
+			if !ok {
+				break
+			}
+			parents = append(parents, next)
+		}
+		if len(parents) < len(path) {
+			continue
+		}
+		last := path[len(path)-1]
+		value, ok := parents[len(parents)-1][last]
+		if !ok || !def.isDefault(value, spec) {
+			continue
+		}
+		delete(parents[len(parents)-1], last)
+		for i := len(parents) - 1; i > 0 && len(parents[i]) == 0; i-- {
+			delete(parents[i-1], path[i-1])
+		}
+	}
+}
+
+// normaliseExport serialises an exported object to YAML, so that
+// exporting an unchanged object always gives the same output: the
+// status and the metadata managed by the API server are removed, as
+// are null values and the spec fields set to their default, and map
+// keys are sorted.
+func normaliseExport(export interface{}) ([]byte, error) {
+	obj, err := normaliseExportObject(export)
+	if err != nil {
+		return nil, err
+	}
+
+	// maps are marshalled with their keys sorted
+	return yaml.Marshal(obj)
+}
+
+// normaliseExportObject decodes an exported object into a map, with
+// the fields that normaliseExport leaves out removed.
+func normaliseExportObject(export interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"creationTimestamp", "uid", "resourceVersion", "generation", "managedFields", "selfLink"} {
+			delete(metadata, field)
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			for _, annotation := range exportIgnoredAnnotations {
+				delete(annotations, annotation)
+			}
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+		if labels, ok := metadata["labels"].(map[string]interface{}); ok && len(labels) == 0 {
+			delete(metadata, "labels")
+		}
+	}
+	removeNulls(obj)
+	removeDefaults(obj)
+	return obj, nil
+}
+
+// removeNulls removes the null values from a decoded JSON object,
+// recursively.
+func removeNulls(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == nil {
+				delete(v, key)
+				continue
+			}
+			removeNulls(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			removeNulls(item)
+		}
+	}
+}
+
 func resourceToString(data []byte) string {
 	data = bytes.Replace(data, []byte("  creationTimestamp: null\n"), []byte(""), 1)
 	data = bytes.Replace(data, []byte("status: {}\n"), []byte(""), 1)
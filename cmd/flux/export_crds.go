@@ -0,0 +1,208 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/apply"
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+)
+
+var exportCRDsCmd = &cobra.Command{
+	Use:   "crds",
+	Short: "Export the CRDs of the toolkit components",
+	Long: `The export crds command writes the custom resource definitions of the selected components, as found
+in the install manifests of the given version, or their OpenAPI schemas as JSON schemas, so that validation
+tools, editors and admission policies use the schemas matching the installed version.
+
+The JSON schemas are written to a file per kind and API version, in a directory per API group, e.g.
+kustomize.toolkit.fluxcd.io/kustomization_v1beta1.json, the layout kubeconform reads with the schema location
+'<output-dir>/{{.Group}}/{{.ResourceKind}}_{{.ResourceAPIVersion}}.json'.`,
+	Example: `  # Export the CRDs of the default components
+  flux export crds > crds.yaml
+
+  # Export the JSON schemas of all the components for kubeconform
+  flux export crds --components-extra=image-reflector-controller,image-automation-controller \
+    --format=jsonschema --output-dir=./schemas
+
+  kubeconform -schema-location default \
+    -schema-location './schemas/{{.Group}}/{{.ResourceKind}}_{{.ResourceAPIVersion}}.json' \
+    ./clusters
+`,
+	Args: cobra.NoArgs,
+	RunE: exportCRDsCmdRun,
+}
+
+type exportCRDsFlags struct {
+	version           string
+	defaultComponents []string
+	extraComponents   []string
+	format            flags.CRDFormat
+	outputDir         string
+}
+
+var exportCRDsArgs = exportCRDsFlags{
+	format: flags.YAMLCRDFormat,
+}
+
+func init() {
+	exportCRDsCmd.Flags().StringVarP(&exportCRDsArgs.version, "version", "v", "",
+		"toolkit version, the manifests of the version of the CLI are embedded in the binary, the ones of other versions are downloaded from https://github.com/fluxcd/flux2/releases")
+	exportCRDsCmd.Flags().StringSliceVar(&exportCRDsArgs.defaultComponents, "components", rootArgs.defaults.Components,
+		"list of components, accepts comma-separated values")
+	exportCRDsCmd.Flags().StringSliceVar(&exportCRDsArgs.extraComponents, "components-extra", nil,
+		"list of components in addition to those supplied or defaulted, accepts comma-separated values")
+	exportCRDsCmd.Flags().Var(&exportCRDsArgs.format, "format", exportCRDsArgs.format.Description())
+	exportCRDsCmd.Flags().StringVar(&exportCRDsArgs.outputDir, "output-dir", "",
+		"write the CRDs to a file each, or the JSON schemas to a directory per API group, instead of stdout")
+
+	exportCmd.AddCommand(exportCRDsCmd)
+}
+
+func exportCRDsCmdRun(cmd *cobra.Command, args []string) error {
+	if exportCRDsArgs.format == flags.JSONSchemaCRDFormat && exportCRDsArgs.outputDir == "" {
+		return fmt.Errorf("--output-dir is required with --format=%s", flags.JSONSchemaCRDFormat)
+	}
+
+	components := append(exportCRDsArgs.defaultComponents, exportCRDsArgs.extraComponents...)
+	if err := utils.ValidateComponents(components); err != nil {
+		return err
+	}
+	version, err := getVersion(exportCRDsArgs.version)
+	if err != nil {
+		return err
+	}
+
+	crds, err := generateCRDs(version, components)
+	if err != nil {
+		return err
+	}
+
+	if exportCRDsArgs.format == flags.JSONSchemaCRDFormat {
+		return writeCRDSchemas(crds, exportCRDsArgs.outputDir)
+	}
+
+	for _, crd := range crds {
+		data, err := normaliseExport(crd)
+		if err != nil {
+			return err
+		}
+		if exportCRDsArgs.outputDir == "" {
+			fmt.Printf("---\n%s", data)
+			continue
+		}
+		if err := os.MkdirAll(exportCRDsArgs.outputDir, os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(exportCRDsArgs.outputDir, crd.Name+".yaml"), data, 0644); err != nil {
+			return err
+		}
+	}
+	if exportCRDsArgs.outputDir != "" {
+		logger.Successf("%d CRDs of flux %s written to %s", len(crds), version, exportCRDsArgs.outputDir)
+	}
+	return nil
+}
+
+// generateCRDs returns the CRDs of the install manifests of the given
+// version and components.
+func generateCRDs(version string, components []string) ([]apiextensionsv1.CustomResourceDefinition, error) {
+	tmpDir, err := ioutil.TempDir("", rootArgs.namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifestsBase := ""
+	if isEmbeddedVersion(version) {
+		if err := writeEmbeddedManifests(tmpDir); err != nil {
+			return nil, err
+		}
+		manifestsBase = tmpDir
+	}
+
+	opts := install.MakeDefaultOptions()
+	opts.Version = version
+	opts.Namespace = rootArgs.namespace
+	opts.Components = components
+	opts.ManifestFile = fmt.Sprintf("%s.yaml", rootArgs.namespace)
+	opts.Timeout = rootArgs.timeout
+	manifest, err := install.Generate(opts, manifestsBase)
+	if err != nil {
+		return nil, fmt.Errorf("generating the manifests of flux %s failed: %w", version, err)
+	}
+
+	objects, err := apply.DecodeObjects([]byte(manifest.Content))
+	if err != nil {
+		return nil, err
+	}
+	var crds []apiextensionsv1.CustomResourceDefinition
+	for _, obj := range objects {
+		if obj.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &crd); err != nil {
+			return nil, fmt.Errorf("%s: %w", apply.ObjectName(obj), err)
+		}
+		crd.TypeMeta.APIVersion = apiextensionsv1.SchemeGroupVersion.String()
+		crd.TypeMeta.Kind = "CustomResourceDefinition"
+		crds = append(crds, crd)
+	}
+	return crds, nil
+}
+
+// writeCRDSchemas writes the OpenAPI schema of each version of the CRDs
+// as a JSON schema, to <dir>/<group>/<kind>_<version>.json.
+func writeCRDSchemas(crds []apiextensionsv1.CustomResourceDefinition, dir string) error {
+	count := 0
+	for _, crd := range crds {
+		groupDir := filepath.Join(dir, crd.Spec.Group)
+		if err := os.MkdirAll(groupDir, os.ModePerm); err != nil {
+			return err
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+				logger.Warningf("%s: version %s has no schema", crd.Name, v.Name)
+				continue
+			}
+			data, err := json.MarshalIndent(v.Schema.OpenAPIV3Schema, "", "  ")
+			if err != nil {
+				return err
+			}
+			name := fmt.Sprintf("%s_%s.json", strings.ToLower(crd.Spec.Names.Kind), v.Name)
+			if err := ioutil.WriteFile(filepath.Join(groupDir, name), append(data, '\n'), 0644); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+	logger.Successf("%d JSON schemas written to %s", count, dir)
+	return nil
+}
@@ -33,6 +33,7 @@ var exportImagePolicyCmd = &cobra.Command{
   # Export a specific policy
   flux export image policy alpine1x > alpine1x.yaml
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imagePolicyType),
 	RunE: exportCommand{
 		object: imagePolicyAdapter{&imagev1.ImagePolicy{}},
 		list:   imagePolicyListAdapter{&imagev1.ImagePolicyList{}},
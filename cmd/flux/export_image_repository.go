@@ -33,6 +33,7 @@ var exportImageRepositoryCmd = &cobra.Command{
   # Export a specific ImageRepository resource
   flux export image repository alpine > alpine.yaml
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageRepositoryType),
 	RunE: exportCommand{
 		object: imageRepositoryAdapter{&imagev1.ImageRepository{}},
 		list:   imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
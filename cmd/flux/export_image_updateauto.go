@@ -33,6 +33,7 @@ var exportImageUpdateCmd = &cobra.Command{
   # Export a specific automation
   flux export image update latest-images > latest.yaml
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageUpdateAutomationType),
 	RunE: exportCommand{
 		object: imageUpdateAutomationAdapter{&autov1.ImageUpdateAutomation{}},
 		list:   imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
@@ -17,7 +17,15 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
+
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var exportSourceCmd = &cobra.Command{
@@ -35,3 +43,31 @@ func init() {
 
 	exportCmd.AddCommand(exportSourceCmd)
 }
+
+// exportCredentials writes the secret holding the credentials of a
+// source to w, if it refers to one.
+func exportCredentials(ctx context.Context, kubeClient client.Client, w io.Writer, namespacedName *types.NamespacedName) error {
+	if namespacedName == nil {
+		return nil
+	}
+
+	var cred corev1.Secret
+	err := kubeClient.Get(ctx, *namespacedName, &cred)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve secret %s, error: %w", namespacedName.Name, err)
+	}
+
+	exported := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namespacedName.Name,
+			Namespace: namespacedName.Namespace,
+		},
+		Data: cred.Data,
+		Type: cred.Type,
+	}
+	return writeExport(w, exported, !exportArgs.showSecrets)
+}
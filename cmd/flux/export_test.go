@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+func TestNormaliseExport(t *testing.T) {
+	// the objects as read from the cluster, with the defaults set by
+	// the API server and the metadata it manages
+	clusterMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "flux-system",
+			UID:               "4f5e0a9c-7d3b-4c1e-9a2b-0c6d8e1f2a3b",
+			ResourceVersion:   "1234",
+			Generation:        3,
+			CreationTimestamp: metav1.NewTime(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)),
+			Annotations: map[string]string{
+				meta.ReconcileRequestAnnotation: "2021-03-01T00:00:00Z",
+			},
+		}
+	}
+	tests := []struct {
+		name   string
+		export interface{}
+		want   string
+	}{
+		{
+			name: "git repository",
+			export: sourcev1.GitRepository{
+				TypeMeta:   metav1.TypeMeta{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.GitRepositoryKind},
+				ObjectMeta: clusterMeta("podinfo"),
+				Spec: sourcev1.GitRepositorySpec{
+					URL:               "https://github.com/stefanprodan/podinfo",
+					Interval:          metav1.Duration{Duration: time.Minute},
+					Timeout:           &metav1.Duration{Duration: 20 * time.Second},
+					Reference:         &sourcev1.GitRepositoryRef{Branch: "master"},
+					GitImplementation: sourcev1.GoGitImplementation,
+				},
+				Status: sourcev1.GitRepositoryStatus{URL: "http://source-controller/gitrepository/flux-system/podinfo/latest.tar.gz"},
+			},
+			want: `apiVersion: source.toolkit.fluxcd.io/v1beta1
+kind: GitRepository
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  interval: 1m0s
+  url: https://github.com/stefanprodan/podinfo
+`,
+		},
+		{
+			name: "kustomization",
+			export: kustomizev1.Kustomization{
+				TypeMeta:   metav1.TypeMeta{APIVersion: kustomizev1.GroupVersion.String(), Kind: kustomizev1.KustomizationKind},
+				ObjectMeta: clusterMeta("apps"),
+				Spec: kustomizev1.KustomizationSpec{
+					Interval:      metav1.Duration{Duration: 10 * time.Minute},
+					RetryInterval: &metav1.Duration{Duration: 10 * time.Minute},
+					Timeout:       &metav1.Duration{Duration: 2 * time.Minute},
+					Path:          "./apps",
+					Prune:         false,
+					SourceRef:     kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.GitRepositoryKind, Name: "podinfo"},
+				},
+			},
+			want: `apiVersion: kustomize.toolkit.fluxcd.io/v1beta1
+kind: Kustomization
+metadata:
+  name: apps
+  namespace: flux-system
+spec:
+  interval: 10m0s
+  path: ./apps
+  prune: false
+  sourceRef:
+    kind: GitRepository
+    name: podinfo
+  timeout: 2m0s
+`,
+		},
+		{
+			name: "helm release",
+			export: helmv2.HelmRelease{
+				TypeMeta:   metav1.TypeMeta{APIVersion: helmv2.GroupVersion.String(), Kind: helmv2.HelmReleaseKind},
+				ObjectMeta: clusterMeta("podinfo"),
+				Spec: helmv2.HelmReleaseSpec{
+					Interval: metav1.Duration{Duration: 5 * time.Minute},
+					Timeout:  &metav1.Duration{Duration: 300 * time.Second},
+					Chart: helmv2.HelmChartTemplate{
+						Spec: helmv2.HelmChartTemplateSpec{
+							Chart:     "podinfo",
+							Version:   "*",
+							SourceRef: helmv2.CrossNamespaceObjectReference{Kind: sourcev1.HelmRepositoryKind, Name: "podinfo"},
+						},
+					},
+				},
+			},
+			want: `apiVersion: helm.toolkit.fluxcd.io/v2beta1
+kind: HelmRelease
+metadata:
+  name: podinfo
+  namespace: flux-system
+spec:
+  chart:
+    spec:
+      chart: podinfo
+      sourceRef:
+        kind: HelmRepository
+        name: podinfo
+  interval: 5m0s
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normaliseExport(tt.export)
+			if err != nil {
+				t.Fatalf("normaliseExport() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("normaliseExport() =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			// exporting the object applied from the export gives the
+			// same output
+			var obj map[string]interface{}
+			if err := yaml.Unmarshal(got, &obj); err != nil {
+				t.Fatalf("decoding the export failed: %v", err)
+			}
+			again, err := normaliseExport(obj)
+			if err != nil {
+				t.Fatalf("normaliseExport() error = %v", err)
+			}
+			if string(again) != string(got) {
+				t.Errorf("normaliseExport() of the export =\n%s\nwant\n%s", again, got)
+			}
+		})
+	}
+}
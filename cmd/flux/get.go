@@ -18,15 +18,34 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/pkg/apis/meta"
 
+	"github.com/fluxcd/flux2/internal/flags"
 	"github.com/fluxcd/flux2/internal/utils"
 )
 
@@ -37,14 +56,28 @@ var getCmd = &cobra.Command{
 }
 
 type GetFlags struct {
-	allNamespaces bool
+	allNamespaces  bool
+	output         flags.OutputFormat
+	watch          bool
+	labelSelector  string
+	statusSelector flags.StatusSelector
+	sortBy         flags.SortKey
 }
 
-var getArgs GetFlags
+var getArgs = GetFlags{
+	output: flags.TableOutputFormat,
+}
 
 func init() {
 	getCmd.PersistentFlags().BoolVarP(&getArgs.allNamespaces, "all-namespaces", "A", false,
 		"list the requested object(s) across all namespaces")
+	getCmd.PersistentFlags().VarP(&getArgs.output, "output", "o", getArgs.output.Description())
+	getCmd.PersistentFlags().StringVarP(&getArgs.labelSelector, "selector", "l", "",
+		"list only the objects matching this label selector (e.g. team=payments)")
+	getCmd.PersistentFlags().Var(&getArgs.statusSelector, "status-selector", getArgs.statusSelector.Description())
+	getCmd.PersistentFlags().Var(&getArgs.sortBy, "sort-by", getArgs.sortBy.Description())
+	getCmd.PersistentFlags().BoolVarP(&getArgs.watch, "watch", "w", false,
+		"after listing the requested object(s), watch for changes and print them as they happen")
 	rootCmd.AddCommand(getCmd)
 }
 
@@ -60,9 +93,24 @@ func statusAndMessage(conditions []metav1.Condition) (string, string) {
 	if c := apimeta.FindStatusCondition(conditions, meta.ReadyCondition); c != nil {
 		return string(c.Status), c.Message
 	}
+	// without a Ready condition, the latest transition is the most
+	// telling about what the controller is doing
+	if c := latestCondition(conditions); c != nil && c.Message != "" {
+		return string(metav1.ConditionFalse), c.Message
+	}
 	return string(metav1.ConditionFalse), "waiting to be reconciled"
 }
 
+func latestCondition(conditions []metav1.Condition) *metav1.Condition {
+	var latest *metav1.Condition
+	for i := range conditions {
+		if latest == nil || conditions[i].LastTransitionTime.After(latest.LastTransitionTime.Time) {
+			latest = &conditions[i]
+		}
+	}
+	return latest
+}
+
 func nameColumns(item named, includeNamespace bool) []string {
 	if includeNamespace {
 		return []string{item.GetNamespace(), item.GetName()}
@@ -78,39 +126,529 @@ type getCommand struct {
 }
 
 func (get getCommand) run(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	if getArgs.watch && structuredOutput() {
+		return fmt.Errorf("the --watch flag can only be used with the table and wide output formats")
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
+	if err := get.fetch(ctx, kubeClient, args); err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return printStructured(os.Stdout, kubeClient.Scheme(), get.list)
+	}
+
+	if get.list.len() == 0 {
+		logger.Failuref("no %s objects found in %s", get.kind, scopeDescription())
+	} else if err := get.print(os.Stdout, false); err != nil {
+		return err
+	}
+
+	if getArgs.watch {
+		// watch until interrupted, rather than until the timeout
+		return get.watch(rootCtx, kubeClient, args)
+	}
+	return nil
+}
+
+// fetch lists the objects in the namespace scope of this operation,
+// or only the named object if a name was given, optionally filtered
+// by label and status.
+func (get getCommand) fetch(ctx context.Context, kubeClient client.Client, args []string) error {
 	var listOpts []client.ListOption
 	if !getArgs.allNamespaces {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
 	}
 
-	if len(args) > 0 {
+	// a single name is selected by the API server, several names or
+	// patterns are matched once the objects are listed
+	if len(args) == 1 && !isNamePattern(args[0]) {
 		listOpts = append(listOpts, client.MatchingFields{"metadata.name": args[0]})
 	}
 
-	err = kubeClient.List(ctx, get.list.asClientList(), listOpts...)
+	if getArgs.labelSelector != "" {
+		sel, err := labels.Parse(getArgs.labelSelector)
+		if err != nil {
+			return fmt.Errorf("invalid label selector '%s': %w", getArgs.labelSelector, err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: sel})
+	}
+
+	list := get.list.asClientList()
+	if err := kubeClient.List(ctx, list, listOpts...); err != nil {
+		return err
+	}
+	if err := filterByNames(list, args); err != nil {
+		return err
+	}
+	return filterByStatus(list)
+}
+
+// filterByNames removes the objects that are not named in the
+// arguments, nor match one of the name patterns, from a list.
+func filterByNames(list client.ObjectList, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	items, err := apimeta.ExtractList(list)
 	if err != nil {
 		return err
 	}
+	var selected []runtime.Object
+	for _, item := range items {
+		o, err := apimeta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		matched, err := matchNames(o.GetName(), args)
+		if err != nil {
+			return err
+		}
+		if matched {
+			selected = append(selected, item)
+		}
+	}
+	return apimeta.SetList(list, selected)
+}
 
-	if get.list.len() == 0 {
-		logger.Failuref("no %s objects found in %s namespace", get.kind, rootArgs.namespace)
+// filterByStatus removes the objects that don't match the selector set
+// with --status-selector from a list, which the API server can't do as
+// the status is not a field selector of custom resources.
+func filterByStatus(list client.ObjectList) error {
+	if getArgs.statusSelector.String() == "" {
 		return nil
 	}
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var selected []runtime.Object
+	for _, item := range items {
+		summary, err := summariseStatus(item)
+		if err != nil {
+			return err
+		}
+		if getArgs.statusSelector.Matches(summary.isReady(), summary.isSuspended()) {
+			selected = append(selected, item)
+		}
+	}
+	return apimeta.SetList(list, selected)
+}
 
+// print renders the fetched objects as a table. When includeKind is
+// set, the names are prefixed with the lower-cased kind, so tables of
+// different kinds can be told apart.
+func (get getCommand) print(w io.Writer, includeKind bool) error {
 	header := get.list.headers(getArgs.allNamespaces)
+	if getArgs.output == flags.WideOutputFormat {
+		header = append(header, wideHeaders...)
+	}
+	nameIdx := 0
+	if getArgs.allNamespaces {
+		nameIdx = 1
+	}
+	items, err := apimeta.ExtractList(get.list.asClientList())
+	if err != nil {
+		return err
+	}
 	var rows [][]string
-	for i := 0; i < get.list.len(); i++ {
-		row := get.list.summariseItem(i, getArgs.allNamespaces)
+	for i, item := range items {
+		row, err := get.row(i, item)
+		if err != nil {
+			return err
+		}
+		if includeKind {
+			row[nameIdx] = fmt.Sprintf("%s/%s", strings.ToLower(get.kind), row[nameIdx])
+		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
+	if getArgs.sortBy != "" {
+		if err := sortRows(rows, items); err != nil {
+			return err
+		}
+	}
+	utils.PrintTable(w, header, rows)
+	return nil
+}
+
+// sortRows orders the rows of the given objects in place, by the key
+// requested with --sort-by. Objects that are not ready and the ones
+// that have not been reconciled for the longest come first.
+func sortRows(rows [][]string, items []runtime.Object) error {
+	summaries := make([]statusSummary, len(items))
+	for i, item := range items {
+		summary, err := summariseStatus(item)
+		if err != nil {
+			return err
+		}
+		summaries[i] = summary
+	}
+
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := summaries[order[a]], summaries[order[b]]
+		switch getArgs.sortBy {
+		case flags.NameSortKey:
+			if x.Namespace != y.Namespace {
+				return x.Namespace < y.Namespace
+			}
+			return x.Name < y.Name
+		case flags.ReadySortKey:
+			return !x.isReady() && y.isReady()
+		case flags.LastReconcileSortKey:
+			return x.lastTransition().Before(y.lastTransition())
+		}
+		return false
+	})
+
+	sorted := make([][]string, len(rows))
+	for i, j := range order {
+		sorted[i] = rows[j]
+	}
+	copy(rows, sorted)
+	return nil
+}
+
+// row summarises the i-th fetched object, which is given as obj, adding
+// the wide columns if requested with --output.
+func (get getCommand) row(i int, obj runtime.Object) ([]string, error) {
+	row := get.list.summariseItem(i, getArgs.allNamespaces)
+	if colorEnabled(os.Stdout) {
+		if err := colorStatus(row, obj); err != nil {
+			return nil, err
+		}
+	}
+	if getArgs.output != flags.WideOutputFormat {
+		return row, nil
+	}
+	columns, err := wideColumns(obj)
+	if err != nil {
+		return nil, err
+	}
+	return append(row, columns...), nil
+}
+
+// colorStatus colors the Ready column of a row, in yellow when the
+// object is suspended, and otherwise in green or red depending on
+// whether the object is ready.
+func colorStatus(row []string, obj runtime.Object) error {
+	summary, err := summariseStatus(obj)
+	if err != nil {
+		return err
+	}
+	readyIdx := 1
+	if getArgs.allNamespaces {
+		readyIdx = 2
+	}
+	color := colorRed
+	switch {
+	case summary.isSuspended():
+		color = colorYellow
+	case summary.isReady():
+		color = colorGreen
+	}
+	row[readyIdx] = colorize(color, row[readyIdx])
+	return nil
+}
+
+var wideHeaders = []string{"Reason", "Observed Generation", "Last Transition"}
+
+// wideColumns returns the Ready condition reason, the observed
+// generation and the age of the last Ready transition of the given
+// object.
+func wideColumns(obj runtime.Object) ([]string, error) {
+	summary, err := summariseStatus(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	reason, transition := "", ""
+	if c := apimeta.FindStatusCondition(summary.Status.Conditions, meta.ReadyCondition); c != nil {
+		reason = c.Reason
+		transition = duration.HumanDuration(time.Since(c.LastTransitionTime.Time))
+	}
+	return []string{reason, strconv.FormatInt(summary.Status.ObservedGeneration, 10), transition}, nil
+}
+
+// statusSummary holds the fields all the Flux APIs have in common.
+type statusSummary struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		Suspend bool `json:"suspend,omitempty"`
+	} `json:"spec,omitempty"`
+	Status struct {
+		ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+		Conditions         []metav1.Condition `json:"conditions,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+func (s statusSummary) isReady() bool {
+	return apimeta.IsStatusConditionTrue(s.Status.Conditions, meta.ReadyCondition)
+}
+
+func (s statusSummary) isSuspended() bool {
+	return s.Spec.Suspend
+}
+
+// lastTransition returns the time of the latest condition transition,
+// which is the best indication of when the object was last reconciled.
+func (s statusSummary) lastTransition() time.Time {
+	if c := latestCondition(s.Status.Conditions); c != nil {
+		return c.LastTransitionTime.Time
+	}
+	return time.Time{}
+}
+
+// summariseStatus reads the common fields of a Flux object from its
+// unstructured form, so that they do not need to be read per kind.
+func summariseStatus(obj runtime.Object) (statusSummary, error) {
+	var summary statusSummary
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return summary, err
+	}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(u, &summary)
+	return summary, err
+}
+
+// structuredOutput reports whether the objects are to be printed as
+// JSON or YAML, rather than as a table.
+func structuredOutput() bool {
+	return getArgs.output == flags.JSONOutputFormat || getArgs.output == flags.YAMLOutputFormat
+}
+
+// watch prints a row for each object in scope every time its summary
+// changes, starting from the resource version of the fetched list. It
+// returns when the server closes the watch.
+func (get getCommand) watch(ctx context.Context, kubeClient client.Client, args []string) error {
+	cfg, err := getKubeConfig()
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	list := get.list.asClientList()
+	gvk, err := apiutil.GVKForObject(list, kubeClient.Scheme())
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	mapping, err := kubeClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+
+	var resource dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+	if !getArgs.allNamespaces {
+		resource = dynamicClient.Resource(mapping.Resource).Namespace(rootArgs.namespace)
+	}
+	opts := metav1.ListOptions{
+		ResourceVersion: list.GetResourceVersion(),
+	}
+	if len(args) == 1 && !isNamePattern(args[0]) {
+		opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", args[0]).String()
+	}
+	if getArgs.labelSelector != "" {
+		opts.LabelSelector = getArgs.labelSelector
+	}
+	watcher, err := resource.Watch(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	// remember what was printed last for each object, so that only
+	// transitions are printed
+	printed := map[string][]string{}
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		o, err := apimeta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		row, err := get.row(i, item)
+		if err != nil {
+			return err
+		}
+		printed[o.GetNamespace()+"/"+o.GetName()] = row
+	}
+
+	for event := range watcher.ResultChan() {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+		case watch.Error:
+			return apierrors.FromObject(event.Object)
+		default:
+			continue
+		}
+
+		u, ok := event.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if len(args) > 0 {
+			if matched, err := matchNames(u.GetName(), args); err != nil || !matched {
+				continue
+			}
+		}
+		obj, err := kubeClient.Scheme().New(gvk)
+		if err != nil {
+			return err
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+			return err
+		}
+		if err := apimeta.SetList(list, []runtime.Object{obj}); err != nil {
+			return err
+		}
+
+		row, err := get.row(0, obj)
+		if err != nil {
+			return err
+		}
+		key := u.GetNamespace() + "/" + u.GetName()
+		last, ok := printed[key]
+		if ok && reflect.DeepEqual(last, row) {
+			continue
+		}
+		summary, err := summariseStatus(obj)
+		if err != nil {
+			return err
+		}
+		if !getArgs.statusSelector.Matches(summary.isReady(), summary.isSuspended()) {
+			// print the object leaving the selection once, e.g. when it
+			// recovers, and forget it until it matches again
+			if ok {
+				delete(printed, key)
+				utils.PrintTable(os.Stdout, nil, [][]string{row})
+			}
+			continue
+		}
+		printed[key] = row
+		utils.PrintTable(os.Stdout, nil, [][]string{row})
+	}
 	return nil
 }
+
+// getAll fetches and prints each of the given kinds in turn, skipping
+// the ones that have no objects in scope.
+func getAll(commands []getCommand, args []string) error {
+	if getArgs.watch {
+		return fmt.Errorf("the --watch flag is not supported when getting multiple kinds")
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	var lists []listAdapter
+	for _, get := range commands {
+		if err := get.fetch(ctx, kubeClient, args); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				// the CRD is not installed, e.g. an optional component
+				continue
+			}
+			return err
+		}
+		if get.list.len() == 0 {
+			continue
+		}
+		if structuredOutput() {
+			lists = append(lists, get.list)
+			continue
+		}
+		if found {
+			fmt.Fprintln(os.Stdout)
+		}
+		if err := get.print(os.Stdout, true); err != nil {
+			return err
+		}
+		found = true
+	}
+
+	if structuredOutput() {
+		return printStructured(os.Stdout, kubeClient.Scheme(), lists...)
+	}
+
+	if !found {
+		logger.Failuref("no objects found in %s", scopeDescription())
+	}
+	return nil
+}
+
+// scopeDescription returns a human readable description of the
+// namespace scope of a get operation.
+func scopeDescription() string {
+	if getArgs.allNamespaces {
+		return "any namespace"
+	}
+	return fmt.Sprintf("%s namespace", rootArgs.namespace)
+}
+
+// printStructured renders the objects of the given lists as a single
+// v1 List, in the output format requested with --output. An empty
+// list is printed when there are no objects, so the output can
+// always be parsed.
+func printStructured(w io.Writer, scheme *runtime.Scheme, lists ...listAdapter) error {
+	out := metav1.List{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "List",
+		},
+		Items: []runtime.RawExtension{},
+	}
+	for _, list := range lists {
+		items, err := apimeta.ExtractList(list.asClientList())
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			// the typed client does not fill in the kind of list items
+			gvk, err := apiutil.GVKForObject(item, scheme)
+			if err != nil {
+				return err
+			}
+			item.GetObjectKind().SetGroupVersionKind(gvk)
+			out.Items = append(out.Items, runtime.RawExtension{Object: item})
+		}
+	}
+
+	var data []byte
+	var err error
+	switch getArgs.output {
+	case flags.JSONOutputFormat:
+		data, err = json.MarshalIndent(out, "", "  ")
+		data = append(data, '\n')
+	case flags.YAMLOutputFormat:
+		data, err = yaml.Marshal(out)
+	default:
+		return fmt.Errorf("unsupported output format '%s'", getArgs.output)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
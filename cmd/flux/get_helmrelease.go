@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -31,6 +32,12 @@ var getHelmReleaseCmd = &cobra.Command{
 	Long:    "The get helmreleases command prints the statuses of the resources.",
 	Example: `  # List all Helm releases and their status
   flux get helmreleases
+
+  # List Helm releases from all namespaces
+  flux get helmreleases --all-namespaces
+
+  # Watch the Helm releases for status changes
+  flux get helmreleases --watch
 `,
 	RunE: getCommand{
 		apiType: helmReleaseType,
@@ -47,13 +54,23 @@ func (a helmReleaseListAdapter) summariseItem(i int, includeNamespace bool) []st
 	revision := item.Status.LastAppliedRevision
 	status, msg := statusAndMessage(item.Status.Conditions)
 	return append(nameColumns(&item, includeNamespace),
-		status, msg, revision, strings.Title(strconv.FormatBool(item.Spec.Suspend)))
+		status, msg, revision, helmReleaseChart(item), strings.Title(strconv.FormatBool(item.Spec.Suspend)))
 }
 
 func (a helmReleaseListAdapter) headers(includeNamespace bool) []string {
-	headers := []string{"Name", "Ready", "Message", "Revision", "Suspended"}
+	headers := []string{"Name", "Ready", "Message", "Revision", "Chart", "Suspended"}
 	if includeNamespace {
 		headers = append([]string{"Namespace"}, headers...)
 	}
 	return headers
 }
+
+// helmReleaseChart returns the chart name of the release, including
+// the requested version range when one is set.
+func helmReleaseChart(item helmv2.HelmRelease) string {
+	chart := item.Spec.Chart.Spec.Chart
+	if version := item.Spec.Chart.Spec.Version; version != "" && version != "*" {
+		chart = fmt.Sprintf("%s@%s", chart, version)
+	}
+	return chart
+}
@@ -24,7 +24,9 @@ var getImageCmd = &cobra.Command{
 	Use:     "images",
 	Aliases: []string{"image"},
 	Short:   "Get image automation object status",
-	Long:    "The get image sub-commands print the status of image automation objects.",
+	Long: `The get image sub-commands print the status of image automation objects: the number of tags
+scanned for each ImageRepository, the latest image selected by each ImagePolicy, and the last commit
+pushed by each ImageUpdateAutomation.`,
 }
 
 func init() {
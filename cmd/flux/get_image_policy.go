@@ -29,7 +29,7 @@ var getImagePolicyCmd = &cobra.Command{
 	Example: `  # List all image policies and their status
   flux get image policy
 
- # List image policies from all namespaces
+  # List image policies from all namespaces
   flux get image policy --all-namespaces
 `,
 	RunE: getCommand{
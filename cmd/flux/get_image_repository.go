@@ -33,7 +33,7 @@ var getImageRepositoryCmd = &cobra.Command{
 	Example: `  # List all image repositories and their status
   flux get image repository
 
- # List image repositories from all namespaces
+  # List image repositories from all namespaces
   flux get image repository --all-namespaces
 `,
 	RunE: getCommand{
@@ -49,16 +49,17 @@ func init() {
 func (s imageRepositoryListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := s.Items[i]
 	status, msg := statusAndMessage(item.Status.Conditions)
-	var lastScan string
+	var tags, lastScan string
 	if item.Status.LastScanResult != nil {
+		tags = strconv.Itoa(item.Status.LastScanResult.TagCount)
 		lastScan = item.Status.LastScanResult.ScanTime.Time.Format(time.RFC3339)
 	}
 	return append(nameColumns(&item, includeNamespace),
-		status, msg, lastScan, strings.Title(strconv.FormatBool(item.Spec.Suspend)))
+		status, msg, tags, lastScan, strings.Title(strconv.FormatBool(item.Spec.Suspend)))
 }
 
 func (s imageRepositoryListAdapter) headers(includeNamespace bool) []string {
-	headers := []string{"Name", "Ready", "Message", "Last scan", "Suspended"}
+	headers := []string{"Name", "Ready", "Message", "Tags", "Last scan", "Suspended"}
 	if includeNamespace {
 		return append(namespaceHeader, headers...)
 	}
@@ -33,7 +33,7 @@ var getImageUpdateCmd = &cobra.Command{
 	Example: `  # List all image update automation object and their status
   flux get image update
 
- # List image update automations from all namespaces
+  # List image update automations from all namespaces
   flux get image update --all-namespaces
 `,
 	RunE: getCommand{
@@ -49,15 +49,19 @@ func init() {
 func (s imageUpdateAutomationListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := s.Items[i]
 	status, msg := statusAndMessage(item.Status.Conditions)
-	var lastRun string
+	var lastRun, lastPush string
 	if item.Status.LastAutomationRunTime != nil {
 		lastRun = item.Status.LastAutomationRunTime.Time.Format(time.RFC3339)
 	}
-	return append(nameColumns(&item, includeNamespace), status, msg, lastRun, strings.Title(strconv.FormatBool(item.Spec.Suspend)))
+	if item.Status.LastPushTime != nil {
+		lastPush = item.Status.LastPushTime.Time.Format(time.RFC3339)
+	}
+	return append(nameColumns(&item, includeNamespace), status, msg, lastRun,
+		item.Status.LastPushCommit, lastPush, strings.Title(strconv.FormatBool(item.Spec.Suspend)))
 }
 
 func (s imageUpdateAutomationListAdapter) headers(includeNamespace bool) []string {
-	headers := []string{"Name", "Ready", "Message", "Last run", "Suspended"}
+	headers := []string{"Name", "Ready", "Message", "Last run", "Last push commit", "Last push", "Suspended"}
 	if includeNamespace {
 		return append(namespaceHeader, headers...)
 	}
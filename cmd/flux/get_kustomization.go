@@ -17,11 +17,20 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
 	"strconv"
 	"strings"
 
-	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
 	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
 )
 
 var getKsCmd = &cobra.Command{
@@ -31,17 +40,116 @@ var getKsCmd = &cobra.Command{
 	Long:    "The get kustomizations command prints the statuses of the resources.",
 	Example: `  # List all kustomizations and their status
   flux get kustomizations
+
+  # List Kustomizations from all namespaces
+  flux get kustomizations --all-namespaces
+
+  # Print the Kustomizations and their full status as JSON
+  flux get kustomizations -o json
+
+  # Watch the Kustomizations for status changes
+  flux get kustomizations --watch
+
+  # List Kustomizations with their Ready reason and last transition
+  flux get kustomizations -o wide
+
+  # List the Kustomizations labeled team=payments
+  flux get kustomizations -l team=payments
+
+  # List the Kustomizations across all namespaces, failing ones first
+  flux get kustomizations --all-namespaces --sort-by ready
+
+  # Render the dependency graph of the Kustomizations of all namespaces with Graphviz
+  flux get kustomizations --all-namespaces --graph dot | dot -Tsvg > kustomizations.svg
+
+  # List the Kustomizations whose names start with apps-
+  flux get kustomizations 'apps-*'
 `,
-	RunE: getCommand{
-		apiType: kustomizationType,
-		list:    &kustomizationListAdapter{&kustomizev1.KustomizationList{}},
-	}.run,
+	RunE: getKsCmdRun,
 }
 
+type getKsFlags struct {
+	graph string
+}
+
+var getKsArgs getKsFlags
+
 func init() {
+	getKsCmd.Flags().StringVar(&getKsArgs.graph, "graph", "",
+		"print the dependency graph of the Kustomizations instead of their statuses, available options are: (dot)")
 	getCmd.AddCommand(getKsCmd)
 }
 
+func getKsCmdRun(cmd *cobra.Command, args []string) error {
+	get := getCommand{
+		apiType: kustomizationType,
+		list:    &kustomizationListAdapter{&kustomizev1.KustomizationList{}},
+	}
+	if getKsArgs.graph == "" {
+		return get.run(cmd, args)
+	}
+	if getKsArgs.graph != "dot" {
+		return fmt.Errorf("unsupported graph format '%s', available options are: (dot)", getKsArgs.graph)
+	}
+	if getArgs.watch {
+		return fmt.Errorf("the --watch flag can't be used with --graph")
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+	if err := get.fetch(ctx, kubeClient, args); err != nil {
+		return err
+	}
+	return writeDependencyGraph(os.Stdout, get.list.(*kustomizationListAdapter).Items)
+}
+
+// writeDependencyGraph writes the dependencies of the Kustomizations in
+// the DOT language, with an edge from each dependency to the
+// Kustomizations depending on it, following the order they're applied
+// in. The dependencies not in the list are dashed.
+func writeDependencyGraph(w io.Writer, items []kustomizev1.Kustomization) error {
+	listed := map[types.NamespacedName]bool{}
+	for _, item := range items {
+		listed[types.NamespacedName{Namespace: item.Namespace, Name: item.Name}] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph kustomizations {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	missing := map[types.NamespacedName]bool{}
+	for _, item := range items {
+		name := types.NamespacedName{Namespace: item.Namespace, Name: item.Name}
+		color := "gray"
+		if c := apimeta.FindStatusCondition(item.Status.Conditions, meta.ReadyCondition); c != nil {
+			color = "red"
+			if c.Status == metav1.ConditionTrue {
+				color = "green"
+			}
+		}
+		style := "solid"
+		if item.Spec.Suspend {
+			style = "dotted"
+		}
+		fmt.Fprintf(&b, "  %q [color=%s, style=%s];\n", name.String(), color, style)
+		for _, dep := range kustomizationDependsOn(&item) {
+			if !listed[dep] && !missing[dep] {
+				missing[dep] = true
+				fmt.Fprintf(&b, "  %q [style=dashed];\n", dep.String())
+			}
+			fmt.Fprintf(&b, "  %q -> %q;\n", dep.String(), name.String())
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
 func (a kustomizationListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := a.Items[i]
 	revision := item.Status.LastAppliedRevision
@@ -31,7 +31,7 @@ var getSourceBucketCmd = &cobra.Command{
 	Example: `  # List all Buckets and their status
   flux get sources bucket
 
- # List buckets from all namespaces
+  # List buckets from all namespaces
   flux get sources helm --all-namespaces
 `,
 	RunE: getCommand{
@@ -31,7 +31,7 @@ var getSourceHelmChartCmd = &cobra.Command{
 	Example: `  # List all Helm charts and their status
   flux get sources chart
 
- # List Helm charts from all namespaces
+  # List Helm charts from all namespaces
   flux get sources chart --all-namespaces
 `,
 	RunE: getCommand{
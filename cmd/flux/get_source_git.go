@@ -31,8 +31,11 @@ var getSourceGitCmd = &cobra.Command{
 	Example: `  # List all Git repositories and their status
   flux get sources git
 
- # List Git repositories from all namespaces
+  # List Git repositories from all namespaces
   flux get sources git --all-namespaces
+
+  # Watch the Git repositories for status changes
+  flux get sources git --watch
 `,
 	RunE: getCommand{
 		apiType: gitRepositoryType,
@@ -31,7 +31,7 @@ var getSourceHelmCmd = &cobra.Command{
 	Example: `  # List all Helm repositories and their status
   flux get sources helm
 
- # List Helm repositories from all namespaces
+  # List Helm repositories from all namespaces
   flux get sources helm --all-namespaces
 `,
 	RunE: getCommand{
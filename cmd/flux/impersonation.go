@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// validateImpersonation checks the values of the --target-namespace and
+// --service-account flags of the commands creating reconciliations.
+func validateImpersonation(targetNamespace, serviceAccount string) error {
+	if targetNamespace != "" {
+		if errs := validation.IsDNS1123Label(targetNamespace); len(errs) > 0 {
+			return fmt.Errorf("invalid target namespace '%s': %s", targetNamespace, strings.Join(errs, ", "))
+		}
+	}
+	if serviceAccount != "" {
+		if errs := validation.IsDNS1123Subdomain(serviceAccount); len(errs) > 0 {
+			return fmt.Errorf("invalid service account '%s': %s", serviceAccount, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// checkServiceAccount warns when the service account a reconciliation
+// impersonates doesn't exist in its namespace, as the reconciliation
+// fails until it's created.
+func checkServiceAccount(ctx context.Context, kubeClient client.Client, namespace, name string) error {
+	if name == "" {
+		return nil
+	}
+	var sa corev1.ServiceAccount
+	err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &sa)
+	switch {
+	case apierrors.IsNotFound(err):
+		logger.Warningf("service account %s not found in namespace %s, the reconciliation fails until it's created", name, namespace)
+		return nil
+	default:
+		return err
+	}
+}
@@ -19,7 +19,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -49,6 +51,12 @@ If a previous version is installed, then an in-place upgrade will be performed.`
   # Dry-run install with manifests preview
   flux install --dry-run --verbose
 
+  # Install a hotfix build of the source-controller from a mirror
+  flux install --image=source-controller=registry.example.com/fluxcd/source-controller:v0.9.1-hotfix.1
+
+  # Install Flux on a cluster reaching Git through a proxy
+  flux install --https-proxy=http://proxy.example.com:3128 --no-proxy=git.internal.example.com
+
   # Write install manifests to file
   flux install --export > flux-system.yaml
 `,
@@ -63,6 +71,9 @@ type installFlags struct {
 	extraComponents    []string
 	registry           string
 	imagePullSecret    string
+	images             []string
+	httpsProxy         string
+	noProxy            []string
 	branch             string
 	watchAllNamespaces bool
 	networkPolicy      bool
@@ -72,6 +83,7 @@ type installFlags struct {
 	tokenAuth          bool
 	clusterDomain      string
 	tolerationKeys     []string
+	concurrency        int
 }
 
 var installArgs = NewInstallFlags()
@@ -82,7 +94,7 @@ func init() {
 	installCmd.Flags().BoolVarP(&installArgs.dryRun, "dry-run", "", false,
 		"only print the object that would be applied")
 	installCmd.Flags().StringVarP(&installArgs.version, "version", "v", "",
-		"toolkit version, when specified the manifests are downloaded from https://github.com/fluxcd/flux2/releases")
+		"toolkit version, the manifests of the version of the CLI are embedded in the binary, the ones of other versions are downloaded from https://github.com/fluxcd/flux2/releases")
 	installCmd.Flags().StringSliceVar(&installArgs.defaultComponents, "components", rootArgs.defaults.Components,
 		"list of components, accepts comma-separated values")
 	installCmd.Flags().StringSliceVar(&installArgs.extraComponents, "components-extra", nil,
@@ -92,6 +104,11 @@ func init() {
 		"container registry where the toolkit images are published")
 	installCmd.Flags().StringVar(&installArgs.imagePullSecret, "image-pull-secret", "",
 		"Kubernetes secret name used for pulling the toolkit images from a private registry")
+	installCmd.Flags().StringArrayVar(&installArgs.images, "image", nil,
+		"image of a component in the format '<component>=<image>', overriding the one from the registry, e.g. a mirrored image or a hotfix build, may be specified multiple times")
+	installCmd.Flags().StringVar(&installArgs.httpsProxy, "https-proxy", "", "URL of the HTTPS proxy the controllers reach the sources, registries and notification providers through, set as HTTPS_PROXY on the controllers")
+	installCmd.Flags().StringSliceVar(&installArgs.noProxy, "no-proxy", nil,
+		"list of hosts, domains or CIDRs the controllers reach without the proxy, in addition to the Kubernetes API and the services of the cluster, accepts comma-separated values")
 	installCmd.Flags().Var(&installArgs.arch, "arch", installArgs.arch.Description())
 	installCmd.Flags().BoolVar(&installArgs.watchAllNamespaces, "watch-all-namespaces", rootArgs.defaults.WatchAllNamespaces,
 		"watch for custom resources in all namespaces, if set to false it will only watch the namespace where the toolkit is installed")
@@ -101,6 +118,8 @@ func init() {
 	installCmd.Flags().StringVar(&installArgs.clusterDomain, "cluster-domain", rootArgs.defaults.ClusterDomain, "internal cluster domain")
 	installCmd.Flags().StringSliceVar(&installArgs.tolerationKeys, "toleration-keys", nil,
 		"list of toleration keys used to schedule the components pods onto nodes with matching taints")
+	installCmd.Flags().IntVar(&installArgs.concurrency, "concurrency", defaultConcurrency,
+		"number of readiness checks to run concurrently while waiting for the components")
 	installCmd.Flags().MarkHidden("manifests")
 	installCmd.Flags().MarkDeprecated("arch", "multi-arch container image is now available for AMD64, ARMv7 and ARM64")
 	rootCmd.AddCommand(installCmd)
@@ -113,7 +132,7 @@ func NewInstallFlags() installFlags {
 }
 
 func installCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
 	components := append(installArgs.defaultComponents, installArgs.extraComponents...)
@@ -128,6 +147,14 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		installArgs.version = ver
 	}
 
+	images, err := parseComponentImages(installArgs.images)
+	if err != nil {
+		return err
+	}
+	if err := validateProxy(installArgs.httpsProxy, installArgs.noProxy); err != nil {
+		return err
+	}
+
 	if !installArgs.export {
 		logger.Generatef("generating manifests")
 	}
@@ -161,6 +188,9 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		Timeout:                rootArgs.timeout,
 		ClusterDomain:          installArgs.clusterDomain,
 		TolerationKeys:         installArgs.tolerationKeys,
+		ComponentImages:        images,
+		HTTPSProxy:             installArgs.httpsProxy,
+		NoProxy:                installArgs.noProxy,
 	}
 
 	if installArgs.manifestsPath == "" {
@@ -172,9 +202,11 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("install failed: %w", err)
 	}
 
-	if _, err := manifest.WriteFile(tmpDir); err != nil {
+	manifestPath, err := manifest.WriteFile(tmpDir)
+	if err != nil {
 		return fmt.Errorf("install failed: %w", err)
 	}
+	logger.Debugf("install manifests written to %s", manifestPath)
 
 	if rootArgs.verbose {
 		fmt.Print(manifest.Content)
@@ -189,18 +221,17 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 
 	logger.Successf("manifests build completed")
 	logger.Actionf("installing components in %s namespace", rootArgs.namespace)
-	applyOutput := utils.ModeStderrOS
-	if rootArgs.verbose {
-		applyOutput = utils.ModeOS
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
 	}
 
-	kubectlArgs := []string{"apply", "-f", filepath.Join(tmpDir, manifest.Path)}
-	if installArgs.dryRun {
-		kubectlArgs = append(kubectlArgs, "--dry-run=client")
-		applyOutput = utils.ModeOS
+	applyOutput := io.Writer(os.Stderr)
+	if rootArgs.verbose || installArgs.dryRun {
+		applyOutput = os.Stdout
 	}
-	if _, err := utils.ExecKubectlCommand(ctx, applyOutput, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err != nil {
-		return fmt.Errorf("install failed")
+	if err := applyManifests(ctx, kubeClient, applyOutput, filepath.Join(tmpDir, manifest.Path), installArgs.dryRun); err != nil {
+		return fmt.Errorf("install failed: %w", err)
 	}
 
 	if installArgs.dryRun {
@@ -208,7 +239,7 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	statusChecker, err := NewStatusChecker(time.Second, time.Minute)
+	statusChecker, err := NewStatusChecker(time.Second, rootArgs.timeout, installArgs.concurrency)
 	if err != nil {
 		return fmt.Errorf("install failed: %w", err)
 	}
@@ -221,3 +252,44 @@ func installCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Successf("install finished")
 	return nil
 }
+
+// parseComponentImages parses the image overrides in the format
+// <component>=<image> into a map of images per component.
+func parseComponentImages(images []string) (map[string]string, error) {
+	if len(images) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(images))
+	for _, image := range images {
+		parts := strings.SplitN(image, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid image override '%s', must be in the format '<component>=<image>'", image)
+		}
+		if err := utils.ValidateComponents([]string{parts[0]}); err != nil {
+			return nil, err
+		}
+		if _, ok := result[parts[0]]; ok {
+			return nil, fmt.Errorf("image of component %s overridden more than once", parts[0])
+		}
+		result[parts[0]] = parts[1]
+	}
+	return result, nil
+}
+
+// validateProxy validates the proxy settings of the controllers.
+func validateProxy(httpsProxy string, noProxy []string) error {
+	if httpsProxy == "" {
+		if len(noProxy) > 0 {
+			return fmt.Errorf("--no-proxy requires --https-proxy")
+		}
+		return nil
+	}
+	u, err := url.Parse(httpsProxy)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL '%s': %w", httpsProxy, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid proxy URL '%s', must be in the format 'http(s)://host:port'", httpsProxy)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// kubeClientFactory builds the configuration, the RESTMapper and the
+// client of the Kubernetes API on first use, and shares them between
+// all the steps of a command, so that the API discovery is done once.
+type kubeClientFactory struct {
+	once   sync.Once
+	config *rest.Config
+	client client.Client
+	err    error
+}
+
+var kubeClients kubeClientFactory
+
+func (f *kubeClientFactory) init() {
+	f.once.Do(func() {
+		f.config, f.err = utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext, rootArgs.kubeOptions)
+		if f.err != nil {
+			return
+		}
+		if rootArgs.verbose {
+			f.config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+				return debugRoundTripper{next: rt}
+			})
+		}
+		// the mapper discovers the API groups now, and again when asked
+		// for a kind it doesn't know, e.g. one defined by a CRD applied
+		// since. Lazy discovery isn't used, as its first failure leaves
+		// the mapper unset for the retried calls.
+		mapper, err := apiutil.NewDynamicRESTMapper(f.config)
+		if err != nil {
+			f.err = fmt.Errorf("kubernetes client initialization failed: %w", err)
+			return
+		}
+		kubeClient, err := client.New(f.config, client.Options{
+			Scheme: utils.NewScheme(),
+			Mapper: mapper,
+		})
+		if err != nil {
+			f.err = fmt.Errorf("kubernetes client initialization failed: %w", err)
+			return
+		}
+		f.client = utils.NewRetryClient(kubeClient)
+	})
+}
+
+// getKubeConfig returns a copy of the shared configuration of the
+// Kubernetes API clients.
+func getKubeConfig() (*rest.Config, error) {
+	kubeClients.init()
+	if kubeClients.err != nil {
+		return nil, kubeClients.err
+	}
+	return rest.CopyConfig(kubeClients.config), nil
+}
+
+// getKubeClient returns the shared client of the Kubernetes API.
+func getKubeClient() (client.Client, error) {
+	kubeClients.init()
+	if kubeClients.err != nil {
+		return nil, kubeClients.err
+	}
+	return kubeClients.client, nil
+}
+
+// debugRoundTripper logs the requests to the Kubernetes API as debug
+// messages.
+type debugRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (rt debugRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		logger.Debugf("%s %s failed after %s: %s", req.Method, req.URL, elapsed, err.Error())
+		return resp, err
+	}
+	logger.Debugf("%s %s %s in %s", req.Method, req.URL, resp.Status, elapsed)
+	return resp, nil
+}
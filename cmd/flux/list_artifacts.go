@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/oci"
+)
+
+var listArtifactsCmd = &cobra.Command{
+	Use:   "artifacts [oci-url]",
+	Short: "List the tagged artifacts of a repository",
+	Long: `The list artifacts command prints the tagged artifacts of a repository, with their digest and the
+Git address and revision they were pushed from. The credentials of the registry are read from the
+Docker configuration, as written by docker login.`,
+	Example: `  # List the artifacts of a repository
+  flux list artifacts oci://ghcr.io/org/manifests/podinfo
+`,
+	RunE: listArtifactsCmdRun,
+}
+
+func init() {
+	listCmd.AddCommand(listArtifactsCmd)
+}
+
+func listArtifactsCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("repository URL is required")
+	}
+	url := args[0]
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	artifacts, err := oci.List(ctx, url)
+	if err != nil {
+		return err
+	}
+	if len(artifacts) == 0 {
+		logger.Failuref("no artifacts found in %s", url)
+		return nil
+	}
+
+	header := []string{"Artifact", "Digest", "Source", "Revision", "Created"}
+	var rows [][]string
+	for _, artifact := range artifacts {
+		rows = append(rows, []string{
+			artifact.URL,
+			artifact.Digest,
+			artifact.Source,
+			artifact.Revision,
+			artifact.Created,
+		})
+	}
+	utils.PrintTable(os.Stdout, header, rows)
+	return nil
+}
@@ -17,12 +17,37 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"time"
+
+	fluxlog "github.com/fluxcd/flux2/pkg/log"
 )
 
+// cliLogger is the logger of the commands, which also writes the
+// warnings and debug messages, on top of the messages of fluxlog.Logger.
+type cliLogger interface {
+	fluxlog.Logger
+	// Warningf logs a formatted warning message.
+	Warningf(format string, a ...interface{})
+	// Debugf logs a formatted debug message.
+	Debugf(format string, a ...interface{})
+}
+
+// stderrLogger writes the messages prefixed with a marker, the
+// markers of the successes, warnings and failures being colored when
+// color is set.
 type stderrLogger struct {
 	stderr io.Writer
+	color  bool
+}
+
+func (l stderrLogger) marker(marker, color string) string {
+	if l.color {
+		return colorize(color, marker)
+	}
+	return marker
 }
 
 func (l stderrLogger) Actionf(format string, a ...interface{}) {
@@ -38,9 +63,126 @@ func (l stderrLogger) Waitingf(format string, a ...interface{}) {
 }
 
 func (l stderrLogger) Successf(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `✔`, fmt.Sprintf(format, a...))
+	fmt.Fprintln(l.stderr, l.marker(`✔`, colorGreen), fmt.Sprintf(format, a...))
+}
+
+func (l stderrLogger) Warningf(format string, a ...interface{}) {
+	fmt.Fprintln(l.stderr, l.marker(`⚠`, colorYellow), fmt.Sprintf(format, a...))
 }
 
 func (l stderrLogger) Failuref(format string, a ...interface{}) {
-	fmt.Fprintln(l.stderr, `✗`, fmt.Sprintf(format, a...))
+	fmt.Fprintln(l.stderr, l.marker(`✗`, colorRed), fmt.Sprintf(format, a...))
+}
+
+func (l stderrLogger) Debugf(format string, a ...interface{}) {
+	fmt.Fprintln(l.stderr, `…`, fmt.Sprintf(format, a...))
+}
+
+// jsonLogger writes the messages as a stream of JSON objects, one per
+// line, for the commands to be run by automation.
+type jsonLogger struct {
+	stderr io.Writer
+}
+
+// logEvent is a message written by the jsonLogger.
+type logEvent struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+func (l jsonLogger) log(level, format string, a ...interface{}) {
+	data, err := json.Marshal(logEvent{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Level:   level,
+		Message: fmt.Sprintf(format, a...),
+	})
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(l.stderr, string(data))
+}
+
+func (l jsonLogger) Actionf(format string, a ...interface{}) {
+	l.log("action", format, a...)
+}
+
+func (l jsonLogger) Generatef(format string, a ...interface{}) {
+	l.log("generate", format, a...)
+}
+
+func (l jsonLogger) Waitingf(format string, a ...interface{}) {
+	l.log("waiting", format, a...)
+}
+
+func (l jsonLogger) Successf(format string, a ...interface{}) {
+	l.log("success", format, a...)
+}
+
+func (l jsonLogger) Warningf(format string, a ...interface{}) {
+	l.log("warning", format, a...)
+}
+
+func (l jsonLogger) Failuref(format string, a ...interface{}) {
+	l.log("failure", format, a...)
+}
+
+func (l jsonLogger) Debugf(format string, a ...interface{}) {
+	l.log("debug", format, a...)
+}
+
+// stepLogger records the last success message, which marks the last
+// step completed by a command.
+type stepLogger struct {
+	cliLogger
+	last *string
+}
+
+func (l stepLogger) Successf(format string, a ...interface{}) {
+	*l.last = fmt.Sprintf(format, a...)
+	l.cliLogger.Successf(format, a...)
+}
+
+// levelLogger drops the debug messages unless verbose is set, and
+// every message but the failures when silent is set.
+type levelLogger struct {
+	cliLogger
+	verbose bool
+	silent  bool
+}
+
+func (l levelLogger) Actionf(format string, a ...interface{}) {
+	if !l.silent {
+		l.cliLogger.Actionf(format, a...)
+	}
+}
+
+func (l levelLogger) Generatef(format string, a ...interface{}) {
+	if !l.silent {
+		l.cliLogger.Generatef(format, a...)
+	}
+}
+
+func (l levelLogger) Waitingf(format string, a ...interface{}) {
+	if !l.silent {
+		l.cliLogger.Waitingf(format, a...)
+	}
+}
+
+func (l levelLogger) Successf(format string, a ...interface{}) {
+	if !l.silent {
+		l.cliLogger.Successf(format, a...)
+	}
+}
+
+func (l levelLogger) Warningf(format string, a ...interface{}) {
+	if !l.silent {
+		l.cliLogger.Warningf(format, a...)
+	}
+}
+
+func (l levelLogger) Debugf(format string, a ...interface{}) {
+	if l.verbose && !l.silent {
+		l.cliLogger.Debugf(format, a...)
+	}
 }
@@ -0,0 +1,300 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1alpha1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Display formatted logs for the toolkit controllers",
+	Long: `The logs command displays the logs of the toolkit controllers, merged from all their pods and
+sorted by time. The logs can be narrowed down to the reconciliations of a kind of object, or of a
+single object. When a kind is given, only the controller responsible for that kind is queried.`,
+	Example: `  # Print the logs of all the controllers
+  flux logs
+
+  # Print the logs of the last ten minutes for a Kustomization
+  flux logs --kind=Kustomization --name=podinfo --since=10m
+
+  # Follow the logs of the HelmReleases in all namespaces
+  flux logs --kind=HelmRelease --all-namespaces --follow
+
+  # Print the logs of the controllers installed in another namespace
+  flux logs --flux-namespace=gotk-system
+`,
+	Args: cobra.NoArgs,
+	RunE: logsCmdRun,
+}
+
+type logsFlags struct {
+	kind          string
+	name          string
+	since         time.Duration
+	tail          int64
+	follow        bool
+	allNamespaces bool
+	fluxNamespace string
+}
+
+var logsArgs = logsFlags{
+	tail: -1,
+}
+
+func init() {
+	logsCmd.Flags().StringVar(&logsArgs.kind, "kind", "", "only display the logs of the reconciliations of this kind of object")
+	logsCmd.Flags().StringVar(&logsArgs.name, "name", "", "only display the logs of the reconciliations of the object with this name")
+	logsCmd.Flags().DurationVar(&logsArgs.since, "since", 0, "only display the logs newer than this relative duration, e.g. 10m")
+	logsCmd.Flags().Int64Var(&logsArgs.tail, "tail", logsArgs.tail, "number of lines to display from the end of the logs of each pod, -1 for all")
+	logsCmd.Flags().BoolVarP(&logsArgs.follow, "follow", "f", false, "stream the logs as they are written")
+	logsCmd.Flags().BoolVarP(&logsArgs.allNamespaces, "all-namespaces", "A", false, "display the logs of the reconciliations in all namespaces")
+	logsCmd.Flags().StringVar(&logsArgs.fluxNamespace, "flux-namespace", rootArgs.defaults.Namespace, "the namespace where the controllers are installed")
+
+	rootCmd.AddCommand(logsCmd)
+}
+
+// controllerForKind maps the kinds of the toolkit to the controller
+// which reconciles them.
+var controllerForKind = map[string]string{
+	sourcev1.GitRepositoryKind:       "source-controller",
+	sourcev1.HelmRepositoryKind:      "source-controller",
+	sourcev1.HelmChartKind:           "source-controller",
+	sourcev1.BucketKind:              "source-controller",
+	kustomizev1.KustomizationKind:    "kustomize-controller",
+	helmv2.HelmReleaseKind:           "helm-controller",
+	alertType.kind:                   "notification-controller",
+	alertProviderType.kind:           "notification-controller",
+	receiverType.kind:                "notification-controller",
+	imagev1.ImageRepositoryKind:      "image-reflector-controller",
+	imagev1.ImagePolicyKind:          "image-reflector-controller",
+	autov1.ImageUpdateAutomationKind: "image-automation-controller",
+}
+
+// controllerLogEntry holds the fields of the structured logs written
+// by the controllers which are used for filtering and display.
+type controllerLogEntry struct {
+	Timestamp string `json:"ts"`
+	Level     string `json:"level"`
+	Message   string `json:"msg"`
+	Error     string `json:"error,omitempty"`
+	Kind      string `json:"reconciler kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// logFilter selects the log entries about the reconciliations of some
+// objects, an empty field matching any value.
+type logFilter struct {
+	kind      string
+	name      string
+	namespace string
+}
+
+func (f logFilter) matches(e controllerLogEntry) bool {
+	if f.kind != "" && !strings.EqualFold(e.Kind, f.kind) {
+		return false
+	}
+	if f.name != "" && e.Name != f.name {
+		return false
+	}
+	if f.namespace != "" && e.Namespace != f.namespace {
+		return false
+	}
+	return true
+}
+
+func (e controllerLogEntry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", e.Timestamp, e.Level)
+	if e.Kind != "" {
+		fmt.Fprintf(&b, " %s/%s/%s", e.Kind, e.Namespace, e.Name)
+	}
+	fmt.Fprintf(&b, " - %s", e.Message)
+	if e.Error != "" {
+		fmt.Fprintf(&b, ": %s", e.Error)
+	}
+	return b.String()
+}
+
+func logsCmdRun(cmd *cobra.Command, args []string) error {
+	controllers := []string{}
+	if logsArgs.kind != "" {
+		found := false
+		for kind, controller := range controllerForKind {
+			if strings.EqualFold(kind, logsArgs.kind) {
+				controllers = append(controllers, controller)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown kind %s", logsArgs.kind)
+		}
+	} else {
+		for _, controller := range controllerForKind {
+			if !utils.ContainsItemString(controllers, controller) {
+				controllers = append(controllers, controller)
+			}
+		}
+	}
+
+	// following the logs lasts until interrupted, so it is not
+	// subject to the timeout
+	ctx := rootCtx
+	if !logsArgs.follow {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rootArgs.timeout)
+		defer cancel()
+	}
+
+	cfg, err := getKubeConfig()
+	if err != nil {
+		return err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(controllers)
+	pods, err := clientSet.CoreV1().Pods(logsArgs.fluxNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app in (%s)", strings.Join(controllers, ",")),
+	})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no controller pods found in %s namespace", logsArgs.fluxNamespace)
+	}
+
+	logOpts := &corev1.PodLogOptions{
+		Container: "manager",
+		Follow:    logsArgs.follow,
+	}
+	if logsArgs.since > 0 {
+		seconds := int64(logsArgs.since.Seconds())
+		logOpts.SinceSeconds = &seconds
+	}
+	if logsArgs.tail >= 0 {
+		logOpts.TailLines = &logsArgs.tail
+	}
+
+	var streams []io.ReadCloser
+	for _, pod := range pods.Items {
+		stream, err := clientSet.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOpts).Stream(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the logs of pod %s: %w", pod.Name, err)
+		}
+		defer stream.Close()
+		streams = append(streams, stream)
+	}
+
+	filter := logFilter{kind: logsArgs.kind, name: logsArgs.name}
+	if !logsArgs.allNamespaces && (logsArgs.kind != "" || logsArgs.name != "") {
+		filter.namespace = rootArgs.namespace
+	}
+	if logsArgs.follow {
+		return followLogs(streams, filter)
+	}
+	return printLogs(streams, filter)
+}
+
+// printLogs reads the logs of all the pods, and prints the selected
+// entries in time order.
+func printLogs(streams []io.ReadCloser, filter logFilter) error {
+	var entries []controllerLogEntry
+	for _, stream := range streams {
+		err := scanLogs(stream, filter, func(entry controllerLogEntry) {
+			entries = append(entries, entry)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp < entries[j].Timestamp
+	})
+	for _, entry := range entries {
+		fmt.Fprintln(os.Stdout, entry)
+	}
+	return nil
+}
+
+// followLogs prints the selected entries of all the pods as they are
+// written, until all the streams are closed.
+func followLogs(streams []io.ReadCloser, filter logFilter) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(chan error, len(streams))
+	for _, stream := range streams {
+		wg.Add(1)
+		go func(stream io.Reader) {
+			defer wg.Done()
+			errs <- scanLogs(stream, filter, func(entry controllerLogEntry) {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Fprintln(os.Stdout, entry)
+			})
+		}(stream)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanLogs calls fn with each entry of a log stream matching the
+// filter. Lines which are not structured log entries are skipped.
+func scanLogs(stream io.Reader, filter logFilter, fn func(controllerLogEntry)) error {
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		var entry controllerLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.matches(entry) {
+			fn(entry)
+		}
+	}
+	return scanner.Err()
+}
@@ -17,15 +17,22 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
 )
 
@@ -91,9 +98,50 @@ var rootCmd = &cobra.Command{
   # Uninstall Flux and delete CRDs
   flux uninstall
 `,
+	PersistentPreRunE: rootCmdPreRun,
 }
 
-var logger = stderrLogger{stderr: os.Stderr}
+// rootCmdPreRun checks the verbosity flags, sets the timeout of the
+// command, and sets and checks its namespace.
+func rootCmdPreRun(cmd *cobra.Command, args []string) error {
+	if rootArgs.verbose && rootArgs.silent {
+		return fmt.Errorf("--verbose and --silent are mutually exclusive")
+	}
+	setDefaultTimeout(cmd)
+	setDefaultNamespace(cmd)
+	return checkNamespace(cmd)
+}
+
+// timeoutAnnotation is the annotation of the commands setting their
+// own default for --timeout, and for the one of their sub-commands.
+const timeoutAnnotation = "flux/default-timeout"
+
+// setDefaultTimeout sets the timeout of a command, when not given with
+// --timeout, to the default set by the command or the closest of its
+// parents.
+func setDefaultTimeout(cmd *cobra.Command) {
+	if cmd.Flags().Changed("timeout") {
+		return
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if value, ok := c.Annotations[timeoutAnnotation]; ok {
+			if timeout, err := time.ParseDuration(value); err == nil {
+				rootArgs.timeout = timeout
+			}
+			return
+		}
+	}
+}
+
+var logger cliLogger = stderrLogger{stderr: os.Stderr}
+
+// rootCtx is the parent context of the operations of the commands,
+// cancelled on SIGINT and SIGTERM.
+var rootCtx = context.Background()
+
+// lastStep is the last step completed by the command, as logged with
+// Successf, reported when the command is interrupted.
+var lastStep string
 
 type rootFlags struct {
 	kubeconfig   string
@@ -101,6 +149,10 @@ type rootFlags struct {
 	namespace    string
 	timeout      time.Duration
 	verbose      bool
+	silent       bool
+	noColor      bool
+	logFormat    flags.LogFormat
+	kubeOptions  utils.KubeConfigOptions
 	pollInterval time.Duration
 	defaults     install.Options
 }
@@ -108,14 +160,43 @@ type rootFlags struct {
 var rootArgs = NewRootFlags()
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&rootArgs.namespace, "namespace", "n", rootArgs.defaults.Namespace, "the namespace scope for this operation")
+	rootCmd.PersistentFlags().StringVarP(&rootArgs.namespace, "namespace", "n", rootArgs.defaults.Namespace,
+		"the namespace scope for this operation, the commands working with toolkit objects default to the namespace of the kubeconfig context when it sets one")
 	rootCmd.PersistentFlags().DurationVar(&rootArgs.timeout, "timeout", 5*time.Minute, "timeout for this operation")
-	rootCmd.PersistentFlags().BoolVar(&rootArgs.verbose, "verbose", false, "print generated objects")
+	rootCmd.PersistentFlags().BoolVar(&rootArgs.verbose, "verbose", false,
+		"print generated objects, and debug messages such as the requests to the Kubernetes API and the paths of the generated files")
+	rootCmd.PersistentFlags().BoolVarP(&rootArgs.silent, "silent", "s", false,
+		"only print the error messages, and delete objects without asking for confirmation")
+	rootCmd.PersistentFlags().BoolVar(&rootArgs.noColor, "no-color", false,
+		"disable the colored output, which is also disabled when not writing to a terminal or when NO_COLOR is set")
 	rootCmd.PersistentFlags().StringVarP(&rootArgs.kubecontext, "context", "", "", "kubernetes context to use")
+	rootCmd.RegisterFlagCompletionFunc("context", contextNamesCompletionFunc)
+	rootCmd.PersistentFlags().Var(&rootArgs.logFormat, "log-format", rootArgs.logFormat.Description())
+	rootCmd.PersistentFlags().Float32Var(&rootArgs.kubeOptions.QPS, "kube-api-qps", 50,
+		"maximum number of queries per second to the Kubernetes API")
+	rootCmd.PersistentFlags().IntVar(&rootArgs.kubeOptions.Burst, "kube-api-burst", 100,
+		"maximum burst of queries to the Kubernetes API")
+	rootCmd.PersistentFlags().DurationVar(&rootArgs.kubeOptions.Timeout, "request-timeout", 0,
+		"timeout of a single request to the Kubernetes API, zero means no timeout")
+
+	cobra.OnInitialize(setLogger)
+}
+
+// setLogger switches the logger to the log format and the verbosity
+// given with the global flags, once the flags are parsed.
+func setLogger() {
+	if rootArgs.logFormat == flags.JSONLogFormat {
+		logger = jsonLogger{stderr: os.Stderr}
+	} else {
+		logger = stderrLogger{stderr: os.Stderr, color: colorEnabled(os.Stderr)}
+	}
+	logger = levelLogger{cliLogger: logger, verbose: rootArgs.verbose, silent: rootArgs.silent}
+	logger = stepLogger{cliLogger: logger, last: &lastStep}
 }
 
 func NewRootFlags() rootFlags {
 	rf := rootFlags{
+		logFormat:    flags.TextLogFormat,
 		pollInterval: 2 * time.Second,
 		defaults:     install.MakeDefaultOptions(),
 	}
@@ -127,8 +208,29 @@ func main() {
 	log.SetFlags(0)
 	generateDocs()
 	kubeconfigFlag()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// a second signal terminates the process right away
+		<-ctx.Done()
+		stop()
+	}()
+	rootCtx = ctx
+
 	if err := rootCmd.Execute(); err != nil {
+		if ctx.Err() != nil {
+			if lastStep != "" {
+				logger.Failuref("interrupted, the last completed step was: %s", lastStep)
+			} else {
+				logger.Failuref("interrupted before any step was completed")
+			}
+			os.Exit(130)
+		}
 		logger.Failuref("%v", err)
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
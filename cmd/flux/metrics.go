@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Print a summary of the reconciliation metrics of the controllers",
+	Long: `The metrics command reads the Prometheus metrics of the toolkit controllers, through the
+Kubernetes API server proxy, and prints for each reconciler the number of reconciliations, the
+error rate, the average reconciliation duration and the depth of its work queue. The figures
+are counted from the start of each controller pod.`,
+	Example: `  # Print the metrics summary of the controllers
+  flux metrics
+
+  # Print the metrics summary of the controllers installed in another namespace
+  flux metrics --namespace=gotk-system
+`,
+	Args: cobra.NoArgs,
+	RunE: metricsCmdRun,
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+}
+
+// reconcilerMetrics holds the metrics of a reconciler of a controller
+// pod.
+type reconcilerMetrics struct {
+	total      float64
+	errors     float64
+	timeSum    float64
+	timeCount  float64
+	queueDepth float64
+}
+
+func metricsCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	cfg, err := getKubeConfig()
+	if err != nil {
+		return err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	controllers := []string{}
+	for _, controller := range controllerForKind {
+		if !utils.ContainsItemString(controllers, controller) {
+			controllers = append(controllers, controller)
+		}
+	}
+	pods, err := clientSet.CoreV1().Pods(rootArgs.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app in (%s)", strings.Join(controllers, ",")),
+	})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no controller pods found in %s namespace", rootArgs.namespace)
+	}
+
+	header := []string{"Pod", "Reconciler", "Reconciliations", "Error Rate", "Avg Duration", "Queue Depth"}
+	var rows [][]string
+	for _, pod := range pods.Items {
+		port := "8080"
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == "http-prom" {
+					port = strconv.Itoa(int(p.ContainerPort))
+				}
+			}
+		}
+		data, err := clientSet.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, port, "/metrics", nil).DoRaw(ctx)
+		if err != nil {
+			logger.Failuref("failed to read the metrics of pod %s: %s", pod.Name, err.Error())
+			continue
+		}
+
+		metrics := parseReconcilerMetrics(data)
+		var reconcilers []string
+		for reconciler := range metrics {
+			reconcilers = append(reconcilers, reconciler)
+		}
+		sort.Strings(reconcilers)
+		for _, reconciler := range reconcilers {
+			m := metrics[reconciler]
+			errorRate, avgDuration := "-", "-"
+			if m.total > 0 {
+				errorRate = fmt.Sprintf("%.1f%%", 100*m.errors/m.total)
+			}
+			if m.timeCount > 0 {
+				avgDuration = (time.Duration(m.timeSum / m.timeCount * float64(time.Second))).Round(time.Millisecond).String()
+			}
+			rows = append(rows, []string{
+				pod.Name,
+				reconciler,
+				strconv.FormatFloat(m.total, 'f', 0, 64),
+				errorRate,
+				avgDuration,
+				strconv.FormatFloat(m.queueDepth, 'f', 0, 64),
+			})
+		}
+	}
+	utils.PrintTable(os.Stdout, header, rows)
+	return nil
+}
+
+// parseReconcilerMetrics reads the controller-runtime metrics from the
+// Prometheus text exposition format, per reconciler.
+func parseReconcilerMetrics(data []byte) map[string]*reconcilerMetrics {
+	metrics := map[string]*reconcilerMetrics{}
+	get := func(reconciler string) *reconcilerMetrics {
+		if _, ok := metrics[reconciler]; !ok {
+			metrics[reconciler] = &reconcilerMetrics{}
+		}
+		return metrics[reconciler]
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, labels, value, ok := parseMetricLine(line)
+		if !ok {
+			continue
+		}
+		switch name {
+		case "controller_runtime_reconcile_total":
+			m := get(labels["controller"])
+			m.total += value
+			if labels["result"] == "error" {
+				m.errors += value
+			}
+		case "controller_runtime_reconcile_time_seconds_sum":
+			get(labels["controller"]).timeSum += value
+		case "controller_runtime_reconcile_time_seconds_count":
+			get(labels["controller"]).timeCount += value
+		case "workqueue_depth":
+			get(labels["name"]).queueDepth += value
+		}
+	}
+	return metrics
+}
+
+// parseMetricLine splits a sample line, e.g.
+// `workqueue_depth{name="kustomization"} 0`, into the metric name, its
+// labels and its value.
+func parseMetricLine(line string) (string, map[string]string, float64, bool) {
+	labels := map[string]string{}
+	name := line
+	rest := ""
+	if i := strings.Index(line, "{"); i >= 0 {
+		j := strings.LastIndex(line, "}")
+		if j < i {
+			return "", nil, 0, false
+		}
+		name = line[:i]
+		for _, pair := range strings.Split(line[i+1:j], ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 {
+				labels[strings.TrimSpace(kv[0])] = strings.Trim(kv[1], `"`)
+			}
+		}
+		rest = line[j+1:]
+	} else if i := strings.Index(line, " "); i >= 0 {
+		name = line[:i]
+		rest = line[i:]
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+	return name, labels, value, true
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate from Flux v1 and Helm Operator",
+	Long: `The migrate sub-commands generate the toolkit objects equivalent to the configuration of Flux v1
+and of Helm Operator, and report what can't be migrated automatically.`,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// objectNameFrom turns a path or an image name into a valid object
+// name.
+func objectNameFrom(s string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(s), "-")
+	return strings.Trim(name, "-")
+}
+
+// migrationReport collects the constructs needing manual attention.
+type migrationReport []string
+
+func (r *migrationReport) add(format string, a ...interface{}) {
+	*r = append(*r, fmt.Sprintf(format, a...))
+}
+
+func (r migrationReport) print() {
+	if len(r) == 0 {
+		logger.Successf("migration completed, no manual steps required")
+		return
+	}
+	for _, item := range r {
+		logger.Warningf("%s", item)
+	}
+	logger.Actionf("%d item(s) need manual attention", len(r))
+}
@@ -0,0 +1,596 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1alpha1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/apply"
+)
+
+var migrateFlux1Cmd = &cobra.Command{
+	Use:   "flux1",
+	Short: "Generate the toolkit objects equivalent to a Flux v1 installation",
+	Long: `The migrate flux1 command reads the configuration of a Flux v1 deployment, from the cluster or from
+a manifest, and prints the GitRepository and the Kustomizations syncing the same repository and paths.
+Given a checkout of the repository, it also translates the kustomize generators of the .flux.yaml files,
+and the automation annotations of the workloads into ImageRepositories, ImagePolicies and an
+ImageUpdateAutomation. The constructs that need manual attention are reported on stderr.`,
+	Example: `  # Generate the toolkit objects equivalent to the Flux v1 deployment of the flux namespace
+  flux migrate flux1 --repo-path=./fleet-infra > flux-system.yaml
+
+  # Read the configuration of Flux v1 from its deployment manifest
+  flux migrate flux1 --deployment-file=./flux/deployment.yaml --repo-path=./fleet-infra
+`,
+	RunE: migrateFlux1CmdRun,
+}
+
+type migrateFlux1Flags struct {
+	flux1Namespace  string
+	flux1Deployment string
+	deploymentFile  string
+	repoPath        string
+	name            string
+}
+
+var migrateFlux1Args migrateFlux1Flags
+
+func init() {
+	migrateFlux1Cmd.Flags().StringVar(&migrateFlux1Args.flux1Namespace, "flux1-namespace", "flux", "the namespace of the Flux v1 deployment")
+	migrateFlux1Cmd.Flags().StringVar(&migrateFlux1Args.flux1Deployment, "flux1-deployment", "flux", "the name of the Flux v1 deployment")
+	migrateFlux1Cmd.Flags().StringVar(&migrateFlux1Args.deploymentFile, "deployment-file", "",
+		"path to the manifest of the Flux v1 deployment, read instead of the deployment in the cluster")
+	migrateFlux1Cmd.Flags().StringVar(&migrateFlux1Args.repoPath, "repo-path", "",
+		"path to a checkout of the repository synced by Flux v1, to migrate its .flux.yaml files and automation annotations")
+	migrateFlux1Cmd.Flags().StringVar(&migrateFlux1Args.name, "name", "flux-system", "the name of the generated GitRepository, prefix of the Kustomizations")
+
+	migrateCmd.AddCommand(migrateFlux1Cmd)
+}
+
+// flux1Config is the configuration of a Flux v1 daemon, read from its
+// command line arguments.
+type flux1Config struct {
+	args map[string]string
+}
+
+// flux1Defaults are the defaults of the Flux v1 arguments the migration
+// relies on.
+var flux1Defaults = map[string]string{
+	"git-branch":              "master",
+	"git-poll-interval":       "5m",
+	"sync-interval":           "5m",
+	"k8s-secret-name":         "flux-git-deploy",
+	"git-readonly":            "false",
+	"sync-garbage-collection": "false",
+	"manifest-generation":     "false",
+}
+
+// flux1MigratedArgs are the arguments translated to toolkit objects,
+// any other argument is reported.
+var flux1MigratedArgs = []string{
+	"git-url", "git-branch", "git-path", "git-poll-interval", "sync-interval", "k8s-secret-name",
+	"git-readonly", "sync-garbage-collection", "manifest-generation", "git-user", "git-email",
+	"memcached-hostname", "memcached-service", "listen-metrics", "ssh-keygen-dir",
+}
+
+func (c flux1Config) get(name string) string {
+	if v, ok := c.args[name]; ok {
+		return v
+	}
+	return flux1Defaults[name]
+}
+
+// parseFlux1Args reads the --name=value and --name value arguments of
+// the Flux v1 daemon.
+func parseFlux1Args(args []string) flux1Config {
+	config := flux1Config{args: map[string]string{}}
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimLeft(args[i], "-")
+		if arg == args[i] {
+			continue
+		}
+		if kv := strings.SplitN(arg, "=", 2); len(kv) == 2 {
+			config.args[kv[0]] = kv[1]
+			continue
+		}
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			config.args[arg] = args[i+1]
+			i++
+			continue
+		}
+		config.args[arg] = "true"
+	}
+	return config
+}
+
+func migrateFlux1CmdRun(cmd *cobra.Command, args []string) error {
+	deployment, err := readFlux1Deployment()
+	if err != nil {
+		return err
+	}
+	containers := deployment.Spec.Template.Spec.Containers
+	if len(containers) == 0 {
+		return fmt.Errorf("deployment %s has no containers", deployment.Name)
+	}
+	container := containers[0]
+	for _, c := range containers {
+		if c.Name == "flux" {
+			container = c
+		}
+	}
+	config := parseFlux1Args(container.Args)
+	if config.get("git-url") == "" {
+		return fmt.Errorf("the Flux v1 deployment has no --git-url argument")
+	}
+
+	var report migrationReport
+	for _, arg := range sortedKeys(config.args) {
+		if !utils.ContainsItemString(flux1MigratedArgs, arg) {
+			report.add("--%s=%s has no equivalent in the generated objects", arg, config.args[arg])
+		}
+	}
+
+	gitRepository, err := migrateFlux1Source(config, &report)
+	if err != nil {
+		return err
+	}
+	kustomizations, err := migrateFlux1Paths(config, &report)
+	if err != nil {
+		return err
+	}
+	exports := []interface{}{exportGitRepository(gitRepository)}
+	for i := range kustomizations {
+		exports = append(exports, exportKustomization(&kustomizations[i]))
+	}
+
+	if migrateFlux1Args.repoPath != "" {
+		automation, err := migrateFlux1Automation(config, &report)
+		if err != nil {
+			return err
+		}
+		exports = append(exports, automation...)
+	} else {
+		report.add("the .flux.yaml files and the automation annotations were not migrated, set --repo-path to migrate them")
+	}
+
+	for _, export := range exports {
+		if err := printExport(export); err != nil {
+			return err
+		}
+	}
+
+	report.print()
+	return nil
+}
+
+func readFlux1Deployment() (*appsv1.Deployment, error) {
+	var deployment appsv1.Deployment
+	if migrateFlux1Args.deploymentFile != "" {
+		data, err := ioutil.ReadFile(migrateFlux1Args.deploymentFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &deployment); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", migrateFlux1Args.deploymentFile, err)
+		}
+		return &deployment, nil
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	namespacedName := types.NamespacedName{
+		Namespace: migrateFlux1Args.flux1Namespace,
+		Name:      migrateFlux1Args.flux1Deployment,
+	}
+	if err := kubeClient.Get(ctx, namespacedName, &deployment); err != nil {
+		return nil, fmt.Errorf("reading the Flux v1 deployment %s failed: %w", namespacedName, err)
+	}
+	return &deployment, nil
+}
+
+var scpLikeURL = regexp.MustCompile(`^([^@/]+@)?([^:/]+):(.+)$`)
+
+// toSSHURL turns a git@host:org/repo address, which Flux v1 accepts,
+// into the ssh://git@host/org/repo format source-controller requires.
+func toSSHURL(url string) string {
+	if strings.Contains(url, "://") {
+		return url
+	}
+	if m := scpLikeURL.FindStringSubmatch(url); m != nil {
+		return fmt.Sprintf("ssh://%s%s/%s", m[1], m[2], m[3])
+	}
+	return url
+}
+
+func migrateFlux1Source(config flux1Config, report *migrationReport) (*sourcev1.GitRepository, error) {
+	interval, err := time.ParseDuration(config.get("git-poll-interval"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --git-poll-interval: %w", err)
+	}
+	url := toSSHURL(config.get("git-url"))
+	gitRepository := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      migrateFlux1Args.name,
+			Namespace: rootArgs.namespace,
+		},
+		Spec: sourcev1.GitRepositorySpec{
+			URL:      url,
+			Interval: metav1.Duration{Duration: interval},
+			Reference: &sourcev1.GitRepositoryRef{
+				Branch: config.get("git-branch"),
+			},
+		},
+	}
+	if strings.HasPrefix(url, "ssh://") {
+		gitRepository.Spec.SecretRef = &meta.LocalObjectReference{
+			Name: migrateFlux1Args.name,
+		}
+		report.add("the deploy key of secret %s/%s must be copied to secret %s/%s, with the known_hosts of the Git host, e.g. with flux create secret git %s --url=%s --private-key-file=<identity>",
+			migrateFlux1Args.flux1Namespace, config.get("k8s-secret-name"), rootArgs.namespace, migrateFlux1Args.name,
+			migrateFlux1Args.name, url)
+	}
+	return gitRepository, nil
+}
+
+func migrateFlux1Paths(config flux1Config, report *migrationReport) ([]kustomizev1.Kustomization, error) {
+	interval, err := time.ParseDuration(config.get("sync-interval"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --sync-interval: %w", err)
+	}
+	prune := config.get("sync-garbage-collection") == "true"
+
+	paths := []string{""}
+	if p := config.get("git-path"); p != "" {
+		paths = strings.Split(p, ",")
+	}
+
+	var kustomizations []kustomizev1.Kustomization
+	for _, p := range paths {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		name := migrateFlux1Args.name
+		if len(paths) > 1 {
+			name = objectNameFrom(migrateFlux1Args.name + "-" + p)
+		}
+		if config.get("manifest-generation") == "true" {
+			if err := checkFlux1Generators(p, report); err != nil {
+				return nil, err
+			}
+		}
+		kustomizations = append(kustomizations, kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: rootArgs.namespace,
+			},
+			Spec: kustomizev1.KustomizationSpec{
+				Interval: metav1.Duration{Duration: interval},
+				Path:     "./" + p,
+				Prune:    prune,
+				SourceRef: kustomizev1.CrossNamespaceSourceReference{
+					Kind: sourcev1.GitRepositoryKind,
+					Name: migrateFlux1Args.name,
+				},
+				Validation: "client",
+			},
+		})
+	}
+	return kustomizations, nil
+}
+
+// flux1ManifestGeneration is the content of a .flux.yaml file.
+type flux1ManifestGeneration struct {
+	CommandUpdated *struct {
+		Generators []flux1Generator `json:"generators"`
+	} `json:"commandUpdated"`
+	PatchUpdated *struct {
+		Generators []flux1Generator `json:"generators"`
+	} `json:"patchUpdated"`
+}
+
+type flux1Generator struct {
+	Command string `json:"command"`
+}
+
+var kustomizeBuildCommand = regexp.MustCompile(`^kustomize build( \.)?$`)
+
+// checkFlux1Generators reports the .flux.yaml file applying to a path,
+// unless its only generator is a kustomize build of the directory the
+// Kustomization builds as well.
+func checkFlux1Generators(p string, report *migrationReport) error {
+	if migrateFlux1Args.repoPath == "" {
+		return nil
+	}
+	// Flux v1 looks for the .flux.yaml file in the path and its parents
+	dir := filepath.Join(migrateFlux1Args.repoPath, p)
+	for {
+		configPath := filepath.Join(dir, ".flux.yaml")
+		data, err := ioutil.ReadFile(configPath)
+		if err == nil {
+			var generation flux1ManifestGeneration
+			if err := yaml.Unmarshal(data, &generation); err != nil {
+				return fmt.Errorf("failed to decode %s: %w", configPath, err)
+			}
+			var generators []flux1Generator
+			switch {
+			case generation.CommandUpdated != nil:
+				generators = generation.CommandUpdated.Generators
+			case generation.PatchUpdated != nil:
+				generators = generation.PatchUpdated.Generators
+			}
+			if len(generators) == 1 && kustomizeBuildCommand.MatchString(strings.TrimSpace(generators[0].Command)) {
+				if dir != filepath.Join(migrateFlux1Args.repoPath, p) {
+					report.add("%s builds %s, set the path of the Kustomization of %s to it", configPath, dir, p)
+				}
+				return nil
+			}
+			report.add("the generators of %s have no equivalent, commit their output or replace them with a kustomization.yaml", configPath)
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		if filepath.Clean(dir) == filepath.Clean(migrateFlux1Args.repoPath) {
+			return nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+const (
+	flux1AutomatedAnnotation = "fluxcd.io/automated"
+	flux1TagAnnotationPrefix = "fluxcd.io/tag."
+	flux1FilterPrefix        = "filter.fluxcd.io/"
+)
+
+// migrateFlux1Automation translates the automation annotations of the
+// workloads of the repository into image automation objects.
+func migrateFlux1Automation(config flux1Config, report *migrationReport) ([]interface{}, error) {
+	repositories := map[string]*imagev1.ImageRepository{}
+	var policies []imagev1.ImagePolicy
+
+	root := migrateFlux1Args.repoPath
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if ext := filepath.Ext(p); info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		objects, err := apply.DecodeObjects(data)
+		if err != nil {
+			// not a manifest, e.g. a values or config file
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		for _, obj := range objects {
+			if obj.GetAnnotations()[flux1AutomatedAnnotation] != "true" {
+				continue
+			}
+			policies = append(policies, migrateFlux1Workload(obj, rel, repositories, report)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	if config.get("git-readonly") == "true" {
+		report.add("Flux v1 runs with --git-readonly, review the ImageUpdateAutomation before applying it")
+	}
+
+	var exports []interface{}
+	for _, name := range sortedRepositoryNames(repositories) {
+		exports = append(exports, exportImageRepository(repositories[name]))
+	}
+	for i := range policies {
+		exports = append(exports, exportImagePolicy(&policies[i]))
+	}
+
+	interval, err := time.ParseDuration(config.get("git-poll-interval"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --git-poll-interval: %w", err)
+	}
+	authorName := config.get("git-user")
+	if authorName == "" {
+		authorName = "fluxcdbot"
+	}
+	authorEmail := config.get("git-email")
+	if authorEmail == "" {
+		authorEmail = "fluxcdbot@users.noreply.github.com"
+	}
+	update := autov1.ImageUpdateAutomation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      migrateFlux1Args.name,
+			Namespace: rootArgs.namespace,
+		},
+		Spec: autov1.ImageUpdateAutomationSpec{
+			Checkout: autov1.GitCheckoutSpec{
+				GitRepositoryRef: meta.LocalObjectReference{
+					Name: migrateFlux1Args.name,
+				},
+				Branch: config.get("git-branch"),
+			},
+			Interval: metav1.Duration{Duration: interval},
+			Commit: autov1.CommitSpec{
+				AuthorName:  authorName,
+				AuthorEmail: authorEmail,
+			},
+		},
+	}
+	exports = append(exports, exportImageUpdate(&update))
+	return exports, nil
+}
+
+// migrateFlux1Workload returns an ImagePolicy for each container of an
+// automated workload, adding the ImageRepositories of their images.
+func migrateFlux1Workload(obj *unstructured.Unstructured, file string,
+	repositories map[string]*imagev1.ImageRepository, report *migrationReport) []imagev1.ImagePolicy {
+	containers, found, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	if !found {
+		report.add("%s in %s is automated, but its containers can't be found, migrate it manually", apply.ObjectName(obj), file)
+		return nil
+	}
+
+	var policies []imagev1.ImagePolicy
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		containerName, _ := container["name"].(string)
+		image, _ := container["image"].(string)
+		if image == "" {
+			continue
+		}
+		imageName := image
+		if i := strings.LastIndex(image, ":"); i > 0 && !strings.Contains(image[i:], "/") {
+			imageName = image[:i]
+		}
+
+		repoName := objectNameFrom(filepath.Base(imageName))
+		if existing, ok := repositories[repoName]; ok && existing.Spec.Image != imageName {
+			repoName = objectNameFrom(imageName)
+		}
+		if _, ok := repositories[repoName]; !ok {
+			repositories[repoName] = &imagev1.ImageRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      repoName,
+					Namespace: rootArgs.namespace,
+				},
+				Spec: imagev1.ImageRepositorySpec{
+					Image:    imageName,
+					Interval: metav1.Duration{Duration: time.Minute},
+				},
+			}
+		}
+
+		policyName := objectNameFrom(obj.GetName() + "-" + containerName)
+		policy := imagev1.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      policyName,
+				Namespace: rootArgs.namespace,
+			},
+			Spec: imagev1.ImagePolicySpec{
+				ImageRepositoryRef: meta.LocalObjectReference{
+					Name: repoName,
+				},
+			},
+		}
+
+		filter := obj.GetAnnotations()[flux1TagAnnotationPrefix+containerName]
+		if filter == "" {
+			filter = obj.GetAnnotations()[flux1FilterPrefix+containerName]
+		}
+		switch {
+		case strings.HasPrefix(filter, "semver:"):
+			policy.Spec.Policy.SemVer = &imagev1.SemVerPolicy{
+				Range: strings.TrimPrefix(filter, "semver:"),
+			}
+		case strings.HasPrefix(filter, "regex:"), strings.HasPrefix(filter, "regexp:"):
+			pattern := filter[strings.Index(filter, ":")+1:]
+			policy.Spec.FilterTags = &imagev1.TagFilter{Pattern: pattern}
+			policy.Spec.Policy.Alphabetical = &imagev1.AlphabeticalPolicy{Order: "asc"}
+			report.add("ImagePolicy %s orders the tags matching %s alphabetically, where Flux v1 orders them by build time, review its policy", policyName, pattern)
+		case strings.HasPrefix(filter, "glob:"):
+			pattern := globToRegexp(strings.TrimPrefix(filter, "glob:"))
+			policy.Spec.FilterTags = &imagev1.TagFilter{Pattern: pattern}
+			policy.Spec.Policy.Alphabetical = &imagev1.AlphabeticalPolicy{Order: "asc"}
+			report.add("ImagePolicy %s orders the tags matching %s alphabetically, where Flux v1 orders them by build time, review its policy", policyName, pattern)
+		default:
+			policy.Spec.Policy.Alphabetical = &imagev1.AlphabeticalPolicy{Order: "asc"}
+			report.add("container %s of %s in %s selects the latest built tag, which has no equivalent policy, review ImagePolicy %s",
+				containerName, apply.ObjectName(obj), file, policyName)
+		}
+		report.add("add the marker # {\"$imagepolicy\": \"%s:%s\"} to the image of container %s of %s in %s",
+			rootArgs.namespace, policyName, containerName, apply.ObjectName(obj), file)
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// globToRegexp translates a Flux v1 glob filter into a regular
+// expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedRepositoryNames(m map[string]*imagev1.ImageRepository) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
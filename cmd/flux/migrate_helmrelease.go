@@ -0,0 +1,494 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/pkg/apply"
+)
+
+var migrateHelmReleaseCmd = &cobra.Command{
+	Use:     "helmrelease [name]",
+	Aliases: []string{"hr"},
+	Short:   "Convert Helm Operator HelmReleases to helm-controller HelmReleases",
+	Long: `The migrate helmrelease command translates the helm.fluxcd.io/v1 HelmReleases of Helm Operator,
+read from a file or from the cluster, into helm.toolkit.fluxcd.io HelmReleases and the sources of their charts.
+The fields that can't be translated are reported on stderr.`,
+	Example: `  # Convert the HelmReleases of a manifest
+  flux migrate helmrelease --file=./releases/podinfo.yaml > podinfo.yaml
+
+  # Convert a HelmRelease of the cluster
+  flux migrate hr podinfo --namespace=apps
+
+  # Convert all the HelmReleases of the cluster
+  flux migrate hr --all-namespaces
+`,
+	RunE: migrateHelmReleaseCmdRun,
+}
+
+type migrateHelmReleaseFlags struct {
+	file          string
+	allNamespaces bool
+	interval      time.Duration
+}
+
+var migrateHelmReleaseArgs migrateHelmReleaseFlags
+
+func init() {
+	migrateHelmReleaseCmd.Flags().StringVarP(&migrateHelmReleaseArgs.file, "file", "f", "",
+		"path to a manifest of Helm Operator HelmReleases, read instead of the HelmReleases in the cluster")
+	migrateHelmReleaseCmd.Flags().BoolVarP(&migrateHelmReleaseArgs.allNamespaces, "all-namespaces", "A", false,
+		"convert the HelmReleases of all namespaces")
+	migrateHelmReleaseCmd.Flags().DurationVar(&migrateHelmReleaseArgs.interval, "interval", 5*time.Minute,
+		"the reconciliation interval of the generated objects")
+
+	migrateCmd.AddCommand(migrateHelmReleaseCmd)
+}
+
+var helmOperatorReleaseGVK = schema.GroupVersionKind{
+	Group:   "helm.fluxcd.io",
+	Version: "v1",
+	Kind:    "HelmRelease",
+}
+
+// helmOperatorRelease holds the fields of a helm.fluxcd.io/v1
+// HelmRelease the migration reads.
+type helmOperatorRelease struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		HelmVersion     string                  `json:"helmVersion"`
+		ReleaseName     string                  `json:"releaseName"`
+		TargetNamespace string                  `json:"targetNamespace"`
+		Timeout         *int64                  `json:"timeout"`
+		ResetValues     *bool                   `json:"resetValues"`
+		SkipCRDs        bool                    `json:"skipCRDs"`
+		Wait            *bool                   `json:"wait"`
+		ForceUpgrade    bool                    `json:"forceUpgrade"`
+		MaxHistory      *int                    `json:"maxHistory"`
+		Chart           helmOperatorChart       `json:"chart"`
+		Values          *apiextensionsv1.JSON   `json:"values"`
+		ValuesFrom      []helmOperatorValuesRef `json:"valuesFrom"`
+		Rollback        *struct {
+			Enable       bool   `json:"enable"`
+			Retry        bool   `json:"retry"`
+			MaxRetries   *int64 `json:"maxRetries"`
+			Force        bool   `json:"force"`
+			Recreate     bool   `json:"recreate"`
+			DisableHooks bool   `json:"disableHooks"`
+			Timeout      *int64 `json:"timeout"`
+			Wait         *bool  `json:"wait"`
+		} `json:"rollback"`
+		Test *struct {
+			Enable         bool   `json:"enable"`
+			IgnoreFailures bool   `json:"ignoreFailures"`
+			Timeout        *int64 `json:"timeout"`
+			Cleanup        *bool  `json:"cleanup"`
+		} `json:"test"`
+	} `json:"spec"`
+}
+
+type helmOperatorChart struct {
+	Repository      string                     `json:"repository"`
+	Name            string                     `json:"name"`
+	Version         string                     `json:"version"`
+	ChartPullSecret *meta.LocalObjectReference `json:"chartPullSecret"`
+	Git             string                     `json:"git"`
+	Ref             string                     `json:"ref"`
+	Path            string                     `json:"path"`
+	SecretRef       *meta.LocalObjectReference `json:"secretRef"`
+	SkipDepUpdate   bool                       `json:"skipDepUpdate"`
+}
+
+type helmOperatorKeyRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Optional  bool   `json:"optional"`
+}
+
+type helmOperatorValuesRef struct {
+	ConfigMapKeyRef   *helmOperatorKeyRef `json:"configMapKeyRef"`
+	SecretKeyRef      *helmOperatorKeyRef `json:"secretKeyRef"`
+	ExternalSourceRef *struct {
+		URL string `json:"url"`
+	} `json:"externalSourceRef"`
+	ChartFileRef *struct {
+		Path string `json:"path"`
+	} `json:"chartFileRef"`
+}
+
+func migrateHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
+	objects, err := readHelmOperatorReleases(args)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("no Helm Operator HelmRelease found")
+	}
+
+	var report migrationReport
+	sources := map[string]client.Object{}
+	var sourceKeys []string
+	var exports []interface{}
+	for _, obj := range objects {
+		var release helmOperatorRelease
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &release); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", apply.ObjectName(obj), err)
+		}
+
+		helmRelease, source, err := migrateHelmOperatorRelease(&release, &report)
+		if err != nil {
+			return fmt.Errorf("%s: %w", apply.ObjectName(obj), err)
+		}
+		key := fmt.Sprintf("%T/%s/%s", source, source.GetNamespace(), source.GetName())
+		if _, ok := sources[key]; !ok {
+			sources[key] = source
+			sourceKeys = append(sourceKeys, key)
+		}
+		exports = append(exports, exportHelmRelease(helmRelease))
+	}
+
+	for _, key := range sourceKeys {
+		var export interface{}
+		switch source := sources[key].(type) {
+		case *sourcev1.HelmRepository:
+			export = exportHelmRepository(source)
+		case *sourcev1.GitRepository:
+			export = exportGitRepository(source)
+		}
+		if err := printExport(export); err != nil {
+			return err
+		}
+	}
+	for _, export := range exports {
+		if err := printExport(export); err != nil {
+			return err
+		}
+	}
+
+	report.print()
+	return nil
+}
+
+// readHelmOperatorReleases returns the Helm Operator HelmReleases of
+// the file given with --file, or else of the cluster.
+func readHelmOperatorReleases(args []string) ([]*unstructured.Unstructured, error) {
+	if migrateHelmReleaseArgs.file != "" {
+		data, err := ioutil.ReadFile(migrateHelmReleaseArgs.file)
+		if err != nil {
+			return nil, err
+		}
+		objects, err := apply.DecodeObjects(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", migrateHelmReleaseArgs.file, err)
+		}
+		var releases []*unstructured.Unstructured
+		for _, obj := range objects {
+			if obj.GroupVersionKind() != helmOperatorReleaseGVK {
+				continue
+			}
+			if len(args) > 0 && obj.GetName() != args[0] {
+				continue
+			}
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(rootArgs.namespace)
+			}
+			releases = append(releases, obj)
+		}
+		return releases, nil
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(helmOperatorReleaseGVK)
+		namespacedName := types.NamespacedName{
+			Namespace: rootArgs.namespace,
+			Name:      args[0],
+		}
+		if err := kubeClient.Get(ctx, namespacedName, obj); err != nil {
+			return nil, err
+		}
+		return []*unstructured.Unstructured{obj}, nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(helmOperatorReleaseGVK.GroupVersion().WithKind(helmOperatorReleaseGVK.Kind + "List"))
+	var opts []client.ListOption
+	if !migrateHelmReleaseArgs.allNamespaces {
+		opts = append(opts, client.InNamespace(rootArgs.namespace))
+	}
+	if err := kubeClient.List(ctx, list, opts...); err != nil {
+		return nil, err
+	}
+	var releases []*unstructured.Unstructured
+	for i := range list.Items {
+		releases = append(releases, &list.Items[i])
+	}
+	return releases, nil
+}
+
+// migrateHelmOperatorRelease returns the HelmRelease equivalent to a
+// Helm Operator HelmRelease, and the source of its chart.
+func migrateHelmOperatorRelease(release *helmOperatorRelease, report *migrationReport) (*helmv2.HelmRelease, client.Object, error) {
+	name := fmt.Sprintf("%s/%s", release.Namespace, release.Name)
+	spec := release.Spec
+
+	helmRelease := &helmv2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      release.Name,
+			Namespace: release.Namespace,
+			Labels:    release.Labels,
+		},
+		Spec: helmv2.HelmReleaseSpec{
+			Interval:        metav1.Duration{Duration: migrateHelmReleaseArgs.interval},
+			ReleaseName:     spec.ReleaseName,
+			TargetNamespace: spec.TargetNamespace,
+			MaxHistory:      spec.MaxHistory,
+			Values:          spec.Values,
+		},
+	}
+	// Helm Operator names the releases <namespace>-<name> by default,
+	// where helm-controller uses the name alone.
+	if spec.ReleaseName == "" {
+		namespace := release.Namespace
+		if spec.TargetNamespace != "" {
+			namespace = spec.TargetNamespace
+		}
+		helmRelease.Spec.ReleaseName = namespace + "-" + release.Name
+	}
+
+	if spec.HelmVersion == "v2" {
+		report.add("HelmRelease %s is installed with Helm 2, its release must be converted to Helm 3 with helm-2to3 before the cutover", name)
+	}
+
+	var source client.Object
+	switch {
+	case spec.Chart.Repository != "":
+		repository := migrateHelmOperatorRepository(release, report)
+		helmRelease.Spec.Chart.Spec = helmv2.HelmChartTemplateSpec{
+			Chart:   spec.Chart.Name,
+			Version: spec.Chart.Version,
+			SourceRef: helmv2.CrossNamespaceObjectReference{
+				Kind: sourcev1.HelmRepositoryKind,
+				Name: repository.Name,
+			},
+		}
+		source = repository
+	case spec.Chart.Git != "":
+		repository := migrateHelmOperatorGitRepository(release, report)
+		chartPath := spec.Chart.Path
+		if chartPath == "" {
+			chartPath = "."
+		}
+		helmRelease.Spec.Chart.Spec = helmv2.HelmChartTemplateSpec{
+			Chart: "./" + chartPath,
+			SourceRef: helmv2.CrossNamespaceObjectReference{
+				Kind: sourcev1.GitRepositoryKind,
+				Name: repository.Name,
+			},
+		}
+		source = repository
+	default:
+		return nil, nil, fmt.Errorf("the chart has neither a repository nor a git URL")
+	}
+
+	if spec.Timeout != nil {
+		helmRelease.Spec.Timeout = &metav1.Duration{Duration: time.Duration(*spec.Timeout) * time.Second}
+	}
+	// Helm Operator doesn't wait for the resources to be ready unless
+	// asked to, helm-controller does by default.
+	disableWait := spec.Wait == nil || !*spec.Wait
+	helmRelease.Spec.Install = &helmv2.Install{
+		DisableWait: disableWait,
+		SkipCRDs:    spec.SkipCRDs,
+	}
+	helmRelease.Spec.Upgrade = &helmv2.Upgrade{
+		DisableWait: disableWait,
+		Force:       spec.ForceUpgrade,
+	}
+	if spec.ResetValues != nil && !*spec.ResetValues {
+		report.add("HelmRelease %s sets resetValues to false, helm-controller always resets the values to the ones of the HelmRelease", name)
+	}
+
+	if rollback := spec.Rollback; rollback != nil && rollback.Enable {
+		retries := 0
+		if rollback.Retry {
+			retries = 5
+			if rollback.MaxRetries != nil {
+				retries = int(*rollback.MaxRetries)
+			}
+		}
+		remediateLastFailure := true
+		helmRelease.Spec.Upgrade.Remediation = &helmv2.UpgradeRemediation{
+			Retries:              retries,
+			RemediateLastFailure: &remediateLastFailure,
+		}
+		helmRelease.Spec.Rollback = &helmv2.Rollback{
+			DisableWait:  rollback.Wait == nil || !*rollback.Wait,
+			DisableHooks: rollback.DisableHooks,
+			Recreate:     rollback.Recreate,
+			Force:        rollback.Force,
+		}
+		if rollback.Timeout != nil {
+			helmRelease.Spec.Rollback.Timeout = &metav1.Duration{Duration: time.Duration(*rollback.Timeout) * time.Second}
+		}
+	}
+
+	if test := spec.Test; test != nil && test.Enable {
+		helmRelease.Spec.Test = &helmv2.Test{
+			Enable:         true,
+			IgnoreFailures: test.IgnoreFailures,
+		}
+		if test.Timeout != nil {
+			helmRelease.Spec.Test.Timeout = &metav1.Duration{Duration: time.Duration(*test.Timeout) * time.Second}
+		}
+		if test.Cleanup != nil {
+			report.add("HelmRelease %s sets test.cleanup, helm-controller always keeps the test pods", name)
+		}
+	}
+
+	for _, ref := range spec.ValuesFrom {
+		switch {
+		case ref.ConfigMapKeyRef != nil:
+			helmRelease.Spec.ValuesFrom = append(helmRelease.Spec.ValuesFrom,
+				migrateHelmOperatorValuesRef("ConfigMap", ref.ConfigMapKeyRef, name, release.Namespace, report))
+		case ref.SecretKeyRef != nil:
+			helmRelease.Spec.ValuesFrom = append(helmRelease.Spec.ValuesFrom,
+				migrateHelmOperatorValuesRef("Secret", ref.SecretKeyRef, name, release.Namespace, report))
+		case ref.ExternalSourceRef != nil:
+			report.add("HelmRelease %s reads values from %s, store them in a ConfigMap and reference it in valuesFrom",
+				name, ref.ExternalSourceRef.URL)
+		case ref.ChartFileRef != nil:
+			report.add("HelmRelease %s reads values from the chart file %s, merge them in the values of the HelmRelease",
+				name, ref.ChartFileRef.Path)
+		}
+	}
+
+	return helmRelease, source, nil
+}
+
+func migrateHelmOperatorValuesRef(kind string, ref *helmOperatorKeyRef, name, namespace string,
+	report *migrationReport) helmv2.ValuesReference {
+	if ref.Namespace != "" && ref.Namespace != namespace {
+		report.add("HelmRelease %s reads values from %s %s/%s, which must be copied to namespace %s",
+			name, kind, ref.Namespace, ref.Name, namespace)
+	}
+	return helmv2.ValuesReference{
+		Kind:      kind,
+		Name:      ref.Name,
+		ValuesKey: ref.Key,
+		Optional:  ref.Optional,
+	}
+}
+
+func migrateHelmOperatorRepository(release *helmOperatorRelease, report *migrationReport) *sourcev1.HelmRepository {
+	chart := release.Spec.Chart
+	name := chart.Repository
+	if u, err := url.Parse(chart.Repository); err == nil && u.Host != "" {
+		name = u.Host + u.Path
+	}
+	repository := &sourcev1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      objectNameFrom(name),
+			Namespace: release.Namespace,
+		},
+		Spec: sourcev1.HelmRepositorySpec{
+			URL:      chart.Repository,
+			Interval: metav1.Duration{Duration: migrateHelmReleaseArgs.interval},
+		},
+	}
+	if chart.ChartPullSecret != nil {
+		repository.Spec.SecretRef = &meta.LocalObjectReference{
+			Name: chart.ChartPullSecret.Name,
+		}
+		report.add("secret %s/%s holds a repositories.yaml, HelmRepository %s expects username and password keys instead",
+			release.Namespace, chart.ChartPullSecret.Name, repository.Name)
+	}
+	return repository
+}
+
+func migrateHelmOperatorGitRepository(release *helmOperatorRelease, report *migrationReport) *sourcev1.GitRepository {
+	chart := release.Spec.Chart
+	gitURL := toSSHURL(chart.Git)
+	ref := chart.Ref
+	if ref == "" {
+		ref = "master"
+	}
+	name := gitURL
+	if u, err := url.Parse(gitURL); err == nil && u.Host != "" {
+		name = u.Host + u.Path
+	}
+	repository := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      objectNameFrom(name + "-" + ref),
+			Namespace: release.Namespace,
+		},
+		Spec: sourcev1.GitRepositorySpec{
+			URL:      gitURL,
+			Interval: metav1.Duration{Duration: migrateHelmReleaseArgs.interval},
+			Reference: &sourcev1.GitRepositoryRef{
+				Branch: ref,
+			},
+		},
+	}
+	if chart.SecretRef != nil {
+		repository.Spec.SecretRef = &meta.LocalObjectReference{
+			Name: chart.SecretRef.Name,
+		}
+		report.add("secret %s/%s must hold the identity and known_hosts of GitRepository %s, e.g. with flux create secret git",
+			release.Namespace, chart.SecretRef.Name, repository.Name)
+	} else if strings.HasPrefix(gitURL, "ssh://") {
+		report.add("Helm Operator authenticates to %s with its own key, create a secret for GitRepository %s with flux create secret git",
+			chart.Git, repository.Name)
+	}
+	if chart.SkipDepUpdate {
+		report.add("HelmRelease %s/%s sets skipDepUpdate, helm-controller always updates the dependencies of charts from Git",
+			release.Namespace, release.Name)
+	}
+	return repository
+}
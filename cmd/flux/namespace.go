@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+// clusterCommands are the commands working with the Flux installation
+// or with local files, for which -n defaults to flux-system, rather
+// than to the namespace of the kubeconfig context.
+var clusterCommands = []string{
+	"bootstrap", "check", "completion", "doctor", "help", "install", "list", "logs", "metrics", "migrate", "pull", "push", "tag", "uninstall", "validate", "version",
+}
+
+// offlineCommands are the resource commands which don't use the
+// cluster, given by their path below the root command.
+var offlineCommands = []string{
+	"export crds",
+}
+
+// isResourceCommand returns whether a command works with the toolkit
+// objects of the namespace given with -n.
+func isResourceCommand(cmd *cobra.Command) bool {
+	// the root is found through the command, as referring to rootCmd
+	// here would be an initialization cycle
+	if !cmd.HasParent() || cmd.Name() == cobra.ShellCompRequestCmd {
+		return false
+	}
+	top := cmd
+	for top.Parent().HasParent() {
+		top = top.Parent()
+	}
+	return !utils.ContainsItemString(clusterCommands, top.Name())
+}
+
+// setDefaultNamespace sets the namespace of a resource command, when
+// not given with -n, to the namespace of the kubeconfig context, if
+// the context sets one.
+func setDefaultNamespace(cmd *cobra.Command) {
+	if !isResourceCommand(cmd) || cmd.Flags().Changed("namespace") {
+		return
+	}
+	// the commands report the kubeconfig errors themselves
+	if namespace, err := utils.KubeContextNamespace(rootArgs.kubeconfig, rootArgs.kubecontext); err == nil && namespace != "" {
+		rootArgs.namespace = namespace
+	}
+}
+
+// checkNamespace returns an error when the namespace of a resource
+// command doesn't exist, unless the command doesn't use the cluster or
+// works across namespaces. The namespace is looked up once, without
+// retries, and any other error, e.g. the user not being allowed to get
+// namespaces, is only reported as a warning, the command reporting it
+// again if it matters.
+func checkNamespace(cmd *cobra.Command) error {
+	if !isResourceCommand(cmd) {
+		return nil
+	}
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if utils.ContainsItemString(offlineCommands, path) {
+		return nil
+	}
+	for _, name := range []string{"export", "dry-run", "all-namespaces"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Value.String() == "true" {
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	// the shared client retries the transient errors, which would
+	// delay every command when the cluster is unreachable
+	cfg, err := getKubeConfig()
+	if err != nil {
+		return nil
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil
+	}
+	_, err = clientSet.CoreV1().Namespaces().Get(ctx, rootArgs.namespace, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return fmt.Errorf("namespace '%s' not found, set the namespace with -n or in the kubeconfig context", rootArgs.namespace)
+	case err != nil:
+		logger.Warningf("could not check the namespace '%s': %s", rootArgs.namespace, err.Error())
+	}
+	return nil
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"reflect"
+
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -70,3 +72,11 @@ func copyName(target, source named) {
 	target.SetName(source.GetName())
 	target.SetNamespace(source.GetNamespace())
 }
+
+// resetObject zeroes the value behind an adapter, so that it can be
+// reused to load another object of the same kind without fields of
+// the previous one lingering.
+func resetObject(obj adapter) {
+	v := reflect.ValueOf(obj.asClientObject()).Elem()
+	v.Set(reflect.Zero(v.Type()))
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/pkg/oci"
+)
+
+var pullArtifactCmd = &cobra.Command{
+	Use:   "artifact [oci-url]",
+	Short: "Pull an OCI artifact and extract its manifests",
+	Long: `The pull artifact command downloads an artifact pushed with push artifact, and extracts its files
+to a local directory, for inspection or to carry the manifests to an air-gapped environment.
+The credentials of the registry are read from the Docker configuration, as written by docker login.
+With --verify, the cosign signature of the artifact is verified before it is pulled, with cosign,
+which must be in the PATH.`,
+	Example: `  # Pull the artifact tagged v1 and extract its manifests to ./deploy
+  flux pull artifact oci://ghcr.io/org/manifests/podinfo:v1 --output=./deploy
+
+  # Pull an artifact by digest
+  flux pull artifact oci://ghcr.io/org/manifests/podinfo@sha256:<hash> --output=./deploy
+
+  # Verify the signature of the artifact with a cosign public key before pulling it
+  flux pull artifact oci://ghcr.io/org/manifests/podinfo:v1 --output=./deploy \
+    --verify \
+    --cosign-key=./cosign.pub
+`,
+	RunE: pullArtifactCmdRun,
+}
+
+type pullArtifactFlags struct {
+	output    string
+	verify    bool
+	cosignKey string
+}
+
+var pullArtifactArgs pullArtifactFlags
+
+func init() {
+	pullArtifactCmd.Flags().StringVarP(&pullArtifactArgs.output, "output", "o", "", "path to the directory the manifests are extracted to")
+
+	pullArtifactCmd.Flags().BoolVar(&pullArtifactArgs.verify, "verify", false, "verify the cosign signature of the artifact before pulling it")
+	pullArtifactCmd.Flags().StringVar(&pullArtifactArgs.cosignKey, "cosign-key", "",
+		"path to the cosign public key the signature is verified with, the signature is verified keyless when not set")
+
+	pullCmd.AddCommand(pullArtifactCmd)
+}
+
+func pullArtifactCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("artifact URL is required")
+	}
+	url := args[0]
+
+	if pullArtifactArgs.output == "" {
+		return fmt.Errorf("output is required")
+	}
+	if _, err := oci.ParseArtifactURL(url); err != nil {
+		return err
+	}
+	if pullArtifactArgs.cosignKey != "" && !pullArtifactArgs.verify {
+		return fmt.Errorf("--cosign-key requires --verify")
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	if pullArtifactArgs.verify {
+		// the artifact is pinned to its digest, so that a tag moved in
+		// the meantime doesn't make another artifact be pulled
+		digestURL, err := oci.ResolveDigest(ctx, url)
+		if err != nil {
+			return err
+		}
+		logger.Actionf("verifying the signature of %s", digestURL)
+		if err := verifyArtifact(ctx, digestURL, pullArtifactArgs.cosignKey); err != nil {
+			return fmt.Errorf("verifying the artifact signature failed: %w", err)
+		}
+		logger.Successf("artifact signature verified")
+		url = digestURL
+	}
+
+	logger.Actionf("pulling artifact from %s", url)
+	metadata, err := oci.Pull(ctx, url, pullArtifactArgs.output)
+	if err != nil {
+		return err
+	}
+	if metadata.Source != "" {
+		logger.Successf("source %s", metadata.Source)
+	}
+	if metadata.Revision != "" {
+		logger.Successf("revision %s", metadata.Revision)
+	}
+	logger.Successf("digest %s", metadata.Digest)
+	logger.Successf("artifact content extracted to %s", pullArtifactArgs.output)
+	return nil
+}
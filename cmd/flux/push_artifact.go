@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/pkg/oci"
+)
+
+var pushArtifactCmd = &cobra.Command{
+	Use:   "artifact [oci-url]",
+	Short: "Push the manifests of a directory as an OCI artifact",
+	Long: `The push artifact command packages the files of a directory as an OCI artifact, and pushes it to a
+container registry, for OCIRepository sources to pull it. The Git address and revision the manifests
+come from are recorded in the annotations of the artifact. The credentials of the registry are read
+from the Docker configuration, as written by docker login. With --sign, the pushed artifact is signed
+with cosign, which must be in the PATH.`,
+	Example: `  # Push the manifests of the ./deploy directory, with the Git revision they come from
+  flux push artifact oci://ghcr.io/org/manifests/podinfo:v1 \
+    --path=./deploy \
+    --source=https://github.com/org/podinfo \
+    --revision=main/$(git rev-parse HEAD)
+
+  # Push the manifests and sign the artifact with a cosign key pair
+  flux push artifact oci://ghcr.io/org/manifests/podinfo:v1 \
+    --path=./deploy \
+    --sign \
+    --cosign-key=./cosign.key
+`,
+	RunE: pushArtifactCmdRun,
+}
+
+type pushArtifactFlags struct {
+	path      string
+	source    string
+	revision  string
+	sign      bool
+	cosignKey string
+}
+
+var pushArtifactArgs pushArtifactFlags
+
+func init() {
+	pushArtifactCmd.Flags().StringVar(&pushArtifactArgs.path, "path", "", "path to the directory holding the manifests")
+	pushArtifactCmd.Flags().StringVar(&pushArtifactArgs.source, "source", "", "the address of the Git repository the manifests come from")
+	pushArtifactCmd.Flags().StringVar(&pushArtifactArgs.revision, "revision", "", "the Git revision the manifests come from, in the format <branch|tag>/<commit-sha>")
+
+	pushArtifactCmd.Flags().BoolVar(&pushArtifactArgs.sign, "sign", false, "sign the pushed artifact with cosign")
+	pushArtifactCmd.Flags().StringVar(&pushArtifactArgs.cosignKey, "cosign-key", "",
+		"path to the cosign private key the artifact is signed with, the artifact is signed keyless when not set")
+
+	pushCmd.AddCommand(pushArtifactCmd)
+}
+
+func pushArtifactCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("artifact URL is required")
+	}
+	url := args[0]
+
+	if pushArtifactArgs.path == "" {
+		return fmt.Errorf("path is required")
+	}
+	if pushArtifactArgs.cosignKey != "" && !pushArtifactArgs.sign {
+		return fmt.Errorf("--cosign-key requires --sign")
+	}
+	if _, err := oci.ParseArtifactURL(url); err != nil {
+		return err
+	}
+
+	metadata := oci.Metadata{
+		Source:   pushArtifactArgs.source,
+		Revision: pushArtifactArgs.revision,
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	logger.Actionf("pushing artifact to %s", url)
+	digestURL, err := oci.Push(ctx, url, pushArtifactArgs.path, metadata)
+	if err != nil {
+		return err
+	}
+	logger.Successf("artifact successfully pushed to %s", digestURL)
+
+	if pushArtifactArgs.sign {
+		logger.Actionf("signing artifact with cosign")
+		if err := signArtifact(ctx, digestURL, pushArtifactArgs.cosignKey); err != nil {
+			return fmt.Errorf("signing the artifact failed: %w", err)
+		}
+		logger.Successf("artifact signed")
+	}
+	return nil
+}
@@ -29,8 +29,6 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-
-	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var reconcileCmd = &cobra.Command{
@@ -46,11 +44,12 @@ func init() {
 type reconcileCommand struct {
 	apiType
 	object reconcilable
+	list   listAdapter // for expanding name patterns
 }
 
 type reconcilable interface {
-	adapter     // to be able to load from the cluster
-	suspendable // to tell if it's suspended
+	adapter            // to be able to load from the cluster
+	isSuspended() bool // to tell if it's suspended
 
 	// these are implemented by anything embedding metav1.ObjectMeta
 	GetAnnotations() map[string]string
@@ -59,30 +58,49 @@ type reconcilable interface {
 	// this is usually implemented by GOTK types, since it's used for meta.SetResourceCondition
 	GetStatusConditions() *[]metav1.Condition
 
+	successMessage() string // what do you want to tell people when successfully reconciled?
+}
+
+// trackedReconcilable is for reconcilables whose controller records
+// the last reconcile request it handled, which tells precisely when
+// the requested reconciliation is done. For the others, the best
+// indication is that they are ready at their current generation.
+type trackedReconcilable interface {
+	reconcilable
 	lastHandledReconcileRequest() string // what was the last handled reconcile request?
-	successMessage() string              // what do you want to tell people when successfully reconciled?
 }
 
 func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("%s name is required", reconcile.kind)
 	}
-	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
-	namespacedName := types.NamespacedName{
-		Namespace: rootArgs.namespace,
-		Name:      name,
+	selected, err := selectObjects(ctx, kubeClient, reconcile.list, args, objectSelection{})
+	if err != nil {
+		return err
+	}
+	for _, namespacedName := range selected {
+		resetObject(reconcile.object)
+		if err := reconcile.reconcile(ctx, kubeClient, namespacedName); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	err = kubeClient.Get(ctx, namespacedName, reconcile.object.asClientObject())
+// reconcile requests the reconciliation of the given object and waits
+// for the controller to handle it.
+func (reconcile reconcileCommand) reconcile(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName) error {
+	err := kubeClient.Get(ctx, namespacedName, reconcile.object.asClientObject())
 	if err != nil {
 		return err
 	}
@@ -91,35 +109,55 @@ func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resource is suspended")
 	}
 
-	logger.Actionf("annotating %s %s in %s namespace", reconcile.kind, name, rootArgs.namespace)
+	logger.Actionf("annotating %s %s in %s namespace", reconcile.kind, namespacedName.Name, namespacedName.Namespace)
 	if err := requestReconciliation(ctx, kubeClient, namespacedName, reconcile.object); err != nil {
 		return err
 	}
 	logger.Successf("%s annotated", reconcile.kind)
 
-	lastHandledReconcileAt := reconcile.object.lastHandledReconcileRequest()
+	var reconciled wait.ConditionFunc
+	switch obj := reconcile.object.(type) {
+	case trackedReconcilable:
+		reconciled = reconciliationHandled(ctx, kubeClient, namespacedName, obj, obj.lastHandledReconcileRequest())
+	case statusable:
+		reconciled = isReady(ctx, kubeClient, namespacedName, obj)
+	default:
+		return fmt.Errorf("cannot tell when the %s reconciliation is done", reconcile.kind)
+	}
+
 	logger.Waitingf("waiting for %s reconciliation", reconcile.kind)
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		reconciliationHandled(ctx, kubeClient, namespacedName, reconcile.object, lastHandledReconcileAt)); err != nil {
+	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout, reconciled); err != nil {
+		// tell why the object is not ready yet, if the controller says
+		c := apimeta.FindStatusCondition(*reconcile.object.GetStatusConditions(), meta.ReadyCondition)
+		if err == wait.ErrWaitTimeout && c != nil && c.Message != "" {
+			return fmt.Errorf("timed out waiting for %s reconciliation: %s", reconcile.kind, c.Message)
+		}
 		return err
 	}
 	logger.Successf("%s reconciliation completed", reconcile.kind)
 
-	if apimeta.IsStatusConditionFalse(*reconcile.object.GetStatusConditions(), meta.ReadyCondition) {
-		return fmt.Errorf("%s reconciliation failed", reconcile.kind)
+	if c := apimeta.FindStatusCondition(*reconcile.object.GetStatusConditions(), meta.ReadyCondition); c != nil &&
+		c.Status == metav1.ConditionFalse {
+		return fmt.Errorf("%s reconciliation failed: %s", reconcile.kind, c.Message)
 	}
 	logger.Successf(reconcile.object.successMessage())
 	return nil
 }
 
 func reconciliationHandled(ctx context.Context, kubeClient client.Client,
-	namespacedName types.NamespacedName, obj reconcilable, lastHandledReconcileAt string) wait.ConditionFunc {
+	namespacedName types.NamespacedName, obj trackedReconcilable, lastHandledReconcileAt string) wait.ConditionFunc {
 	return func() (bool, error) {
 		err := kubeClient.Get(ctx, namespacedName, obj.asClientObject())
 		if err != nil {
 			return false, err
 		}
-		return obj.lastHandledReconcileRequest() != lastHandledReconcileAt, nil
+		if obj.lastHandledReconcileRequest() == lastHandledReconcileAt {
+			return false, nil
+		}
+		// the request may be handled before the outcome is known,
+		// e.g. while a Helm upgrade is in progress
+		c := apimeta.FindStatusCondition(*obj.GetStatusConditions(), meta.ReadyCondition)
+		return c == nil || c.Status != metav1.ConditionUnknown, nil
 	}
 }
 
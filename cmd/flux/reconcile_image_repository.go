@@ -27,13 +27,16 @@ import (
 var reconcileImageRepositoryCmd = &cobra.Command{
 	Use:   "repository [name]",
 	Short: "Reconcile an ImageRepository",
-	Long:  `The reconcile image repository command triggers a reconciliation of an ImageRepository resource and waits for it to finish.`,
-	Example: `  # Trigger an scan for an existing image repository
+	Long: `The reconcile image repository command triggers a scan of the tags of an ImageRepository and waits for it to finish.
+Run it right after publishing an image, so that the image policies select it without waiting for the next scan.`,
+	Example: `  # Trigger a scan for an existing image repository
   flux reconcile image repository alpine
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageRepositoryType),
 	RunE: reconcileCommand{
 		apiType: imageRepositoryType,
 		object:  imageRepositoryAdapter{&imagev1.ImageRepository{}},
+		list:    imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
 	}.run,
 }
 
@@ -46,5 +49,8 @@ func (obj imageRepositoryAdapter) lastHandledReconcileRequest() string {
 }
 
 func (obj imageRepositoryAdapter) successMessage() string {
+	if obj.Status.LastScanResult == nil {
+		return "scan not yet run"
+	}
 	return fmt.Sprintf("scan fetched %d tags", obj.Status.LastScanResult.TagCount)
 }
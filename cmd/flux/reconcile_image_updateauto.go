@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -29,13 +30,16 @@ import (
 var reconcileImageUpdateCmd = &cobra.Command{
 	Use:   "update [name]",
 	Short: "Reconcile an ImageUpdateAutomation",
-	Long:  `The reconcile image update command triggers a reconciliation of an ImageUpdateAutomation resource and waits for it to finish.`,
+	Long: `The reconcile image update command triggers a reconciliation of an ImageUpdateAutomation resource and waits for it to finish.
+The commit last pushed to the Git repository is reported, for CI pipelines to follow the rollout of a new image.`,
 	Example: `  # Trigger an automation run for an existing image update automation
   flux reconcile image update latest-images
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageUpdateAutomationType),
 	RunE: reconcileCommand{
 		apiType: imageUpdateAutomationType,
 		object:  imageUpdateAutomationAdapter{&autov1.ImageUpdateAutomation{}},
+		list:    imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
 	}.run,
 }
 
@@ -52,11 +56,20 @@ func (obj imageUpdateAutomationAdapter) lastHandledReconcileRequest() string {
 }
 
 func (obj imageUpdateAutomationAdapter) successMessage() string {
-	if rc := apimeta.FindStatusCondition(obj.Status.Conditions, meta.ReadyCondition); rc != nil {
-		return rc.Message
+	var msg string
+	switch rc := apimeta.FindStatusCondition(obj.Status.Conditions, meta.ReadyCondition); {
+	case rc != nil:
+		msg = rc.Message
+	case obj.Status.LastAutomationRunTime != nil:
+		msg = "last run " + obj.Status.LastAutomationRunTime.Time.Format(time.RFC3339)
+	default:
+		return "automation not yet run"
 	}
-	if obj.Status.LastAutomationRunTime != nil {
-		return "last run " + obj.Status.LastAutomationRunTime.Time.Format(time.RFC3339)
+	if obj.Status.LastPushCommit != "" {
+		msg += fmt.Sprintf(", last pushed commit %s", obj.Status.LastPushCommit)
+		if obj.Status.LastPushTime != nil {
+			msg += " at " + obj.Status.LastPushTime.Time.Format(time.RFC3339)
+		}
 	}
-	return "automation not yet run"
+	return msg
 }
@@ -1,5 +1,5 @@
 /*
-Copyright 2020 The Flux authors
+Copyright 2021 The Flux authors
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -19,14 +19,9 @@ package main
 import (
 	"context"
 	"fmt"
-	"time"
-
-	"github.com/fluxcd/flux2/internal/utils"
-	"github.com/fluxcd/pkg/apis/meta"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
 )
@@ -34,66 +29,88 @@ import (
 var reconcileReceiverCmd = &cobra.Command{
 	Use:   "receiver [name]",
 	Short: "Reconcile a Receiver",
-	Long:  `The reconcile receiver command triggers a reconciliation of a Receiver resource and waits for it to finish.`,
+	Long: `The reconcile receiver command triggers a reconciliation of a Receiver resource and waits for it to finish.
+With --rotate-token, the token of the Receiver is replaced first, which changes its webhook URL.`,
 	Example: `  # Trigger a reconciliation for an existing receiver
   flux reconcile receiver main
+
+  # Replace the token of a receiver and print the webhook URL to configure on the Git provider
+  flux reconcile receiver main --rotate-token --ingress-host flux-webhook.example.com
 `,
-	RunE: reconcileReceiverCmdRun,
+	ValidArgsFunction: resourceNamesCompletionFunc(receiverType),
+	RunE:              reconcileReceiverCmdRun,
 }
 
+type reconcileReceiverFlags struct {
+	rotateToken bool
+	ingressHost string
+}
+
+var reconcileReceiverArgs reconcileReceiverFlags
+
 func init() {
+	reconcileReceiverCmd.Flags().BoolVar(&reconcileReceiverArgs.rotateToken, "rotate-token", false,
+		"replace the token of the Receiver with a newly generated one before reconciling it")
+	reconcileReceiverCmd.Flags().StringVar(&reconcileReceiverArgs.ingressHost, "ingress-host", "",
+		"the host the webhook receiver is exposed on, e.g. with an Ingress, to print the full webhook URL")
 	reconcileCmd.AddCommand(reconcileReceiverCmd)
 }
 
 func reconcileReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
-		return fmt.Errorf("receiver name is required")
+		return fmt.Errorf("Receiver name is required")
 	}
-	name := args[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
-	namespacedName := types.NamespacedName{
-		Namespace: rootArgs.namespace,
-		Name:      name,
-	}
-
-	var receiver notificationv1.Receiver
-	err = kubeClient.Get(ctx, namespacedName, &receiver)
+	receiver := receiverAdapter{&notificationv1.Receiver{}}
+	selected, err := selectObjects(ctx, kubeClient, receiverListAdapter{&notificationv1.ReceiverList{}}, args, objectSelection{})
 	if err != nil {
 		return err
 	}
 
-	if receiver.Spec.Suspend {
-		return fmt.Errorf("resource is suspended")
-	}
+	// receivers may share a secret, which is rotated once
+	rotated := map[types.NamespacedName]string{}
+	for _, namespacedName := range selected {
+		resetObject(receiver)
+		if reconcileReceiverArgs.rotateToken {
+			if err := kubeClient.Get(ctx, namespacedName, receiver.asClientObject()); err != nil {
+				return err
+			}
+			secretName := types.NamespacedName{
+				Namespace: namespacedName.Namespace,
+				Name:      receiver.Spec.SecretRef.Name,
+			}
+			if _, ok := rotated[secretName]; !ok {
+				token, err := setReceiverToken(ctx, kubeClient, secretName)
+				if err != nil {
+					return err
+				}
+				rotated[secretName] = token
+				logger.Successf("secret %s updated with the webhook token %s", secretName.Name, token)
+			}
+		}
 
-	logger.Actionf("annotating Receiver %s in %s namespace", name, rootArgs.namespace)
-	if receiver.Annotations == nil {
-		receiver.Annotations = map[string]string{
-			meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+		err = reconcileCommand{
+			apiType: receiverType,
+			object:  receiver,
+		}.reconcile(ctx, kubeClient, namespacedName)
+		if err != nil {
+			return err
 		}
-	} else {
-		receiver.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
-	}
-	if err := kubeClient.Update(ctx, &receiver); err != nil {
-		return err
-	}
-	logger.Successf("Receiver annotated")
 
-	logger.Waitingf("waiting for Receiver reconciliation")
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isReceiverReady(ctx, kubeClient, namespacedName, &receiver)); err != nil {
-		return err
+		if reconcileReceiverArgs.ingressHost != "" {
+			logger.Successf("webhook URL %s", receiverWebhookURL(reconcileReceiverArgs.ingressHost, receiver.Status.URL))
+		}
+	}
+	if reconcileReceiverArgs.rotateToken {
+		logger.Actionf("update the webhook URL and token on the Git provider")
 	}
-
-	logger.Successf("Receiver reconciliation completed")
-
 	return nil
 }
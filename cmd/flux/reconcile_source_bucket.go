@@ -37,9 +37,11 @@ var reconcileSourceBucketCmd = &cobra.Command{
 	Example: `  # Trigger a reconciliation for an existing source
   flux reconcile source bucket podinfo
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(bucketType),
 	RunE: reconcileCommand{
 		apiType: bucketType,
 		object:  bucketAdapter{&sourcev1.Bucket{}},
+		list:    bucketListAdapter{&sourcev1.BucketList{}},
 	}.run,
 }
 
@@ -77,5 +79,5 @@ func (obj bucketAdapter) lastHandledReconcileRequest() string {
 }
 
 func (obj bucketAdapter) successMessage() string {
-	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
+	return artifactMessage(obj.Status.Artifact)
 }
@@ -17,7 +17,6 @@ limitations under the License.
 package main
 
 import (
-	"fmt"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/spf13/cobra"
 )
@@ -29,9 +28,11 @@ var reconcileSourceGitCmd = &cobra.Command{
 	Example: `  # Trigger a git pull for an existing source
   flux reconcile source git podinfo
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(gitRepositoryType),
 	RunE: reconcileCommand{
 		apiType: gitRepositoryType,
 		object:  gitRepositoryAdapter{&sourcev1.GitRepository{}},
+		list:    gitRepositoryListAdapter{&sourcev1.GitRepositoryList{}},
 	}.run,
 }
 
@@ -44,5 +45,5 @@ func (obj gitRepositoryAdapter) lastHandledReconcileRequest() string {
 }
 
 func (obj gitRepositoryAdapter) successMessage() string {
-	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
+	return artifactMessage(obj.Status.Artifact)
 }
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// reconcileWithSource is for objects that refer to a source, which
+// can be reconciled before the object itself, e.g., with --with-source.
+type reconcileWithSource interface {
+	reconcilable
+	reconcileSource() bool
+	getSource() (reconcileCommand, types.NamespacedName, error)
+}
+
+type reconcileWithSourceCommand struct {
+	apiType
+	object reconcileWithSource
+	list   listAdapter // for expanding name patterns
+}
+
+func (reconcile reconcileWithSourceCommand) run(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s name is required", reconcile.kind)
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	selected, err := selectObjects(ctx, kubeClient, reconcile.list, args, objectSelection{})
+	if err != nil {
+		return err
+	}
+
+	// objects may share a source, which is reconciled once
+	reconciledSources := map[types.NamespacedName]bool{}
+	for _, namespacedName := range selected {
+		resetObject(reconcile.object)
+		err = kubeClient.Get(ctx, namespacedName, reconcile.object.asClientObject())
+		if err != nil {
+			return err
+		}
+
+		if reconcile.object.isSuspended() {
+			return fmt.Errorf("%s %s is suspended", reconcile.humanKind, namespacedName.Name)
+		}
+
+		if reconcile.object.reconcileSource() {
+			source, sourceName, err := reconcile.object.getSource()
+			if err != nil {
+				return err
+			}
+			if !reconciledSources[sourceName] {
+				if err := source.reconcile(ctx, kubeClient, sourceName); err != nil {
+					return err
+				}
+				reconciledSources[sourceName] = true
+			}
+		}
+
+		err = reconcileCommand{
+			apiType: reconcile.apiType,
+			object:  reconcile.object,
+		}.reconcile(ctx, kubeClient, namespacedName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -19,27 +19,50 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
-
-	"github.com/fluxcd/flux2/internal/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var resumeCmd = &cobra.Command{
 	Use:   "resume",
 	Short: "Resume suspended resources",
-	Long:  "The resume sub-commands resume a suspended resource.",
+	Long: `The resume sub-commands resume a suspended resource.
+When several objects are selected, they are resumed in layers following their dependencies, and unless
+--wait=false, each layer is ready before the objects depending on it are resumed.`,
+}
+
+type ResumeFlags struct {
+	labelSelector string
+	all           bool
+	allNamespaces bool
+	wait          bool
 }
 
+var resumeArgs ResumeFlags
+
 func init() {
+	resumeCmd.PersistentFlags().BoolVar(&resumeArgs.all, "all", false,
+		"resume all the objects of the kind in the namespace, or in all namespaces with --all-namespaces")
+	resumeCmd.PersistentFlags().BoolVarP(&resumeArgs.allNamespaces, "all-namespaces", "A", false,
+		"select the objects across all namespaces, together with --all or a label selector")
+	resumeCmd.PersistentFlags().StringVarP(&resumeArgs.labelSelector, "selector", "l", "",
+		"resume the objects matching this label selector (e.g. team=payments) instead of the named one")
+	resumeCmd.PersistentFlags().BoolVar(&resumeArgs.wait, "wait", true,
+		"wait for the resumed object(s) to be reconciled, within the timeout, before resuming the objects depending on them")
 	rootCmd.AddCommand(resumeCmd)
 }
 
 type resumable interface {
 	adapter
 	statusable
+	// these are implemented by anything embedding metav1.ObjectMeta
+	GetAnnotations() map[string]string
+	SetAnnotations(map[string]string)
 	setUnsuspended()
 	successMessage() string
 }
@@ -47,45 +70,127 @@ type resumable interface {
 type resumeCommand struct {
 	apiType
 	object resumable
+	list   listAdapter // for selecting objects by label
 }
 
 func (resume resumeCommand) run(cmd *cobra.Command, args []string) error {
-	if len(args) < 1 {
+	selection := objectSelection{
+		labelSelector: resumeArgs.labelSelector,
+		all:           resumeArgs.all,
+		allNamespaces: resumeArgs.allNamespaces,
+	}
+	if len(args) < 1 && selection.isEmpty() {
 		return fmt.Errorf("%s name is required", resume.humanKind)
 	}
-	name := args[0]
+	if err := selection.validate(args); err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
-	namespacedName := types.NamespacedName{
-		Namespace: rootArgs.namespace,
-		Name:      name,
+	// objects are resumed in layers of dependencies, each layer being
+	// ready before the objects depending on it are resumed
+	var layers [][]types.NamespacedName
+	if len(args) == 1 && selection.isEmpty() && !isNamePattern(args[0]) {
+		layers = [][]types.NamespacedName{{{Namespace: rootArgs.namespace, Name: args[0]}}}
+	} else {
+		layers, err = selectObjectLayers(ctx, kubeClient, resume.list, args, selection)
+		if err != nil {
+			return err
+		}
+	}
+	if len(layers) == 0 {
+		logger.Failuref("no %s objects found matching the selection", resume.kind)
+		return nil
 	}
 
-	err = kubeClient.Get(ctx, namespacedName, resume.object.asClientObject())
-	if err != nil {
+	for i, layer := range layers {
+		if len(layers) > 1 {
+			logger.Actionf("resuming layer %d/%d of %d %s objects", i+1, len(layers), len(layer), resume.kind)
+		}
+		for _, namespacedName := range layer {
+			if err := resume.resume(ctx, kubeClient, namespacedName); err != nil {
+				return err
+			}
+		}
+
+		if !resumeArgs.wait {
+			continue
+		}
+
+		for _, namespacedName := range layer {
+			resetObject(resume.object)
+			logger.Waitingf("waiting for %s %s reconciliation", resume.kind, namespacedName.Name)
+			// the layers share the timeout of the operation, each poll
+			// lasting until its deadline at most
+			err := wait.PollImmediate(rootArgs.pollInterval, timeoutLeft(ctx),
+				isReady(ctx, kubeClient, namespacedName, resume.object))
+			if err != nil && ctx.Err() == context.DeadlineExceeded {
+				err = wait.ErrWaitTimeout
+			}
+			if err != nil {
+				if left := countLayers(layers[i+1:]); left > 0 {
+					return fmt.Errorf("%s %s: %w, %d %s objects of the layers after %d/%d left suspended",
+						resume.kind, namespacedName.Name, err, left, resume.kind, i+1, len(layers))
+				}
+				return err
+			}
+			logger.Successf("%s reconciliation completed", resume.kind)
+			logger.Successf("%s", resume.object.successMessage())
+		}
+		if len(layers) > 1 {
+			logger.Successf("layer %d/%d ready", i+1, len(layers))
+		}
+	}
+	return nil
+}
+
+// resume unsuspends an object and requests its reconciliation.
+func (resume resumeCommand) resume(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName) error {
+	resetObject(resume.object)
+	if err := kubeClient.Get(ctx, namespacedName, resume.object.asClientObject()); err != nil {
 		return err
 	}
 
-	logger.Actionf("resuming %s %s in %s namespace", resume.humanKind, name, rootArgs.namespace)
+	logger.Actionf("resuming %s %s in %s namespace", resume.humanKind, namespacedName.Name, namespacedName.Namespace)
+	patch := client.MergeFrom(resume.object.asClientObject().DeepCopyObject().(client.Object))
 	resume.object.setUnsuspended()
-	if err := kubeClient.Update(ctx, resume.object.asClientObject()); err != nil {
+	// request a reconciliation as well, so the controller picks the
+	// object up right away instead of at the next interval
+	ann := resume.object.GetAnnotations()
+	if ann == nil {
+		ann = map[string]string{}
+	}
+	ann[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+	resume.object.SetAnnotations(ann)
+	if err := kubeClient.Patch(ctx, resume.object.asClientObject(), patch); err != nil {
 		return err
 	}
 	logger.Successf("%s resumed", resume.humanKind)
+	return nil
+}
 
-	logger.Waitingf("waiting for %s reconciliation", resume.kind)
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isReady(ctx, kubeClient, namespacedName, resume.object)); err != nil {
-		return err
+// countLayers returns the number of objects in the given layers.
+func countLayers(layers [][]types.NamespacedName) int {
+	count := 0
+	for _, layer := range layers {
+		count += len(layer)
 	}
-	logger.Successf("%s reconciliation completed", resume.kind)
-	logger.Successf(resume.object.successMessage())
-	return nil
+	return count
+}
+
+// timeoutLeft returns the time left before the deadline of the
+// operation, or the whole timeout when the context has no deadline.
+func timeoutLeft(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return rootArgs.timeout
 }
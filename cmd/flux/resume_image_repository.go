@@ -29,9 +29,11 @@ var resumeImageRepositoryCmd = &cobra.Command{
 	Example: `  # Resume reconciliation for an existing ImageRepository
   flux resume image repository alpine
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageRepositoryType),
 	RunE: resumeCommand{
 		apiType: imageRepositoryType,
 		object:  imageRepositoryAdapter{&imagev1.ImageRepository{}},
+		list:    imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
 	}.run,
 }
 
@@ -29,9 +29,11 @@ var resumeImageUpdateCmd = &cobra.Command{
 	Example: `  # Resume reconciliation for an existing ImageUpdateAutomation
   flux resume image update latest-images
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageUpdateAutomationType),
 	RunE: resumeCommand{
 		apiType: imageUpdateAutomationType,
 		object:  imageUpdateAutomationAdapter{&autov1.ImageUpdateAutomation{}},
+		list:    imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
 	}.run,
 }
 
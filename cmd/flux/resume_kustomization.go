@@ -31,10 +31,22 @@ var resumeKsCmd = &cobra.Command{
 finish the apply.`,
 	Example: `  # Resume reconciliation for an existing Kustomization
   flux resume ks podinfo
+
+  # Resume reconciliation for all the Kustomizations labeled team=payments
+  flux resume ks -l team=payments
+
+  # Resume reconciliation for an existing Kustomization without waiting for it
+  flux resume ks podinfo --wait=false
+
+  # Resume reconciliation for all the Kustomizations in the cluster, in dependency order,
+  # each layer of dependencies being ready before the Kustomizations depending on it are resumed
+  flux resume ks --all --all-namespaces
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizationType),
 	RunE: resumeCommand{
 		apiType: kustomizationType,
 		object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
+		list:    kustomizationListAdapter{&kustomizev1.KustomizationList{}},
 	}.run,
 }
 
@@ -17,8 +17,6 @@ limitations under the License.
 package main
 
 import (
-	"fmt"
-
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/spf13/cobra"
 )
@@ -30,9 +28,11 @@ var resumeSourceHelmChartCmd = &cobra.Command{
 	Example: `  # Resume reconciliation for an existing HelmChart
   flux resume source chart podinfo
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(helmChartType),
 	RunE: resumeCommand{
 		apiType: helmChartType,
 		object:  &helmChartAdapter{&sourcev1.HelmChart{}},
+		list:    helmChartListAdapter{&sourcev1.HelmChartList{}},
 	}.run,
 }
 
@@ -49,5 +49,5 @@ func (obj helmChartAdapter) setUnsuspended() {
 }
 
 func (obj helmChartAdapter) successMessage() string {
-	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
+	return artifactMessage(obj.Status.Artifact)
 }
@@ -0,0 +1,272 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// objectSelection is how the objects an operation applies to can be
+// selected, other than by giving their names.
+type objectSelection struct {
+	labelSelector string
+	all           bool
+	allNamespaces bool
+}
+
+func (sel objectSelection) isEmpty() bool {
+	return sel.labelSelector == "" && !sel.all
+}
+
+// validate makes sure the selection can be used with the given names,
+// --all-namespaces only widening --all and the label selector.
+func (sel objectSelection) validate(args []string) error {
+	if !sel.isEmpty() && len(args) > 0 {
+		return fmt.Errorf("names cannot be given together with --all or a label selector")
+	}
+	if sel.allNamespaces && sel.isEmpty() {
+		return fmt.Errorf("--all-namespaces can only be given together with --all or a label selector")
+	}
+	return nil
+}
+
+// isNamePattern tells whether an argument is a shell-style pattern of
+// names, e.g. apps-*, rather than a name.
+func isNamePattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// hasNamePattern tells whether one of the arguments is a pattern.
+func hasNamePattern(args []string) bool {
+	for _, arg := range args {
+		if isNamePattern(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchNames returns whether a name is one of the given names, or
+// matches one of the given patterns.
+func matchNames(name string, args []string) (bool, error) {
+	for _, arg := range args {
+		if !isNamePattern(arg) {
+			if arg == name {
+				return true, nil
+			}
+			continue
+		}
+		matched, err := path.Match(arg, name)
+		if err != nil {
+			return false, fmt.Errorf("invalid name pattern '%s': %w", arg, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// selectObjects returns the objects an operation applies to: either
+// the ones named in the arguments, or the ones matching the selection
+// in the namespace scope of the operation. Selected objects are
+// ordered so that they come after the objects they depend on.
+func selectObjects(ctx context.Context, kubeClient client.Client, list listAdapter,
+	args []string, sel objectSelection) ([]types.NamespacedName, error) {
+	if sel.isEmpty() && !hasNamePattern(args) {
+		var selected []types.NamespacedName
+		for _, name := range args {
+			selected = append(selected, types.NamespacedName{Namespace: rootArgs.namespace, Name: name})
+		}
+		return selected, nil
+	}
+
+	layers, err := selectObjectLayers(ctx, kubeClient, list, args, sel)
+	if err != nil {
+		return nil, err
+	}
+	var selected []types.NamespacedName
+	for _, layer := range layers {
+		selected = append(selected, layer...)
+	}
+	return selected, nil
+}
+
+// selectObjectLayers returns the objects an operation applies to, like
+// selectObjects, grouped in layers of objects depending only on the
+// objects of the previous layers. The named objects that are not found
+// make up the last layer, so that they are reported by the operation.
+func selectObjectLayers(ctx context.Context, kubeClient client.Client, list listAdapter,
+	args []string, sel objectSelection) ([][]types.NamespacedName, error) {
+	if err := sel.validate(args); err != nil {
+		return nil, err
+	}
+
+	var listOpts []client.ListOption
+	if sel.isEmpty() || !sel.allNamespaces {
+		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
+	}
+	if sel.labelSelector != "" {
+		selector, err := labels.Parse(sel.labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector '%s': %w", sel.labelSelector, err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: selector})
+	}
+	if err := kubeClient.List(ctx, list.asClientList(), listOpts...); err != nil {
+		return nil, err
+	}
+	items, err := apimeta.ExtractList(list.asClientList())
+	if err != nil {
+		return nil, err
+	}
+	if !sel.isEmpty() {
+		return dependencyLayers(items)
+	}
+
+	var matched []runtime.Object
+	found := map[string]bool{}
+	for _, item := range items {
+		obj, err := apimeta.Accessor(item)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := matchNames(obj.GetName(), args)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, item)
+			found[obj.GetName()] = true
+		}
+	}
+	for _, arg := range args {
+		if !isNamePattern(arg) {
+			continue
+		}
+		ok := false
+		for name := range found {
+			if ok, _ = matchNames(name, []string{arg}); ok {
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("no objects matching '%s' found in %s namespace", arg, rootArgs.namespace)
+		}
+	}
+
+	layers, err := dependencyLayers(matched)
+	if err != nil {
+		return nil, err
+	}
+	var missing []types.NamespacedName
+	for _, arg := range args {
+		if !isNamePattern(arg) && !found[arg] {
+			missing = append(missing, types.NamespacedName{Namespace: rootArgs.namespace, Name: arg})
+		}
+	}
+	if len(missing) > 0 {
+		layers = append(layers, missing)
+	}
+	return layers, nil
+}
+
+// dependencyLayers groups the given objects in layers, so that each
+// object comes in a layer after the ones listed in its spec.dependsOn,
+// if any, while keeping the order of the list within a layer.
+// Dependencies outside of the given objects are ignored, and so are
+// cycles, which the controllers report on: the objects of a cycle, and
+// the ones depending on them, make up the last layer.
+func dependencyLayers(items []runtime.Object) ([][]types.NamespacedName, error) {
+	var names []types.NamespacedName
+	dependsOn := map[types.NamespacedName][]types.NamespacedName{}
+	for _, item := range items {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+		obj := unstructured.Unstructured{Object: u}
+		name := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+		names = append(names, name)
+
+		deps, _, err := unstructured.NestedSlice(u, "spec", "dependsOn")
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			ref, ok := dep.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			depName := types.NamespacedName{Namespace: name.Namespace}
+			depName.Name, _, _ = unstructured.NestedString(ref, "name")
+			if ns, _, _ := unstructured.NestedString(ref, "namespace"); ns != "" {
+				depName.Namespace = ns
+			}
+			dependsOn[name] = append(dependsOn[name], depName)
+		}
+	}
+
+	selected := map[types.NamespacedName]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+	var layers [][]types.NamespacedName
+	done := map[types.NamespacedName]bool{}
+	for count := 0; count < len(names); {
+		var layer []types.NamespacedName
+		for _, name := range names {
+			if done[name] {
+				continue
+			}
+			ready := true
+			for _, dep := range dependsOn[name] {
+				if selected[dep] && !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				layer = append(layer, name)
+			}
+		}
+		if len(layer) == 0 {
+			// a cycle; keep the list order for the rest
+			for _, name := range names {
+				if !done[name] {
+					layer = append(layer, name)
+				}
+			}
+		}
+		for _, name := range layer {
+			done[name] = true
+		}
+		count += len(layer)
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
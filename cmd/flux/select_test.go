@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/runtime/dependency"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func testKustomization(namespace, name string, labels map[string]string, dependsOn ...string) *kustomizev1.Kustomization {
+	ks := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+	}
+	for _, dep := range dependsOn {
+		ks.Spec.DependsOn = append(ks.Spec.DependsOn, dependency.CrossNamespaceDependencyReference{Name: dep})
+	}
+	return ks
+}
+
+func TestDependencyLayers(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []runtime.Object
+		want  [][]string
+	}{
+		{
+			name: "independent objects keep the list order",
+			items: []runtime.Object{
+				testKustomization("flux-system", "b", nil),
+				testKustomization("flux-system", "a", nil),
+			},
+			want: [][]string{{"b", "a"}},
+		},
+		{
+			name: "objects come after their dependencies",
+			items: []runtime.Object{
+				testKustomization("flux-system", "apps", nil, "infra"),
+				testKustomization("flux-system", "infra", nil, "crds"),
+				testKustomization("flux-system", "crds", nil),
+				testKustomization("flux-system", "monitoring", nil, "crds"),
+			},
+			want: [][]string{{"crds"}, {"infra", "monitoring"}, {"apps"}},
+		},
+		{
+			name: "dependencies outside of the objects are ignored",
+			items: []runtime.Object{
+				testKustomization("flux-system", "apps", nil, "infra"),
+			},
+			want: [][]string{{"apps"}},
+		},
+		{
+			name: "cycles make up the last layer",
+			items: []runtime.Object{
+				testKustomization("flux-system", "a", nil, "b"),
+				testKustomization("flux-system", "b", nil, "a"),
+				testKustomization("flux-system", "c", nil, "a"),
+				testKustomization("flux-system", "d", nil),
+			},
+			want: [][]string{{"d"}, {"a", "b", "c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers, err := dependencyLayers(tt.items)
+			if err != nil {
+				t.Fatalf("dependencyLayers() error = %v", err)
+			}
+			if got := layerNames(layers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dependencyLayers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectObjectLayers(t *testing.T) {
+	kubeClient := fake.NewClientBuilder().WithScheme(utils.NewScheme()).WithObjects(
+		testKustomization("flux-system", "apps", map[string]string{"tier": "apps"}, "infra"),
+		testKustomization("flux-system", "infra", map[string]string{"tier": "infra"}),
+		testKustomization("flux-system", "apps-staging", map[string]string{"tier": "apps"}, "infra"),
+		testKustomization("team-a", "apps", map[string]string{"tier": "apps"}),
+	).Build()
+
+	namespace := rootArgs.namespace
+	rootArgs.namespace = "flux-system"
+	defer func() { rootArgs.namespace = namespace }()
+
+	tests := []struct {
+		name    string
+		args    []string
+		sel     objectSelection
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name: "names in dependency order, missing names last",
+			args: []string{"apps", "missing", "infra"},
+			want: [][]string{{"infra"}, {"apps"}, {"missing"}},
+		},
+		{
+			name: "name patterns",
+			args: []string{"apps*"},
+			want: [][]string{{"apps", "apps-staging"}},
+		},
+		{
+			name:    "pattern matching nothing",
+			args:    []string{"web*"},
+			wantErr: true,
+		},
+		{
+			name: "all objects of the namespace",
+			sel:  objectSelection{all: true},
+			want: [][]string{{"infra"}, {"apps", "apps-staging"}},
+		},
+		{
+			name: "label selector across namespaces",
+			sel:  objectSelection{labelSelector: "tier=apps", allNamespaces: true},
+			want: [][]string{{"apps", "apps-staging", "team-a/apps"}},
+		},
+		{
+			name:    "names with a selection",
+			args:    []string{"apps"},
+			sel:     objectSelection{all: true},
+			wantErr: true,
+		},
+		{
+			name:    "all namespaces without a selection",
+			args:    []string{"apps"},
+			sel:     objectSelection{allNamespaces: true},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers, err := selectObjectLayers(context.TODO(), kubeClient,
+				kustomizationListAdapter{&kustomizev1.KustomizationList{}}, tt.args, tt.sel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectObjectLayers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := layerNames(layers); !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectObjectLayers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// layerNames returns the names of the objects of the layers, qualified
+// by their namespace when it isn't flux-system.
+func layerNames(layers [][]types.NamespacedName) [][]string {
+	var names [][]string
+	for _, layer := range layers {
+		var layerNames []string
+		for _, name := range layer {
+			if name.Namespace == "flux-system" {
+				layerNames = append(layerNames, name.Name)
+				continue
+			}
+			layerNames = append(layerNames, name.String())
+		}
+		names = append(names, layerNames)
+	}
+	return names
+}
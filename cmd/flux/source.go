@@ -17,6 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
+
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
@@ -141,3 +143,12 @@ func (a helmRepositoryListAdapter) asClientList() client.ObjectList {
 func (a helmRepositoryListAdapter) len() int {
 	return len(a.HelmRepositoryList.Items)
 }
+
+// artifactMessage summarises the artifact of a source, for telling
+// users what was fetched.
+func artifactMessage(artifact *sourcev1.Artifact) string {
+	if artifact == nil {
+		return "no artifact fetched yet"
+	}
+	return fmt.Sprintf("fetched revision %s with checksum %s", artifact.Revision, artifact.Checksum)
+}
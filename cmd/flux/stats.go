@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1alpha1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Print the reconciliation statistics of the toolkit objects",
+	Long: `The stats command prints, for each kind of toolkit object, how many objects there are, and how many
+of them are ready, failing or suspended. Kinds whose controller is not installed are left out.`,
+	Example: `  # Print the statistics of the objects in the flux-system namespace
+  flux stats
+
+  # Print the statistics of the objects in all namespaces
+  flux stats --all-namespaces
+`,
+	Args: cobra.NoArgs,
+	RunE: statsCmdRun,
+}
+
+type statsFlags struct {
+	allNamespaces bool
+}
+
+var statsArgs statsFlags
+
+func init() {
+	statsCmd.Flags().BoolVarP(&statsArgs.allNamespaces, "all-namespaces", "A", false,
+		"count the objects in all namespaces")
+
+	rootCmd.AddCommand(statsCmd)
+}
+
+func statsCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	var listOpts []client.ListOption
+	if !statsArgs.allNamespaces {
+		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
+	}
+
+	commands := append(allSourceGetCommands(),
+		getCommand{
+			apiType: kustomizationType,
+			list:    &kustomizationListAdapter{&kustomizev1.KustomizationList{}},
+		},
+		getCommand{
+			apiType: helmReleaseType,
+			list:    &helmReleaseListAdapter{&helmv2.HelmReleaseList{}},
+		},
+		getCommand{
+			apiType: alertType,
+			list:    &alertListAdapter{&notificationv1.AlertList{}},
+		},
+		getCommand{
+			apiType: alertProviderType,
+			list:    &alertProviderListAdapter{&notificationv1.ProviderList{}},
+		},
+		getCommand{
+			apiType: receiverType,
+			list:    &receiverListAdapter{&notificationv1.ReceiverList{}},
+		},
+		getCommand{
+			apiType: imageRepositoryType,
+			list:    imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
+		},
+		getCommand{
+			apiType: imagePolicyType,
+			list:    &imagePolicyListAdapter{&imagev1.ImagePolicyList{}},
+		},
+		getCommand{
+			apiType: imageUpdateAutomationType,
+			list:    &imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
+		},
+	)
+
+	header := []string{"Kind", "Total", "Ready", "Failing", "Suspended"}
+	var rows [][]string
+	for _, get := range commands {
+		if err := kubeClient.List(ctx, get.list.asClientList(), listOpts...); err != nil {
+			if apimeta.IsNoMatchError(err) {
+				// the CRD is not installed, e.g. an optional component
+				continue
+			}
+			return err
+		}
+		items, err := apimeta.ExtractList(get.list.asClientList())
+		if err != nil {
+			return err
+		}
+
+		var ready, failing, suspended int
+		for _, item := range items {
+			summary, err := summariseStatus(item)
+			if err != nil {
+				return err
+			}
+			switch {
+			case summary.isSuspended():
+				suspended++
+			case summary.isReady():
+				ready++
+			default:
+				failing++
+			}
+		}
+		rows = append(rows, []string{
+			get.kind,
+			strconv.Itoa(len(items)),
+			strconv.Itoa(ready),
+			strconv.Itoa(failing),
+			strconv.Itoa(suspended),
+		})
+	}
+	utils.PrintTable(os.Stdout, header, rows)
+	return nil
+}
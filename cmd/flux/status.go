@@ -24,21 +24,13 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
-	"sigs.k8s.io/cli-utils/pkg/kstatus/polling"
-	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/aggregator"
-	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/collector"
-	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
-	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
-	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
 	"github.com/fluxcd/pkg/apis/meta"
 
-	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/readiness"
 )
 
 // statusable is used to see if a resource is considered ready in the usual way
@@ -54,10 +46,14 @@ type statusable interface {
 type StatusChecker struct {
 	pollInterval time.Duration
 	timeout      time.Duration
+	concurrency  int
 	client       client.Client
-	statusPoller *polling.StatusPoller
 }
 
+// defaultConcurrency is the number of readiness checks run at once,
+// unless set with --concurrency.
+const defaultConcurrency = 4
+
 func isReady(ctx context.Context, kubeClient client.Client,
 	namespacedName types.NamespacedName, object statusable) wait.ConditionFunc {
 	return func() (bool, error) {
@@ -76,6 +72,11 @@ func isReady(ctx context.Context, kubeClient client.Client,
 			case metav1.ConditionTrue:
 				return true, nil
 			case metav1.ConditionFalse:
+				// the condition of a resumed object without observed
+				// generation may still be the one set when suspended
+				if c.Reason == meta.SuspendedReason {
+					return false, nil
+				}
 				return false, fmt.Errorf(c.Message)
 			}
 		}
@@ -83,16 +84,8 @@ func isReady(ctx context.Context, kubeClient client.Client,
 	}
 }
 
-func NewStatusChecker(pollInterval time.Duration, timeout time.Duration) (*StatusChecker, error) {
-	kubeConfig, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext)
-	if err != nil {
-		return nil, err
-	}
-	restMapper, err := apiutil.NewDynamicRESTMapper(kubeConfig)
-	if err != nil {
-		return nil, err
-	}
-	client, err := client.New(kubeConfig, client.Options{Mapper: restMapper})
+func NewStatusChecker(pollInterval time.Duration, timeout time.Duration, concurrency int) (*StatusChecker, error) {
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return nil, err
 	}
@@ -100,79 +93,54 @@ func NewStatusChecker(pollInterval time.Duration, timeout time.Duration) (*Statu
 	return &StatusChecker{
 		pollInterval: pollInterval,
 		timeout:      timeout,
-		client:       client,
-		statusPoller: polling.NewStatusPoller(client, restMapper),
+		concurrency:  concurrency,
+		client:       kubeClient,
 	}, nil
 }
 
+// Assess waits for the deployments of the components to be rolled
+// out, checking them concurrently, and logs the ones that are not.
 func (sc *StatusChecker) Assess(components ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), sc.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, sc.timeout)
 	defer cancel()
 
-	objRefs, err := sc.getObjectRefs(components)
-	if err != nil {
-		return err
+	checks := make([]readiness.Check, 0, len(components))
+	for _, component := range components {
+		namespacedName := types.NamespacedName{
+			Namespace: rootArgs.namespace,
+			Name:      component,
+		}
+		checks = append(checks, readiness.Check{
+			Name:      component,
+			Condition: readiness.DeploymentReady(ctx, sc.client, namespacedName),
+		})
 	}
 
-	opts := polling.Options{PollInterval: sc.pollInterval, UseCache: true}
-	eventsChan := sc.statusPoller.Poll(ctx, objRefs, opts)
-
-	coll := collector.NewResourceStatusCollector(objRefs)
-	done := coll.ListenWithObserver(eventsChan, collector.ObserverFunc(
-		func(statusCollector *collector.ResourceStatusCollector, e event.Event) {
-			var rss []*event.ResourceStatus
-			for _, rs := range statusCollector.ResourceStatuses {
-				rss = append(rss, rs)
-			}
-			desired := status.CurrentStatus
-			aggStatus := aggregator.AggregateStatus(rss, desired)
-			if aggStatus == desired {
-				cancel()
-				return
-			}
-		}),
-	)
-	<-done
-
-	if coll.Error != nil || ctx.Err() == context.DeadlineExceeded {
-		for _, rs := range coll.ResourceStatuses {
-			if rs.Status != status.CurrentStatus {
-				if !sc.deploymentExists(rs.Identifier) {
-					logger.Failuref("%s: deployment not found", rs.Identifier.Name)
-				} else {
-					logger.Failuref("%s: unhealthy (timed out waiting for rollout)", rs.Identifier.Name)
-				}
-			}
+	failed := false
+	for i, err := range readiness.Wait(sc.pollInterval, sc.timeout, sc.concurrency, checks) {
+		if err == nil {
+			continue
+		}
+		failed = true
+		if !sc.deploymentExists(checks[i].Name) {
+			logger.Failuref("%s: deployment not found", checks[i].Name)
+		} else {
+			logger.Failuref("%s: unhealthy (timed out waiting for rollout)", checks[i].Name)
 		}
+	}
+	if failed {
 		return fmt.Errorf("timed out waiting for condition")
 	}
-
 	return nil
 }
 
-func (sc *StatusChecker) getObjectRefs(components []string) ([]object.ObjMetadata, error) {
-	var objRefs []object.ObjMetadata
-	for _, deployment := range components {
-		objMeta, err := object.CreateObjMetadata(rootArgs.namespace, deployment, schema.GroupKind{Group: "apps", Kind: "Deployment"})
-		if err != nil {
-			return nil, err
-		}
-		objRefs = append(objRefs, objMeta)
-	}
-	return objRefs, nil
-}
-
-func (sc *StatusChecker) objMetadataToString(om object.ObjMetadata) string {
-	return fmt.Sprintf("%s '%s/%s'", om.GroupKind.Kind, om.Namespace, om.Name)
-}
-
-func (sc *StatusChecker) deploymentExists(om object.ObjMetadata) bool {
-	ctx, cancel := context.WithTimeout(context.Background(), sc.timeout)
+func (sc *StatusChecker) deploymentExists(name string) bool {
+	ctx, cancel := context.WithTimeout(rootCtx, sc.timeout)
 	defer cancel()
 
 	namespacedName := types.NamespacedName{
-		Namespace: om.Namespace,
-		Name:      om.Name,
+		Namespace: rootArgs.namespace,
+		Name:      name,
 	}
 	var existing appsv1.Deployment
 	err := sc.client.Get(ctx, namespacedName, &existing)
@@ -21,9 +21,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/types"
-
-	"github.com/fluxcd/flux2/internal/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var suspendCmd = &cobra.Command{
@@ -32,7 +30,21 @@ var suspendCmd = &cobra.Command{
 	Long:  "The suspend sub-commands suspend the reconciliation of a resource.",
 }
 
+type SuspendFlags struct {
+	labelSelector string
+	all           bool
+	allNamespaces bool
+}
+
+var suspendArgs SuspendFlags
+
 func init() {
+	suspendCmd.PersistentFlags().BoolVar(&suspendArgs.all, "all", false,
+		"suspend all the objects of the kind in the namespace, or in all namespaces with --all-namespaces")
+	suspendCmd.PersistentFlags().BoolVarP(&suspendArgs.allNamespaces, "all-namespaces", "A", false,
+		"select the objects across all namespaces, together with --all or a label selector")
+	suspendCmd.PersistentFlags().StringVarP(&suspendArgs.labelSelector, "selector", "l", "",
+		"suspend the objects matching this label selector (e.g. team=payments) instead of the named one")
 	rootCmd.AddCommand(suspendCmd)
 }
 
@@ -42,40 +54,77 @@ type suspendable interface {
 	setSuspended()
 }
 
+// suspensionWarner is for adapters of objects that can be in a state
+// in which suspending them has consequences users need to know about.
+type suspensionWarner interface {
+	suspensionWarning() string
+}
+
 type suspendCommand struct {
 	apiType
 	object suspendable
+	list   listAdapter // for selecting objects by label
 }
 
 func (suspend suspendCommand) run(cmd *cobra.Command, args []string) error {
-	if len(args) < 1 {
+	selection := objectSelection{
+		labelSelector: suspendArgs.labelSelector,
+		all:           suspendArgs.all,
+		allNamespaces: suspendArgs.allNamespaces,
+	}
+	if len(args) < 1 && selection.isEmpty() {
 		return fmt.Errorf("%s name is required", suspend.humanKind)
 	}
-	name := args[0]
+	if err := selection.validate(args); err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
 
-	namespacedName := types.NamespacedName{
-		Namespace: rootArgs.namespace,
-		Name:      name,
-	}
-	err = kubeClient.Get(ctx, namespacedName, suspend.object.asClientObject())
+	selected, err := selectObjects(ctx, kubeClient, suspend.list, args, selection)
 	if err != nil {
 		return err
 	}
+	if len(selected) == 0 {
+		logger.Failuref("no %s objects found matching the selection", suspend.kind)
+		return nil
+	}
 
-	logger.Actionf("suspending %s %s in %s namespace", suspend.humanKind, name, rootArgs.namespace)
-	suspend.object.setSuspended()
-	if err := kubeClient.Update(ctx, suspend.object.asClientObject()); err != nil {
-		return err
+	for _, namespacedName := range selected {
+		name, namespace := namespacedName.Name, namespacedName.Namespace
+		resetObject(suspend.object)
+		err = kubeClient.Get(ctx, namespacedName, suspend.object.asClientObject())
+		if err != nil {
+			return err
+		}
+
+		if suspend.object.isSuspended() {
+			logger.Successf("%s %s is already suspended", suspend.humanKind, name)
+			continue
+		}
+
+		if warner, ok := suspend.object.(suspensionWarner); ok {
+			if warning := warner.suspensionWarning(); warning != "" {
+				logger.Warningf("%s", warning)
+			}
+		}
+
+		logger.Actionf("suspending %s %s in %s namespace", suspend.humanKind, name, namespace)
+		// patch rather than update, so that changes made in the meantime
+		// by the controller or by other users are not overwritten
+		patch := client.MergeFrom(suspend.object.asClientObject().DeepCopyObject().(client.Object))
+		suspend.object.setSuspended()
+		if err := kubeClient.Patch(ctx, suspend.object.asClientObject(), patch); err != nil {
+			return err
+		}
+		logger.Successf("%s suspended", suspend.humanKind)
 	}
-	logger.Successf("%s suspended", suspend.humanKind)
 
 	return nil
 }
@@ -18,7 +18,9 @@ package main
 
 import (
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 )
 
 var suspendHrCmd = &cobra.Command{
@@ -29,9 +31,11 @@ var suspendHrCmd = &cobra.Command{
 	Example: `  # Suspend reconciliation for an existing Helm release
   flux suspend hr podinfo
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(helmReleaseType),
 	RunE: suspendCommand{
 		apiType: helmReleaseType,
 		object:  &helmReleaseAdapter{&helmv2.HelmRelease{}},
+		list:    helmReleaseListAdapter{&helmv2.HelmReleaseList{}},
 	}.run,
 }
 
@@ -46,3 +50,11 @@ func (obj helmReleaseAdapter) isSuspended() bool {
 func (obj helmReleaseAdapter) setSuspended() {
 	obj.HelmRelease.Spec.Suspend = true
 }
+
+func (obj helmReleaseAdapter) suspensionWarning() string {
+	c := apimeta.FindStatusCondition(obj.HelmRelease.Status.Conditions, meta.ReadyCondition)
+	if c == nil || c.Reason != meta.ProgressingReason {
+		return ""
+	}
+	return "the HelmRelease is being reconciled, an upgrade in progress will complete but failures will not be remediated until it is resumed"
+}
@@ -29,9 +29,11 @@ var suspendImageRepositoryCmd = &cobra.Command{
 	Example: `  # Suspend reconciliation for an existing ImageRepository
   flux suspend image repository alpine
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageRepositoryType),
 	RunE: suspendCommand{
 		apiType: imageRepositoryType,
 		object:  imageRepositoryAdapter{&imagev1.ImageRepository{}},
+		list:    imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
 	}.run,
 }
 
@@ -29,9 +29,11 @@ var suspendImageUpdateCmd = &cobra.Command{
 	Example: `  # Suspend reconciliation for an existing ImageUpdateAutomation
   flux suspend image update latest-images
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(imageUpdateAutomationType),
 	RunE: suspendCommand{
 		apiType: imageUpdateAutomationType,
 		object:  imageUpdateAutomationAdapter{&autov1.ImageUpdateAutomation{}},
+		list:    imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
 	}.run,
 }
 
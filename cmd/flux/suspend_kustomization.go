@@ -28,10 +28,21 @@ var suspendKsCmd = &cobra.Command{
 	Long:    "The suspend command disables the reconciliation of a Kustomization resource.",
 	Example: `  # Suspend reconciliation for an existing Kustomization
   flux suspend ks podinfo
+
+  # Suspend reconciliation for all the Kustomizations labeled team=payments
+  flux suspend ks -l team=payments
+
+  # Suspend reconciliation for all the Kustomizations in the cluster
+  flux suspend ks --all --all-namespaces
+
+  # Suspend reconciliation for several Kustomizations
+  flux suspend ks podinfo 'apps-*'
 `,
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizationType),
 	RunE: suspendCommand{
 		apiType: kustomizationType,
 		object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
+		list:    kustomizationListAdapter{&kustomizev1.KustomizationList{}},
 	}.run,
 }
 
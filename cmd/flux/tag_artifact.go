@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/pkg/oci"
+)
+
+var tagArtifactCmd = &cobra.Command{
+	Use:   "artifact [oci-url]",
+	Short: "Tag an artifact",
+	Long: `The tag artifact command adds a tag to an artifact, in the same repository, without pulling it.
+This promotes a tested artifact to the tag that the OCIRepository sources of other clusters track.
+The credentials of the registry are read from the Docker configuration, as written by docker login.`,
+	Example: `  # Promote the artifact tagged v1 to the stable tag
+  flux tag artifact oci://ghcr.io/org/manifests/podinfo:v1 --tag=stable
+
+  # Promote an artifact by digest
+  flux tag artifact oci://ghcr.io/org/manifests/podinfo@sha256:<hash> --tag=stable
+`,
+	RunE: tagArtifactCmdRun,
+}
+
+type tagArtifactFlags struct {
+	tags []string
+}
+
+var tagArtifactArgs tagArtifactFlags
+
+func init() {
+	tagArtifactCmd.Flags().StringSliceVar(&tagArtifactArgs.tags, "tag", nil, "the tags to add to the artifact, accepts comma-separated values")
+
+	tagCmd.AddCommand(tagArtifactCmd)
+}
+
+func tagArtifactCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("artifact URL is required")
+	}
+	url := args[0]
+
+	if len(tagArtifactArgs.tags) == 0 {
+		return fmt.Errorf("at least one tag is required")
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	for _, tag := range tagArtifactArgs.tags {
+		logger.Actionf("tagging artifact %s with %s", url, tag)
+		tagged, err := oci.Tag(ctx, url, tag)
+		if err != nil {
+			return err
+		}
+		logger.Successf("artifact tagged as %s", tagged)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+)
+
+var testAlertCmd = &cobra.Command{
+	Use:   "alert [name]",
+	Short: "Send a test event through an Alert",
+	Long: `The test alert command sends a synthetic event matching the first event source of an Alert
+to the notification-controller, and watches the controller logs for the outcome of its delivery
+to the Provider of the Alert. It fails with the error of the Provider, e.g. the HTTP status
+returned by Slack, when the delivery fails.`,
+	Example: `  # Send a test event through the Alert named slack
+  flux test alert slack
+
+  # Wait longer for the delivery errors of a slow Provider
+  flux test alert msteams --delivery-timeout=1m
+`,
+	ValidArgsFunction: resourceNamesCompletionFunc(alertType),
+	RunE:              testAlertCmdRun,
+}
+
+type testAlertFlags struct {
+	fluxNamespace   string
+	deliveryTimeout time.Duration
+}
+
+var testAlertArgs testAlertFlags
+
+func init() {
+	testAlertCmd.Flags().StringVar(&testAlertArgs.fluxNamespace, "flux-namespace", rootArgs.defaults.Namespace,
+		"the namespace where the notification-controller is installed")
+	testAlertCmd.Flags().DurationVar(&testAlertArgs.deliveryTimeout, "delivery-timeout", 15*time.Second,
+		"how long to watch the notification-controller logs for delivery errors")
+
+	testCmd.AddCommand(testAlertCmd)
+}
+
+// testEvent is the payload the notification-controller accepts on its
+// events endpoint.
+type testEvent struct {
+	InvolvedObject      corev1.ObjectReference `json:"involvedObject"`
+	Severity            string                 `json:"severity"`
+	Timestamp           metav1.Time            `json:"timestamp"`
+	Message             string                 `json:"message"`
+	Reason              string                 `json:"reason"`
+	ReportingController string                 `json:"reportingController"`
+}
+
+func testAlertCmdRun(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("alert name is required")
+	}
+	name := args[0]
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	var alert notificationv1.Alert
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: rootArgs.namespace, Name: name}, &alert); err != nil {
+		return err
+	}
+	if alert.Spec.Suspend {
+		return fmt.Errorf("alert %s is suspended, resume it before testing it", name)
+	}
+	if len(alert.Spec.EventSources) == 0 {
+		return fmt.Errorf("alert %s has no event sources", name)
+	}
+
+	var provider notificationv1.Provider
+	providerName := types.NamespacedName{Namespace: rootArgs.namespace, Name: alert.Spec.ProviderRef.Name}
+	if err := kubeClient.Get(ctx, providerName, &provider); err != nil {
+		return fmt.Errorf("provider %s of alert %s: %w", alert.Spec.ProviderRef.Name, name, err)
+	}
+	if !apimeta.IsStatusConditionTrue(provider.Status.Conditions, meta.ReadyCondition) {
+		logger.Warningf("provider %s is not ready", provider.Name)
+	}
+
+	source := alert.Spec.EventSources[0]
+	event := testEvent{
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      source.Kind,
+			Name:      source.Name,
+			Namespace: source.Namespace,
+		},
+		Severity:            "info",
+		Timestamp:           metav1.Now(),
+		Message:             fmt.Sprintf("Test event sent by flux test alert %s", name),
+		Reason:              "AlertTest",
+		ReportingController: "flux",
+	}
+	// a wildcard source matches the events of any object of the kind
+	if event.InvolvedObject.Name == "*" {
+		event.InvolvedObject.Name = "flux-alert-test"
+	}
+	if event.InvolvedObject.Namespace == "" {
+		event.InvolvedObject.Namespace = alert.Namespace
+	}
+	if alert.Spec.EventSeverity == "error" {
+		event.Severity = "error"
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := getKubeConfig()
+	if err != nil {
+		return err
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	logger.Actionf("sending a test event for %s/%s/%s to %s",
+		event.InvolvedObject.Kind, event.InvolvedObject.Namespace, event.InvolvedObject.Name, provider.Spec.Type)
+	sent := time.Now()
+	err = clientSet.CoreV1().RESTClient().Post().
+		Namespace(testAlertArgs.fluxNamespace).
+		Resource("services").
+		Name("notification-controller").
+		SubResource("proxy").
+		SetHeader("Content-Type", "application/json").
+		Body(body).
+		Do(ctx).
+		Error()
+	if err != nil {
+		return fmt.Errorf("sending the event to the notification-controller failed: %w", err)
+	}
+	logger.Successf("event accepted by the notification-controller")
+
+	logger.Waitingf("watching the delivery to provider %s", provider.Name)
+	deadline := sent.Add(testAlertArgs.deliveryTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(rootArgs.pollInterval)
+		entry, err := findDeliveryEntry(ctx, clientSet, event.InvolvedObject, sent)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		if entry.Error != "" {
+			return fmt.Errorf("delivery to provider %s failed: %s", provider.Name, entry.Error)
+		}
+		return fmt.Errorf("the event was not delivered: %s", entry.Message)
+	}
+
+	logger.Successf("no delivery error reported in %s, check that the notification reached %s", testAlertArgs.deliveryTimeout, provider.Spec.Type)
+	return nil
+}
+
+// findDeliveryEntry returns the first log entry of the
+// notification-controller about the test event written after it was
+// sent, nil when there is none. The controller logs failed deliveries
+// and discarded events only.
+func findDeliveryEntry(ctx context.Context, clientSet *kubernetes.Clientset, involved corev1.ObjectReference,
+	sent time.Time) (*controllerLogEntry, error) {
+	pods, err := clientSet.CoreV1().Pods(testAlertArgs.fluxNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=notification-controller",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no notification-controller pods found in %s namespace", testAlertArgs.fluxNamespace)
+	}
+
+	// a margin accounts for the clock skew between the host and the cluster
+	seconds := int64(time.Since(sent).Seconds()) + 5
+	for _, pod := range pods.Items {
+		stream, err := clientSet.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container:    "manager",
+			SinceSeconds: &seconds,
+		}).Stream(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the logs of pod %s: %w", pod.Name, err)
+		}
+		// the controller logs the deliveries with the object the
+		// event is about
+		filter := logFilter{kind: involved.Kind, name: involved.Name, namespace: involved.Namespace}
+		var found *controllerLogEntry
+		err = scanLogs(stream, filter, func(entry controllerLogEntry) {
+			if found != nil {
+				return
+			}
+			if entry.Error != "" || strings.Contains(entry.Message, "Discarding event") {
+				e := entry
+				found = &e
+			}
+		})
+		stream.Close()
+		if err != nil {
+			return nil, err
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+	return nil, nil
+}
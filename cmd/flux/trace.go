@@ -0,0 +1,291 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var traceCmd = &cobra.Command{
+	Use:   "trace [kind/name]",
+	Short: "Trace an object back to the toolkit objects managing it",
+	Long: `The trace command shows which Kustomization or HelmRelease manages an object, which source it was
+fetched from, and which revision was applied. The chain is followed up to the top, so that nested
+Kustomizations and HelmReleases applied by a Kustomization are shown too.`,
+	Example: `  # Trace a Deployment
+  flux trace deployment/podinfo --namespace=apps
+
+  # Trace a cluster-scoped object, with its kind qualified by its API group
+  flux trace clusterroles.rbac.authorization.k8s.io/crd-controller
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: traceCmdRun,
+}
+
+func init() {
+	rootCmd.AddCommand(traceCmd)
+}
+
+// traceSection is a list of the fields describing an object in the
+// trace, in display order.
+type traceSection [][2]string
+
+func (s traceSection) write(w io.Writer) {
+	fmt.Fprintln(w, "---")
+	for _, field := range s {
+		fmt.Fprintf(w, "%s:\t%s\n", field[0], field[1])
+	}
+}
+
+func traceCmdRun(cmd *cobra.Command, args []string) error {
+	kind, name := utils.ParseObjectKindName(args[0])
+	if kind == "" {
+		return fmt.Errorf("invalid object '%s', must be in the format '<kind>/<name>'", args[0])
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	gvk, err := kubeClient.RESTMapper().KindFor(schema.ParseGroupResource(strings.ToLower(kind)).WithVersion(""))
+	if err != nil {
+		return fmt.Errorf("unknown kind %s: %w", kind, err)
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	if err := kubeClient.Get(ctx, namespacedName, obj); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	section := traceSection{
+		{"Object", fmt.Sprintf("%s/%s", gvk.Kind, obj.GetName())},
+	}
+	if obj.GetNamespace() != "" {
+		section = append(section, [2]string{"Namespace", obj.GetNamespace()})
+	}
+
+	var sections []traceSection
+	managed, err := traceOwners(ctx, kubeClient, obj.GetLabels(), &sections)
+	if err != nil {
+		return err
+	}
+	if managed {
+		section = append(section, [2]string{"Status", "Managed by Flux"})
+	} else {
+		section = append(section, [2]string{"Status", "Not managed by Flux"})
+	}
+
+	section.write(w)
+	for _, s := range sections {
+		s.write(w)
+	}
+	return w.Flush()
+}
+
+// traceOwners appends the sections describing the toolkit object
+// that applied an object with the given labels, its source, and the
+// objects which applied it in turn. It returns false if the labels
+// do not refer to a toolkit object.
+func traceOwners(ctx context.Context, kubeClient client.Client, labels map[string]string, sections *[]traceSection) (bool, error) {
+	if name, ok := labels[helmv2.GroupVersion.Group+"/name"]; ok {
+		namespacedName := types.NamespacedName{
+			Namespace: labels[helmv2.GroupVersion.Group+"/namespace"],
+			Name:      name,
+		}
+		var helmRelease helmv2.HelmRelease
+		if err := kubeClient.Get(ctx, namespacedName, &helmRelease); err != nil {
+			return true, err
+		}
+		status, message := statusAndMessage(helmRelease.Status.Conditions)
+		*sections = append(*sections, traceSection{
+			{"HelmRelease", helmRelease.Name},
+			{"Namespace", helmRelease.Namespace},
+			{"Revision", helmRelease.Status.LastAppliedRevision},
+			{"Ready", status},
+			{"Message", message},
+		})
+
+		// the chart is created by the helm-controller in the
+		// namespace of the source
+		sourceRef := helmRelease.Spec.Chart.Spec.SourceRef
+		sourceNamespace := helmRelease.Namespace
+		if sourceRef.Namespace != "" {
+			sourceNamespace = sourceRef.Namespace
+		}
+		chartName := types.NamespacedName{
+			Namespace: sourceNamespace,
+			Name:      fmt.Sprintf("%s-%s", helmRelease.Namespace, helmRelease.Name),
+		}
+		var chart sourcev1.HelmChart
+		if err := kubeClient.Get(ctx, chartName, &chart); err != nil {
+			return true, err
+		}
+		status, message = statusAndMessage(chart.Status.Conditions)
+		*sections = append(*sections, traceSection{
+			{"HelmChart", chart.Name},
+			{"Namespace", chart.Namespace},
+			{"Chart", chart.Spec.Chart},
+			{"Version", chart.Spec.Version},
+			{"Revision", artifactRevision(chart.Status.Artifact)},
+			{"Ready", status},
+			{"Message", message},
+		})
+
+		if err := traceSource(ctx, kubeClient, sourceRef.Kind, types.NamespacedName{
+			Namespace: sourceNamespace,
+			Name:      sourceRef.Name,
+		}, sections); err != nil {
+			return true, err
+		}
+		_, err := traceOwners(ctx, kubeClient, helmRelease.GetLabels(), sections)
+		return true, err
+	}
+
+	if name, ok := labels[kustomizev1.GroupVersion.Group+"/name"]; ok {
+		namespacedName := types.NamespacedName{
+			Namespace: labels[kustomizev1.GroupVersion.Group+"/namespace"],
+			Name:      name,
+		}
+		var kustomization kustomizev1.Kustomization
+		if err := kubeClient.Get(ctx, namespacedName, &kustomization); err != nil {
+			return true, err
+		}
+		status, message := statusAndMessage(kustomization.Status.Conditions)
+		*sections = append(*sections, traceSection{
+			{"Kustomization", kustomization.Name},
+			{"Namespace", kustomization.Namespace},
+			{"Path", kustomization.Spec.Path},
+			{"Revision", kustomization.Status.LastAppliedRevision},
+			{"Ready", status},
+			{"Message", message},
+		})
+
+		sourceRef := kustomization.Spec.SourceRef
+		sourceName := types.NamespacedName{
+			Namespace: kustomization.Namespace,
+			Name:      sourceRef.Name,
+		}
+		if sourceRef.Namespace != "" {
+			sourceName.Namespace = sourceRef.Namespace
+		}
+		if err := traceSource(ctx, kubeClient, sourceRef.Kind, sourceName, sections); err != nil {
+			return true, err
+		}
+		_, err := traceOwners(ctx, kubeClient, kustomization.GetLabels(), sections)
+		return true, err
+	}
+
+	return false, nil
+}
+
+// traceSource appends the section describing a source.
+func traceSource(ctx context.Context, kubeClient client.Client, kind string, namespacedName types.NamespacedName, sections *[]traceSection) error {
+	var section traceSection
+	var conditions []metav1.Condition
+	switch kind {
+	case sourcev1.GitRepositoryKind:
+		var repository sourcev1.GitRepository
+		if err := kubeClient.Get(ctx, namespacedName, &repository); err != nil {
+			return err
+		}
+		section = traceSection{
+			{"GitRepository", repository.Name},
+			{"Namespace", repository.Namespace},
+			{"URL", repository.Spec.URL},
+		}
+		if ref := repository.Spec.Reference; ref != nil {
+			switch {
+			case ref.Commit != "":
+				section = append(section, [2]string{"Commit", ref.Commit})
+			case ref.SemVer != "":
+				section = append(section, [2]string{"Semver", ref.SemVer})
+			case ref.Tag != "":
+				section = append(section, [2]string{"Tag", ref.Tag})
+			case ref.Branch != "":
+				section = append(section, [2]string{"Branch", ref.Branch})
+			}
+		}
+		section = append(section, [2]string{"Revision", artifactRevision(repository.Status.Artifact)})
+		conditions = repository.Status.Conditions
+	case sourcev1.HelmRepositoryKind:
+		var repository sourcev1.HelmRepository
+		if err := kubeClient.Get(ctx, namespacedName, &repository); err != nil {
+			return err
+		}
+		section = traceSection{
+			{"HelmRepository", repository.Name},
+			{"Namespace", repository.Namespace},
+			{"URL", repository.Spec.URL},
+			{"Revision", artifactRevision(repository.Status.Artifact)},
+		}
+		conditions = repository.Status.Conditions
+	case sourcev1.BucketKind:
+		var bucket sourcev1.Bucket
+		if err := kubeClient.Get(ctx, namespacedName, &bucket); err != nil {
+			return err
+		}
+		section = traceSection{
+			{"Bucket", bucket.Name},
+			{"Namespace", bucket.Namespace},
+			{"Endpoint", bucket.Spec.Endpoint},
+			{"Bucket Name", bucket.Spec.BucketName},
+			{"Revision", artifactRevision(bucket.Status.Artifact)},
+		}
+		conditions = bucket.Status.Conditions
+	default:
+		return fmt.Errorf("unsupported source kind '%s'", kind)
+	}
+
+	status, message := statusAndMessage(conditions)
+	*sections = append(*sections, append(section, [2]string{"Ready", status}, [2]string{"Message", message}))
+	return nil
+}
+
+// artifactRevision returns the revision of the artifact of a source,
+// if it has one.
+func artifactRevision(artifact *sourcev1.Artifact) string {
+	if artifact == nil {
+		return "unknown"
+	}
+	return artifact.Revision
+}
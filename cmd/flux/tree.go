@@ -0,0 +1,219 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Print the resources reconciled by toolkit objects",
+	Long:  "The tree sub-commands print the tree of resources reconciled by a toolkit object.",
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+}
+
+// treeNode is an object in the tree of resources reconciled by a
+// toolkit object.
+type treeNode struct {
+	name     string
+	children []*treeNode
+}
+
+func newTreeNode(kind, namespace, name string) *treeNode {
+	if namespace == "" {
+		return &treeNode{name: fmt.Sprintf("%s/%s", kind, name)}
+	}
+	return &treeNode{name: fmt.Sprintf("%s/%s/%s", kind, namespace, name)}
+}
+
+// write prints the children of the node, and theirs in turn, each
+// line starting with the given prefix.
+func (n *treeNode) write(w io.Writer, prefix string) {
+	for i, child := range n.children {
+		connector, indent := "├── ", "│   "
+		if i == len(n.children)-1 {
+			connector, indent = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%s%s%s\n", prefix, connector, child.name)
+		child.write(w, prefix+indent)
+	}
+}
+
+// sortTreeNodes sorts nodes by name, so the output does not depend
+// on the order in which they were listed.
+func sortTreeNodes(nodes []*treeNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].name < nodes[j].name
+	})
+}
+
+// kustomizationTree returns the resources applied by a Kustomization,
+// as recorded in the snapshot used for garbage collection, including
+// the resources of the Kustomizations and HelmReleases it applied.
+func kustomizationTree(ctx context.Context, kubeClient client.Client, kustomization *kustomizev1.Kustomization) ([]*treeNode, error) {
+	snapshot := kustomization.Status.Snapshot
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	selector := client.MatchingLabels{
+		kustomizev1.GroupVersion.Group + "/name":      kustomization.Name,
+		kustomizev1.GroupVersion.Group + "/namespace": kustomization.Namespace,
+	}
+	var items []unstructured.Unstructured
+	for _, gvk := range snapshot.NonNamespacedKinds() {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := kubeClient.List(ctx, list, selector); err != nil {
+			return nil, err
+		}
+		items = append(items, list.Items...)
+	}
+	for namespace, gvks := range snapshot.NamespacedKinds() {
+		for _, gvk := range gvks {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+			if err := kubeClient.List(ctx, list, client.InNamespace(namespace), selector); err != nil {
+				return nil, err
+			}
+			items = append(items, list.Items...)
+		}
+	}
+
+	var nodes []*treeNode
+	for _, item := range items {
+		node := newTreeNode(item.GetKind(), item.GetNamespace(), item.GetName())
+		namespacedName := types.NamespacedName{
+			Namespace: item.GetNamespace(),
+			Name:      item.GetName(),
+		}
+		var err error
+		switch item.GroupVersionKind().GroupKind() {
+		case kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind).GroupKind():
+			var child kustomizev1.Kustomization
+			if err = kubeClient.Get(ctx, namespacedName, &child); err == nil {
+				node.children, err = kustomizationTree(ctx, kubeClient, &child)
+			}
+		case helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind).GroupKind():
+			var child helmv2.HelmRelease
+			if err = kubeClient.Get(ctx, namespacedName, &child); err == nil {
+				node.children, err = helmReleaseTree(ctx, kubeClient, &child)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	sortTreeNodes(nodes)
+	return nodes, nil
+}
+
+// helmReleaseTree returns the resources installed by a HelmRelease,
+// as recorded in the manifest of its latest Helm release.
+func helmReleaseTree(ctx context.Context, kubeClient client.Client, helmRelease *helmv2.HelmRelease) ([]*treeNode, error) {
+	manifest, err := helmReleaseManifest(ctx, kubeClient, helmRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	for {
+		var obj unstructured.Unstructured
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse the manifest of Helm release %s: %w", helmRelease.GetReleaseName(), err)
+		}
+		if obj.Object == nil {
+			continue
+		}
+
+		namespace := obj.GetNamespace()
+		if namespace == "" {
+			// objects without a namespace are installed in the
+			// release namespace, unless they are cluster-scoped
+			gvk := obj.GroupVersionKind()
+			mapping, err := kubeClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err == nil && mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+				namespace = helmRelease.GetReleaseNamespace()
+			}
+		}
+		nodes = append(nodes, newTreeNode(obj.GetKind(), namespace, obj.GetName()))
+	}
+	sortTreeNodes(nodes)
+	return nodes, nil
+}
+
+// helmReleaseManifest returns the manifest of the latest Helm release
+// of a HelmRelease, or an empty string when it was never released.
+func helmReleaseManifest(ctx context.Context, kubeClient client.Client, helmRelease *helmv2.HelmRelease) (string, error) {
+	if helmRelease.Status.LastReleaseRevision == 0 {
+		return "", nil
+	}
+
+	// Helm stores each release revision in a secret, holding the
+	// gzipped JSON encoding of the release in base64
+	var storage corev1.Secret
+	storageName := types.NamespacedName{
+		Namespace: helmRelease.GetReleaseNamespace(),
+		Name:      fmt.Sprintf("sh.helm.release.v1.%s.v%d", helmRelease.GetReleaseName(), helmRelease.Status.LastReleaseRevision),
+	}
+	if err := kubeClient.Get(ctx, storageName, &storage); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(string(storage.Data["release"]))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode Helm release %s: %w", storageName.Name, err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode Helm release %s: %w", storageName.Name, err)
+	}
+	var release struct {
+		Manifest string `json:"manifest"`
+	}
+	if err := json.NewDecoder(reader).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to decode Helm release %s: %w", storageName.Name, err)
+	}
+	return release.Manifest, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+)
+
+var treeKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Print the resources reconciled by a Kustomization",
+	Long: `The tree kustomization command prints the tree of resources applied by a Kustomization, including
+the resources of the Kustomizations and HelmReleases it applied, which shows what deleting or suspending
+the Kustomization would affect.`,
+	Example: `  # Print the resources reconciled by the flux-system Kustomization
+  flux tree kustomization flux-system
+`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizationType),
+	RunE:              treeKsCmdRun,
+}
+
+func init() {
+	treeCmd.AddCommand(treeKsCmd)
+}
+
+func treeKsCmdRun(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      args[0],
+	}
+	var kustomization kustomizev1.Kustomization
+	if err := kubeClient.Get(ctx, namespacedName, &kustomization); err != nil {
+		return err
+	}
+
+	root := newTreeNode(kustomizev1.KustomizationKind, kustomization.Namespace, kustomization.Name)
+	root.children, err = kustomizationTree(ctx, kubeClient, &kustomization)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stdout, root.name)
+	root.write(os.Stdout, "")
+	return nil
+}
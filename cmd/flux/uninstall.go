@@ -30,7 +30,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	"github.com/fluxcd/flux2/internal/utils"
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
@@ -52,7 +51,6 @@ var uninstallCmd = &cobra.Command{
 type uninstallFlags struct {
 	keepNamespace bool
 	dryRun        bool
-	silent        bool
 }
 
 var uninstallArgs uninstallFlags
@@ -62,14 +60,12 @@ func init() {
 		"skip namespace deletion")
 	uninstallCmd.Flags().BoolVar(&uninstallArgs.dryRun, "dry-run", false,
 		"only print the objects that would be deleted")
-	uninstallCmd.Flags().BoolVarP(&uninstallArgs.silent, "silent", "s", false,
-		"delete components without asking for confirmation")
 
 	rootCmd.AddCommand(uninstallCmd)
 }
 
 func uninstallCmdRun(cmd *cobra.Command, args []string) error {
-	if !uninstallArgs.dryRun && !uninstallArgs.silent {
+	if !uninstallArgs.dryRun && !rootArgs.silent {
 		prompt := promptui.Prompt{
 			Label:     "Are you sure you want to delete Flux and its custom resource definitions",
 			IsConfirm: true,
@@ -79,10 +75,10 @@ func uninstallCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := getKubeClient()
 	if err != nil {
 		return err
 	}
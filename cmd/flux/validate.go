@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	"github.com/fluxcd/flux2/pkg/apply"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Validate toolkit manifests offline",
+	Long: `The validate command checks the toolkit objects in the YAML and JSON files of a path against the
+OpenAPI schemas of the CRDs bundled with this version of the CLI, without access to a cluster.
+Unknown fields, missing required fields, wrong types and malformed durations are reported,
+along with the file they were found in. Objects of other kinds are skipped.`,
+	Example: `  # Validate the manifests of a cluster
+  flux validate ./clusters/prod
+
+  # Validate a single file
+  flux validate ./clusters/prod/podinfo.yaml
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: validateCmdRun,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+func validateCmdRun(cmd *cobra.Command, args []string) error {
+	schemas, err := embeddedSchemas()
+	if err != nil {
+		return fmt.Errorf("loading the CRD schemas failed: %w", err)
+	}
+	scheme := utils.NewScheme()
+
+	var files []string
+	err = filepath.Walk(args[0], func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") && p != args[0] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	validated, invalid := 0, 0
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		objects, err := apply.DecodeObjects(data)
+		if err != nil {
+			logger.Failuref("%s: %v", file, err)
+			invalid++
+			continue
+		}
+		for _, obj := range objects {
+			crdSchema, ok := schemas[obj.GroupVersionKind()]
+			if !ok {
+				continue
+			}
+			validated++
+			errs := crdSchema.check(scheme, obj)
+			if len(errs) == 0 {
+				continue
+			}
+			invalid++
+			for _, e := range errs {
+				logger.Failuref("%s: %s/%s: %v", file, obj.GetKind(), obj.GetName(), e)
+			}
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d objects are invalid", invalid, validated)
+	}
+	logger.Successf("%d objects are valid", validated)
+	return nil
+}
+
+type crdSchema struct {
+	props    *apiextensionsv1.JSONSchemaProps
+	validate func(obj interface{}) field.ErrorList
+}
+
+// embeddedSchemas returns the OpenAPI schemas of the CRDs in the
+// embedded install manifests, by group, version and kind.
+func embeddedSchemas() (map[schema.GroupVersionKind]crdSchema, error) {
+	manifests, err := fs.ReadDir(embeddedManifests, "manifests")
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := map[schema.GroupVersionKind]crdSchema{}
+	for _, manifest := range manifests {
+		data, err := fs.ReadFile(embeddedManifests, path.Join("manifests", manifest.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading file failed: %w", err)
+		}
+		decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+		for {
+			var crd apiextensionsv1.CustomResourceDefinition
+			if err := decoder.Decode(&crd); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, err
+			}
+			if crd.Kind != "CustomResourceDefinition" {
+				continue
+			}
+			for _, version := range crd.Spec.Versions {
+				if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+					continue
+				}
+				var internal apiextensions.JSONSchemaProps
+				if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(version.Schema.OpenAPIV3Schema, &internal, nil); err != nil {
+					return nil, err
+				}
+				validator, _, err := validation.NewSchemaValidator(&apiextensions.CustomResourceValidation{OpenAPIV3Schema: &internal})
+				if err != nil {
+					return nil, err
+				}
+				gvk := schema.GroupVersionKind{
+					Group:   crd.Spec.Group,
+					Version: version.Name,
+					Kind:    crd.Spec.Names.Kind,
+				}
+				schemas[gvk] = crdSchema{
+					props: version.Schema.OpenAPIV3Schema,
+					validate: func(obj interface{}) field.ErrorList {
+						return validation.ValidateCustomResource(nil, obj, validator)
+					},
+				}
+			}
+		}
+	}
+	return schemas, nil
+}
+
+// check validates an object against the schema, and by decoding it in
+// its Go type, which catches the values the schema does not describe
+// precisely, such as durations.
+func (s crdSchema) check(scheme *runtime.Scheme, obj *unstructured.Unstructured) []error {
+	var errs []error
+	for _, unknown := range unknownFields(obj.Object, s.props, nil) {
+		errs = append(errs, fmt.Errorf("%s: unknown field", unknown))
+	}
+	for _, e := range s.validate(obj.Object) {
+		errs = append(errs, e)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	typed, err := scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return nil
+	}
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return []error{err}
+	}
+	if err := json.Unmarshal(data, typed); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// unknownFields returns the paths of the fields of a value which are
+// not declared in its schema.
+func unknownFields(value interface{}, props *apiextensionsv1.JSONSchemaProps, fldPath *field.Path) []string {
+	if props == nil || (props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields) {
+		return nil
+	}
+
+	var unknown []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var keys []string
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := fldPath.Child(key)
+			if p, ok := props.Properties[key]; ok {
+				unknown = append(unknown, unknownFields(v[key], &p, child)...)
+				continue
+			}
+			if additional := props.AdditionalProperties; additional != nil && (additional.Allows || additional.Schema != nil) {
+				unknown = append(unknown, unknownFields(v[key], additional.Schema, child)...)
+				continue
+			}
+			// the metadata of an object is not described by its schema
+			if (fldPath == nil || props.XEmbeddedResource) && (key == "apiVersion" || key == "kind" || key == "metadata") {
+				continue
+			}
+			unknown = append(unknown, child.String())
+		}
+	case []interface{}:
+		if props.Items == nil || props.Items.Schema == nil {
+			return nil
+		}
+		for i, item := range v {
+			unknown = append(unknown, unknownFields(item, props.Items.Schema, fldPath.Index(i))...)
+		}
+	}
+	return unknown
+}
@@ -1,19 +1,191 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1alpha1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/fluxcd/flux2/pkg/manifestgen/install"
 )
 
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the client and server versions",
+	Long: `The version command prints the version of the CLI, and compares the versions of the controllers
+and of the CRDs installed in the cluster with the ones supported by the CLI.
+A controller running another version, or a CRD lacking an API version the CLI relies on, is flagged as skewed.`,
+	Example: `  # Print the client and server versions
+  flux version
+
+  # Print only the client version
+  flux version --client
+`,
+	Args: cobra.NoArgs,
+	RunE: versionCmdRun,
+}
+
+type versionFlags struct {
+	client bool
+}
+
+var versionArgs versionFlags
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionArgs.client, "client", false,
+		"only print the client version, without querying the cluster")
+
+	rootCmd.AddCommand(versionCmd)
+}
+
+func versionCmdRun(cmd *cobra.Command, args []string) error {
+	fmt.Printf("flux: %s\n", rootArgs.defaults.Version)
+	if versionArgs.client {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	controllerRows, controllersSkewed, err := controllerVersions(ctx, kubeClient)
+	if err != nil {
+		return err
+	}
+	crdRows, crdsSkewed, err := crdVersions(ctx, kubeClient)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"Component", "Client", "Server", "Skew"}
+	utils.PrintTable(os.Stdout, header, append(controllerRows, crdRows...))
+
+	if controllersSkewed || crdsSkewed {
+		logger.Warningf("the cluster runs other versions than the ones supported by flux %s, "+
+			"upgrade either the cluster or the CLI before running other commands", VERSION)
+	}
+	return nil
+}
+
+// controllerVersions compares the image tags of the installed
+// controllers with the ones of the embedded manifests.
+func controllerVersions(ctx context.Context, kubeClient client.Client) ([][]string, bool, error) {
+	expectedImages, err := embeddedImages()
+	if err != nil {
+		return nil, false, fmt.Errorf("reading the embedded manifests failed: %w", err)
+	}
+	components := make([]string, 0, len(expectedImages))
+	for component := range expectedImages {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	var rows [][]string
+	skewed := false
+	for _, component := range components {
+		expected := imageTag(expectedImages[component])
+		var d v1.Deployment
+		namespacedName := types.NamespacedName{
+			Namespace: rootArgs.namespace,
+			Name:      component,
+		}
+		if err := kubeClient.Get(ctx, namespacedName, &d); err != nil {
+			if apierrors.IsNotFound(err) {
+				rows = append(rows, []string{component, expected, "not installed", ""})
+				continue
+			}
+			return nil, false, err
+		}
+		if len(d.Spec.Template.Spec.Containers) == 0 {
+			continue
+		}
+		// the registry may have been changed at install time, so only
+		// the tags are compared
+		actual := imageTag(d.Spec.Template.Spec.Containers[0].Image)
+		skew := ""
+		if actual != expected {
+			skew = "yes"
+			skewed = true
+		}
+		rows = append(rows, []string{component, expected, actual, skew})
+	}
+	return rows, skewed, nil
+}
+
+// crdVersions compares the API versions served by the installed CRDs
+// with the ones the CLI uses.
+func crdVersions(ctx context.Context, kubeClient client.Client) ([][]string, bool, error) {
+	required := map[string]string{
+		sourcev1.GroupVersion.Group:       sourcev1.GroupVersion.Version,
+		kustomizev1.GroupVersion.Group:    kustomizev1.GroupVersion.Version,
+		helmv2.GroupVersion.Group:         helmv2.GroupVersion.Version,
+		notificationv1.GroupVersion.Group: notificationv1.GroupVersion.Version,
+		imagev1.GroupVersion.Group:        imagev1.GroupVersion.Version,
+		autov1.GroupVersion.Group:         autov1.GroupVersion.Version,
+	}
+
+	selector := client.MatchingLabels{"app.kubernetes.io/instance": rootArgs.namespace}
+	var list apiextensionsv1.CustomResourceDefinitionList
+	if err := kubeClient.List(ctx, &list, selector); err != nil {
+		return nil, false, fmt.Errorf("listing the CRDs failed: %w", err)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+
+	var rows [][]string
+	skewed := false
+	for _, crd := range list.Items {
+		version, found := required[crd.Spec.Group]
+		if !found {
+			continue
+		}
+		var served []string
+		skew := "yes"
+		for _, v := range crd.Spec.Versions {
+			if !v.Served {
+				continue
+			}
+			served = append(served, v.Name)
+			if v.Name == version {
+				skew = ""
+			}
+		}
+		if skew != "" {
+			skewed = true
+		}
+		rows = append(rows, []string{crd.Name, version, strings.Join(served, ","), skew})
+	}
+	return rows, skewed, nil
+}
+
 func getVersion(input string) (string, error) {
 	if input == "" {
 		return rootArgs.defaults.Version, nil
 	}
 
 	if isEmbeddedVersion(input) {
-		return input, nil
+		return rootArgs.defaults.Version, nil
 	}
 
 	var err error
@@ -37,6 +209,9 @@ func getVersion(input string) (string, error) {
 	return input, nil
 }
 
+// isEmbeddedVersion returns whether the manifests of a version are
+// embedded in the binary, which they are for the version of the CLI,
+// with or without the v prefix.
 func isEmbeddedVersion(input string) bool {
-	return input == rootArgs.defaults.Version
+	return input == rootArgs.defaults.Version || "v"+input == rootArgs.defaults.Version
 }
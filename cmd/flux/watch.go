@@ -0,0 +1,262 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch the reconciliation of sources and resources",
+	Long: `The watch sub-commands print a timeline of the reconciliation of an object, made of the transitions
+of its conditions, of the revisions it reconciles and of the events of the object and of its source,
+until its current revision is reconciled or fails.`,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
+
+type watchCommand struct {
+	apiType
+	object watchable
+}
+
+type watchable interface {
+	statusable
+	isSuspended() bool
+}
+
+// revisionedWatchable is for objects reconciling the artifact of a
+// source, whose reconciliation is done once they attempted the current
+// revision of the artifact, rather than when they are ready.
+type revisionedWatchable interface {
+	watchable
+	currentRevision(ctx context.Context, kubeClient client.Client) (string, error)
+	lastAttemptedRevision() string
+	lastAppliedRevision() string
+}
+
+// artifactSource is for the sources, whose artifact is the current
+// revision of the objects reconciling them.
+type artifactSource interface {
+	adapter
+	getArtifact() *sourcev1.Artifact
+}
+
+// timeline prints the changes of the status of an object and the new
+// events of the objects it's made of, as they happen.
+type timeline struct {
+	conditions map[string]metav1.Condition
+	revisions  map[string]string
+	events     map[string]bool
+}
+
+func (watch watchCommand) run(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s name is required", watch.kind)
+	}
+	name := args[0]
+
+	ctx, cancel := context.WithTimeout(rootCtx, rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := getKubeClient()
+	if err != nil {
+		return err
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	if err := kubeClient.Get(ctx, namespacedName, watch.object.asClientObject()); err != nil {
+		return err
+	}
+	if watch.object.isSuspended() {
+		return fmt.Errorf("%s %s is suspended, resume it to watch its reconciliation", watch.kind, name)
+	}
+
+	targets, err := eventTargets(ctx, kubeClient, eventTarget{kind: watch.kind, NamespacedName: namespacedName})
+	if err != nil {
+		return err
+	}
+
+	t := &timeline{
+		conditions: map[string]metav1.Condition{},
+		revisions:  map[string]string{},
+		events:     map[string]bool{},
+	}
+	// the events emitted before the watch are not part of the timeline
+	events, err := listTargetEvents(ctx, kubeClient, targets)
+	if err != nil {
+		return err
+	}
+	for _, event := range mergeEvents(events) {
+		t.events[eventKey(event)] = true
+	}
+
+	logger.Waitingf("watching %s %s in %s namespace", watch.kind, name, rootArgs.namespace)
+	utils.PrintTable(os.Stdout, []string{"Time", "Object", "Reason", "Message"}, nil)
+	for {
+		if err := kubeClient.Get(ctx, namespacedName, watch.object.asClientObject()); err != nil {
+			return err
+		}
+		rows := t.statusRows(watch.kind, name, watch.object)
+
+		done, err := watch.done(ctx, kubeClient, t, &rows)
+		if err != nil {
+			return err
+		}
+
+		events, err := listTargetEvents(ctx, kubeClient, targets)
+		if err != nil {
+			return err
+		}
+		for _, event := range mergeEvents(events) {
+			if t.events[eventKey(event)] {
+				continue
+			}
+			t.events[eventKey(event)] = true
+			rows = append(rows, []string{
+				eventTime(event).Format(time.RFC3339),
+				fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name),
+				event.Reason,
+				event.Message,
+			})
+		}
+		if len(rows) > 0 {
+			utils.PrintTable(os.Stdout, nil, rows)
+		}
+
+		if done {
+			return watch.result()
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s %s to reconcile its current revision", watch.kind, name)
+		case <-time.After(rootArgs.pollInterval):
+		}
+	}
+}
+
+// done reports whether the object reconciled its current generation
+// and, for the objects reconciling a source, the current revision of
+// the source. The revision changes are added to the rows.
+func (watch watchCommand) done(ctx context.Context, kubeClient client.Client, t *timeline, rows *[][]string) (bool, error) {
+	reconciled := watch.object.GetGeneration() == watch.object.getObservedGeneration()
+	if c := apimeta.FindStatusCondition(*watch.object.GetStatusConditions(), meta.ReadyCondition); c == nil ||
+		c.Status == metav1.ConditionUnknown {
+		reconciled = false
+	}
+
+	obj, ok := watch.object.(revisionedWatchable)
+	if !ok {
+		return reconciled, nil
+	}
+	current, err := obj.currentRevision(ctx, kubeClient)
+	if err != nil {
+		return false, err
+	}
+	object := fmt.Sprintf("%s/%s", watch.kind, watch.object.asClientObject().GetName())
+	now := time.Now().Format(time.RFC3339)
+	for _, r := range []struct{ reason, revision string }{
+		{"SourceRevision", current},
+		{"AttemptedRevision", obj.lastAttemptedRevision()},
+		{"AppliedRevision", obj.lastAppliedRevision()},
+	} {
+		if r.revision == "" || t.revisions[r.reason] == r.revision {
+			continue
+		}
+		t.revisions[r.reason] = r.revision
+		*rows = append(*rows, []string{now, object, r.reason, r.revision})
+	}
+	return reconciled && current != "" && obj.lastAttemptedRevision() == current, nil
+}
+
+// result returns the outcome of the reconciliation of the object, once
+// it's done.
+func (watch watchCommand) result() error {
+	c := apimeta.FindStatusCondition(*watch.object.GetStatusConditions(), meta.ReadyCondition)
+	if c != nil && c.Status == metav1.ConditionFalse {
+		return fmt.Errorf("%s reconciliation failed: %s", watch.kind, c.Message)
+	}
+	if obj, ok := watch.object.(revisionedWatchable); ok {
+		logger.Successf("%s reconciled revision %s", watch.kind, obj.lastAppliedRevision())
+		return nil
+	}
+	logger.Successf("%s reconciliation completed", watch.kind)
+	return nil
+}
+
+// statusRows returns a row for each condition of the object that
+// changed since the previous call.
+func (t *timeline) statusRows(kind, name string, object watchable) [][]string {
+	var rows [][]string
+	for _, c := range *object.GetStatusConditions() {
+		if last, ok := t.conditions[c.Type]; ok && last.Status == c.Status && last.Reason == c.Reason &&
+			last.Message == c.Message {
+			continue
+		}
+		t.conditions[c.Type] = c
+		rows = append(rows, []string{
+			c.LastTransitionTime.Format(time.RFC3339),
+			fmt.Sprintf("%s/%s", kind, name),
+			fmt.Sprintf("%s=%s (%s)", c.Type, c.Status, c.Reason),
+			c.Message,
+		})
+	}
+	return rows
+}
+
+// eventKey identifies an occurrence of an event, which is updated
+// rather than recreated when it repeats.
+func eventKey(event corev1.Event) string {
+	return fmt.Sprintf("%s/%d", event.UID, event.Count)
+}
+
+// sourceRevision returns the revision of the artifact of a source,
+// empty when the source has no artifact yet.
+func sourceRevision(ctx context.Context, kubeClient client.Client, source reconcileCommand, namespacedName types.NamespacedName) (string, error) {
+	obj, ok := source.object.(artifactSource)
+	if !ok {
+		return "", fmt.Errorf("cannot tell the revision of %s %s", source.kind, namespacedName)
+	}
+	if err := kubeClient.Get(ctx, namespacedName, obj.asClientObject()); err != nil {
+		return "", err
+	}
+	if artifact := obj.getArtifact(); artifact != nil {
+		return artifact.Revision, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var watchHrCmd = &cobra.Command{
+	Use:     "helmrelease [name]",
+	Aliases: []string{"hr"},
+	Short:   "Watch the reconciliation of a HelmRelease",
+	Long: `The watch helmrelease command prints the timeline of the reconciliation of a HelmRelease, of its chart
+and of its source, until the HelmRelease has released the current version of the chart or failed to.`,
+	Example: `  # Follow the upgrade of a HelmRelease after a change of its chart
+  flux watch helmrelease podinfo
+`,
+	ValidArgsFunction: resourceNamesCompletionFunc(helmReleaseType),
+	RunE: watchCommand{
+		apiType: helmReleaseType,
+		object:  helmReleaseAdapter{&helmv2.HelmRelease{}},
+	}.run,
+}
+
+func init() {
+	watchCmd.AddCommand(watchHrCmd)
+}
+
+// currentRevision returns the version of the chart of the HelmRelease,
+// which the helm-controller creates in the namespace of the source.
+func (obj helmReleaseAdapter) currentRevision(ctx context.Context, kubeClient client.Client) (string, error) {
+	_, sourceName, err := obj.getSource()
+	if err != nil {
+		return "", err
+	}
+	chart := reconcileCommand{
+		apiType: helmChartType,
+		object:  helmChartAdapter{&sourcev1.HelmChart{}},
+	}
+	namespacedName := types.NamespacedName{
+		Namespace: sourceName.Namespace,
+		Name:      fmt.Sprintf("%s-%s", obj.Namespace, obj.Name),
+	}
+	revision, err := sourceRevision(ctx, kubeClient, chart, namespacedName)
+	if err != nil {
+		return "", fmt.Errorf("chart %s: %w", namespacedName, err)
+	}
+	return revision, nil
+}
+
+func (obj helmReleaseAdapter) lastAttemptedRevision() string {
+	return obj.Status.LastAttemptedRevision
+}
+
+func (obj helmReleaseAdapter) lastAppliedRevision() string {
+	return obj.Status.LastAppliedRevision
+}
+
+func (obj helmChartAdapter) getArtifact() *sourcev1.Artifact {
+	return obj.Status.Artifact
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+)
+
+var watchKsCmd = &cobra.Command{
+	Use:     "kustomization [name]",
+	Aliases: []string{"ks"},
+	Short:   "Watch the reconciliation of a Kustomization",
+	Long: `The watch kustomization command prints the timeline of the reconciliation of a Kustomization and of its
+source, until the Kustomization has applied the current revision of the source or failed to.`,
+	Example: `  # Follow the reconciliation of a Kustomization after a push to its repository
+  flux watch kustomization podinfo
+
+  # Follow a reconciliation that takes longer than the default timeout
+  flux watch kustomization podinfo --timeout=15m
+`,
+	ValidArgsFunction: resourceNamesCompletionFunc(kustomizationType),
+	RunE: watchCommand{
+		apiType: kustomizationType,
+		object:  kustomizationAdapter{&kustomizev1.Kustomization{}},
+	}.run,
+}
+
+func init() {
+	watchCmd.AddCommand(watchKsCmd)
+}
+
+func (obj kustomizationAdapter) currentRevision(ctx context.Context, kubeClient client.Client) (string, error) {
+	source, namespacedName, err := obj.getSource()
+	if err != nil {
+		return "", err
+	}
+	revision, err := sourceRevision(ctx, kubeClient, source, namespacedName)
+	if err != nil {
+		return "", fmt.Errorf("source %s/%s: %w", source.kind, namespacedName.Name, err)
+	}
+	return revision, nil
+}
+
+func (obj kustomizationAdapter) lastAttemptedRevision() string {
+	return obj.Status.LastAttemptedRevision
+}
+
+func (obj kustomizationAdapter) lastAppliedRevision() string {
+	return obj.Status.LastAppliedRevision
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var watchSourceCmd = &cobra.Command{
+	Use:   "source",
+	Short: "Watch the reconciliation of sources",
+	Long:  "The watch source sub-commands print the timeline of the reconciliation of a source, until it's ready at its current generation or failed.",
+}
+
+func init() {
+	watchCmd.AddCommand(watchSourceCmd)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var watchSourceBucketCmd = &cobra.Command{
+	Use:   "bucket [name]",
+	Short: "Watch the reconciliation of a Bucket source",
+	Long:  "The watch source bucket command prints the timeline of the reconciliation of a Bucket source.",
+	Example: `  # Follow the reconciliation of a source
+  flux watch source bucket podinfo
+`,
+	ValidArgsFunction: resourceNamesCompletionFunc(bucketType),
+	RunE: watchCommand{
+		apiType: bucketType,
+		object:  bucketAdapter{&sourcev1.Bucket{}},
+	}.run,
+}
+
+func init() {
+	watchSourceCmd.AddCommand(watchSourceBucketCmd)
+}
+
+func (obj bucketAdapter) getArtifact() *sourcev1.Artifact {
+	return obj.Status.Artifact
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var watchSourceGitCmd = &cobra.Command{
+	Use:   "git [name]",
+	Short: "Watch the reconciliation of a GitRepository source",
+	Long:  "The watch source git command prints the timeline of the reconciliation of a GitRepository source.",
+	Example: `  # Follow the reconciliation of a source
+  flux watch source git podinfo
+`,
+	ValidArgsFunction: resourceNamesCompletionFunc(gitRepositoryType),
+	RunE: watchCommand{
+		apiType: gitRepositoryType,
+		object:  gitRepositoryAdapter{&sourcev1.GitRepository{}},
+	}.run,
+}
+
+func init() {
+	watchSourceCmd.AddCommand(watchSourceGitCmd)
+}
+
+func (obj gitRepositoryAdapter) getArtifact() *sourcev1.Artifact {
+	return obj.Status.Artifact
+}
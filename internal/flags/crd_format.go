@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	YAMLCRDFormat       = "yaml"
+	JSONSchemaCRDFormat = "jsonschema"
+)
+
+var supportedCRDFormats = []string{YAMLCRDFormat, JSONSchemaCRDFormat}
+
+type CRDFormat string
+
+func (f *CRDFormat) String() string {
+	return string(*f)
+}
+
+func (f *CRDFormat) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no CRD format given, must be one of: %s",
+			strings.Join(supportedCRDFormats, ", "))
+	}
+	if !utils.ContainsItemString(supportedCRDFormats, str) {
+		return fmt.Errorf("unsupported CRD format '%s', must be one of: %s",
+			str, strings.Join(supportedCRDFormats, ", "))
+	}
+	*f = CRDFormat(str)
+	return nil
+}
+
+func (f *CRDFormat) Type() string {
+	return "crdFormat"
+}
+
+func (f *CRDFormat) Description() string {
+	return fmt.Sprintf("the format in which the CRDs are exported, available options are: (%s)",
+		strings.Join(supportedCRDFormats, ", "))
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	TextDiffFormat     = "text"
+	MarkdownDiffFormat = "markdown"
+)
+
+var supportedDiffFormats = []string{TextDiffFormat, MarkdownDiffFormat}
+
+type DiffFormat string
+
+func (d *DiffFormat) String() string {
+	return string(*d)
+}
+
+func (d *DiffFormat) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no diff format given, must be one of: %s",
+			strings.Join(supportedDiffFormats, ", "))
+	}
+	if !utils.ContainsItemString(supportedDiffFormats, str) {
+		return fmt.Errorf("unsupported diff format '%s', must be one of: %s",
+			str, strings.Join(supportedDiffFormats, ", "))
+	}
+	*d = DiffFormat(str)
+	return nil
+}
+
+func (d *DiffFormat) Type() string {
+	return "diffFormat"
+}
+
+func (d *DiffFormat) Description() string {
+	return fmt.Sprintf("the format in which the diff is printed, available options are: (%s)",
+		strings.Join(supportedDiffFormats, ", "))
+}
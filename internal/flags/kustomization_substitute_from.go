@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var supportedKustomizationSubstituteFromKinds = []string{"ConfigMap", "Secret"}
+
+type KustomizationSubstituteFrom struct {
+	Kind string
+	Name string
+}
+
+func (s *KustomizationSubstituteFrom) String() string {
+	if s.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", s.Kind, s.Name)
+}
+
+func (s *KustomizationSubstituteFrom) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no substitution source given, please specify %s",
+			s.Description())
+	}
+
+	sourceKind, sourceName := utils.ParseObjectKindName(str)
+	if sourceKind == "" || sourceName == "" {
+		return fmt.Errorf("invalid Kubernetes object reference '%s', must be in format <kind>/<name>", str)
+	}
+	cleanSourceKind, ok := utils.ContainsEqualFoldItemString(supportedKustomizationSubstituteFromKinds, sourceKind)
+	if !ok {
+		return fmt.Errorf("reference kind '%s' is not supported, must be one of: %s",
+			sourceKind, strings.Join(supportedKustomizationSubstituteFromKinds, ", "))
+	}
+
+	s.Name = sourceName
+	s.Kind = cleanSourceKind
+
+	return nil
+}
+
+func (s *KustomizationSubstituteFrom) Type() string {
+	return "kustomizationSubstituteFrom"
+}
+
+func (s *KustomizationSubstituteFrom) Description() string {
+	return fmt.Sprintf(
+		"Kubernetes object reference that contains the variables substituted in the manifests in the format '<kind>/<name>', "+
+			"where kind must be one of: (%s)",
+		strings.Join(supportedKustomizationSubstituteFromKinds, ", "),
+	)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	TextLogFormat = "text"
+	JSONLogFormat = "json"
+)
+
+var supportedLogFormats = []string{TextLogFormat, JSONLogFormat}
+
+type LogFormat string
+
+func (o *LogFormat) String() string {
+	return string(*o)
+}
+
+func (o *LogFormat) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no log format given, must be one of: %s",
+			strings.Join(supportedLogFormats, ", "))
+	}
+	if !utils.ContainsItemString(supportedLogFormats, str) {
+		return fmt.Errorf("unsupported log format '%s', must be one of: %s",
+			str, strings.Join(supportedLogFormats, ", "))
+	}
+	*o = LogFormat(str)
+	return nil
+}
+
+func (o *LogFormat) Type() string {
+	return "logFormat"
+}
+
+func (o *LogFormat) Description() string {
+	return fmt.Sprintf("the format of the messages written to stderr, available options are: (%s)",
+		strings.Join(supportedLogFormats, ", "))
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+const (
+	TableOutputFormat = "table"
+	WideOutputFormat  = "wide"
+	JSONOutputFormat  = "json"
+	YAMLOutputFormat  = "yaml"
+)
+
+var supportedOutputFormats = []string{TableOutputFormat, WideOutputFormat, JSONOutputFormat, YAMLOutputFormat}
+
+type OutputFormat string
+
+func (o *OutputFormat) String() string {
+	return string(*o)
+}
+
+func (o *OutputFormat) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no output format given, must be one of: %s",
+			strings.Join(supportedOutputFormats, ", "))
+	}
+	if !utils.ContainsItemString(supportedOutputFormats, str) {
+		return fmt.Errorf("unsupported output format '%s', must be one of: %s",
+			str, strings.Join(supportedOutputFormats, ", "))
+	}
+	*o = OutputFormat(str)
+	return nil
+}
+
+func (o *OutputFormat) Type() string {
+	return "outputFormat"
+}
+
+func (o *OutputFormat) Description() string {
+	return fmt.Sprintf("the format in which the objects are printed, available options are: (%s)",
+		strings.Join(supportedOutputFormats, ", "))
+}
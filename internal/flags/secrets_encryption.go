@@ -0,0 +1,54 @@
+/*
+Copyright 2020 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+var supportedSecretsEncryptions = []string{"age"}
+
+type SecretsEncryption string
+
+func (s *SecretsEncryption) String() string {
+	return string(*s)
+}
+
+func (s *SecretsEncryption) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no secrets encryption given, must be one of: %s",
+			strings.Join(supportedSecretsEncryptions, ", "))
+	}
+	if !utils.ContainsItemString(supportedSecretsEncryptions, str) {
+		return fmt.Errorf("unsupported secrets encryption '%s', must be one of: %s",
+			str, strings.Join(supportedSecretsEncryptions, ", "))
+	}
+	*s = SecretsEncryption(str)
+	return nil
+}
+
+func (s *SecretsEncryption) Type() string {
+	return "secretsEncryption"
+}
+
+func (s *SecretsEncryption) Description() string {
+	return fmt.Sprintf("generate a key pair for decrypting SOPS encrypted secrets, available options are: (%s)",
+		strings.Join(supportedSecretsEncryptions, ", "))
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	ReadyStatusKey     = "ready"
+	SuspendedStatusKey = "suspended"
+)
+
+var supportedStatusKeys = []string{ReadyStatusKey, SuspendedStatusKey}
+
+// StatusSelector selects objects by their readiness and whether they
+// are suspended, a nil field matching any object.
+type StatusSelector struct {
+	Ready     *bool
+	Suspended *bool
+}
+
+func (s *StatusSelector) String() string {
+	var terms []string
+	if s.Ready != nil {
+		terms = append(terms, fmt.Sprintf("%s=%t", ReadyStatusKey, *s.Ready))
+	}
+	if s.Suspended != nil {
+		terms = append(terms, fmt.Sprintf("%s=%t", SuspendedStatusKey, *s.Suspended))
+	}
+	return strings.Join(terms, ",")
+}
+
+func (s *StatusSelector) Set(str string) error {
+	if strings.TrimSpace(str) == "" {
+		return fmt.Errorf("no status selector given, please specify %s",
+			s.Description())
+	}
+
+	var selector StatusSelector
+	for _, term := range strings.Split(str, ",") {
+		parts := strings.SplitN(term, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid status selector term '%s', must be in format <key>=<true|false>", term)
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value, err := strconv.ParseBool(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return fmt.Errorf("invalid value '%s' for status key '%s', must be true or false", parts[1], key)
+		}
+		switch key {
+		case ReadyStatusKey:
+			selector.Ready = &value
+		case SuspendedStatusKey:
+			selector.Suspended = &value
+		default:
+			return fmt.Errorf("unsupported status key '%s', must be one of: %s",
+				key, strings.Join(supportedStatusKeys, ", "))
+		}
+	}
+
+	*s = selector
+	return nil
+}
+
+func (s *StatusSelector) Type() string {
+	return "statusSelector"
+}
+
+func (s *StatusSelector) Description() string {
+	return fmt.Sprintf("list only the objects matching this status selector in the format '<key>=<true|false>[,...]', "+
+		"where key must be one of: (%s)", strings.Join(supportedStatusKeys, ", "))
+}
+
+// Matches reports whether an object with the given status is selected.
+func (s *StatusSelector) Matches(ready, suspended bool) bool {
+	if s.Ready != nil && *s.Ready != ready {
+		return false
+	}
+	if s.Suspended != nil && *s.Suspended != suspended {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"testing"
+)
+
+func TestStatusSelector_Set(t *testing.T) {
+	tests := []struct {
+		name      string
+		str       string
+		expect    string
+		expectErr bool
+	}{
+		{"ready", "ready=false", "ready=false", false},
+		{"suspended", "suspended=true", "suspended=true", false},
+		{"both", "suspended=false,ready=true", "ready=true,suspended=false", false},
+		{"mixed case", "Ready=False", "ready=false", false},
+		{"unsupported key", "reconciling=true", "", true},
+		{"invalid value", "ready=maybe", "", true},
+		{"invalid format", "ready", "", true},
+		{"empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s StatusSelector
+			if err := s.Set(tt.str); (err != nil) != tt.expectErr {
+				t.Errorf("Set() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if str := s.String(); str != tt.expect {
+				t.Errorf("Set() = %v, expect %v", str, tt.expect)
+			}
+		})
+	}
+}
+
+func TestStatusSelector_Matches(t *testing.T) {
+	var s StatusSelector
+	if !s.Matches(true, false) {
+		t.Error("empty selector should match any object")
+	}
+	if err := s.Set("ready=false"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Matches(true, false) {
+		t.Error("ready=false should not match a ready object")
+	}
+	if !s.Matches(false, true) {
+		t.Error("ready=false should match a suspended object that is not ready")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RetryBackoff is the backoff of the calls to the Kubernetes API that
+// fail with a transient error.
+var RetryBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// IsTransientError returns whether an error returned by a call to the
+// Kubernetes API is likely to go away when the call is retried, e.g.
+// a reset connection, a throttled request or a webhook timing out.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) {
+		return true
+	}
+	if utilnet.IsConnectionReset(err) || utilnet.IsConnectionRefused(err) || utilnet.IsProbableEOF(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Retry calls fn until it succeeds, fails with an error that is not
+// transient, the context is done or RetryBackoff runs out of steps.
+// It returns the last error of fn.
+func Retry(ctx context.Context, fn func() error) error {
+	backoff := RetryBackoff
+	for {
+		err := fn()
+		if !IsTransientError(err) || ctx.Err() != nil || backoff.Steps <= 1 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
+
+// retryClient is a client retrying the calls to the Kubernetes API
+// that fail with a transient error. Create is not retried, as it
+// may have succeeded when the connection was lost.
+type retryClient struct {
+	client.Client
+}
+
+// NewRetryClient wraps a client so that the calls to the Kubernetes
+// API failing with a transient error are retried with RetryBackoff.
+func NewRetryClient(c client.Client) client.Client {
+	return retryClient{Client: c}
+}
+
+func (c retryClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return Retry(ctx, func() error {
+		return c.Client.Get(ctx, key, obj)
+	})
+}
+
+func (c retryClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return Retry(ctx, func() error {
+		return c.Client.List(ctx, list, opts...)
+	})
+}
+
+func (c retryClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return Retry(ctx, func() error {
+		return c.Client.Update(ctx, obj, opts...)
+	})
+}
+
+func (c retryClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return Retry(ctx, func() error {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	})
+}
+
+func (c retryClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return Retry(ctx, func() error {
+		return c.Client.Delete(ctx, obj, opts...)
+	})
+}
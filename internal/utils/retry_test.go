@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsTransientError(t *testing.T) {
+	resource := schema.GroupResource{Group: "source.toolkit.fluxcd.io", Resource: "gitrepositories"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"not found", apierrors.NewNotFound(resource, "podinfo"), false},
+		{"conflict", apierrors.NewConflict(resource, "podinfo", errors.New("modified")), false},
+		{"too many requests", apierrors.NewTooManyRequests("throttled", 1), true},
+		{"server timeout", apierrors.NewServerTimeout(resource, "get", 1), true},
+		{"webhook timeout", apierrors.NewInternalError(errors.New("failed calling webhook")), true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"connection refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransientError(tt.err); got != tt.want {
+				t.Errorf("IsTransientError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	backoff := RetryBackoff
+	RetryBackoff.Duration = time.Millisecond
+	defer func() { RetryBackoff = backoff }()
+
+	transient := apierrors.NewTooManyRequests("throttled", 1)
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"success", []error{nil}, 1, false},
+		{"transient then success", []error{transient, transient, nil}, 3, false},
+		{"permanent", []error{errors.New("invalid")}, 1, true},
+		{"transient until out of steps", []error{transient, transient, transient, transient, transient, transient}, 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(context.Background(), func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Retry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Retry() calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
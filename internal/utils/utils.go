@@ -24,10 +24,10 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"text/template"
+	"time"
 
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
 	imageautov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
@@ -54,54 +54,17 @@ import (
 type Utils struct {
 }
 
-type ExecMode string
-
-const (
-	ModeOS       ExecMode = "os.stderr|stdout"
-	ModeStderrOS ExecMode = "os.stderr"
-	ModeCapture  ExecMode = "capture.stderr|stdout"
-)
-
-func ExecKubectlCommand(ctx context.Context, mode ExecMode, kubeConfigPath string, kubeContext string, args ...string) (string, error) {
+// ExecCosignCommand runs the cosign binary, which signs and verifies
+// the OCI artifacts, and returns its output.
+func ExecCosignCommand(ctx context.Context, args ...string) (string, error) {
 	var stdoutBuf, stderrBuf bytes.Buffer
-
-	if kubeConfigPath != "" && len(filepath.SplitList(kubeConfigPath)) == 1 {
-		args = append(args, "--kubeconfig="+kubeConfigPath)
-	}
-
-	if kubeContext != "" {
-		args = append(args, "--context="+kubeContext)
-	}
-
-	c := exec.CommandContext(ctx, "kubectl", args...)
-
-	if mode == ModeStderrOS {
-		c.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
-	}
-	if mode == ModeOS {
-		c.Stdout = io.MultiWriter(os.Stdout, &stdoutBuf)
-		c.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
-	}
-
-	if mode == ModeStderrOS || mode == ModeOS {
-		if err := c.Run(); err != nil {
-			return "", err
-		} else {
-			return "", nil
-		}
-	}
-
-	if mode == ModeCapture {
-		c.Stdout = &stdoutBuf
-		c.Stderr = &stderrBuf
-		if err := c.Run(); err != nil {
-			return stderrBuf.String(), err
-		} else {
-			return stdoutBuf.String(), nil
-		}
+	c := exec.CommandContext(ctx, "cosign", args...)
+	c.Stdout = &stdoutBuf
+	c.Stderr = &stderrBuf
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w", strings.TrimSpace(stderrBuf.String()), err)
 	}
-
-	return "", nil
+	return stdoutBuf.String(), nil
 }
 
 func ExecTemplate(obj interface{}, tmpl, filename string) error {
@@ -134,7 +97,19 @@ func ExecTemplate(obj interface{}, tmpl, filename string) error {
 	return file.Sync()
 }
 
-func KubeConfig(kubeConfigPath string, kubeContext string) (*rest.Config, error) {
+// KubeConfigOptions tunes the clients of the Kubernetes API.
+type KubeConfigOptions struct {
+	// QPS is the maximum number of queries per second, 0 for the
+	// client-go default.
+	QPS float32
+	// Burst is the maximum burst of queries, 0 for the client-go
+	// default.
+	Burst int
+	// Timeout is the timeout of a single request, 0 for none.
+	Timeout time.Duration
+}
+
+func KubeConfig(kubeConfigPath string, kubeContext string, opts KubeConfigOptions) (*rest.Config, error) {
 	configFiles := SplitKubeConfigPath(kubeConfigPath)
 	configOverrides := clientcmd.ConfigOverrides{}
 
@@ -151,15 +126,33 @@ func KubeConfig(kubeConfigPath string, kubeContext string) (*rest.Config, error)
 		return nil, fmt.Errorf("kubernetes configuration load failed: %w", err)
 	}
 
+	cfg.QPS = opts.QPS
+	cfg.Burst = opts.Burst
+	cfg.Timeout = opts.Timeout
 	return cfg, nil
 }
 
-func KubeClient(kubeConfigPath string, kubeContext string) (client.Client, error) {
-	cfg, err := KubeConfig(kubeConfigPath, kubeContext)
+// KubeContextNamespace returns the namespace set by a kubeconfig
+// context, the current one when kubeContext is empty, or an empty
+// string when it sets none.
+func KubeContextNamespace(kubeConfigPath string, kubeContext string) (string, error) {
+	rules := &clientcmd.ClientConfigLoadingRules{Precedence: SplitKubeConfigPath(kubeConfigPath)}
+	config, err := rules.Load()
 	if err != nil {
-		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
+		return "", fmt.Errorf("kubernetes configuration load failed: %w", err)
+	}
+	if kubeContext == "" {
+		kubeContext = config.CurrentContext
+	}
+	if context, ok := config.Contexts[kubeContext]; ok {
+		return context.Namespace, nil
 	}
+	return "", nil
+}
 
+// NewScheme returns a scheme with the Kubernetes and toolkit types
+// the CLI works with.
+func NewScheme() *apiruntime.Scheme {
 	scheme := apiruntime.NewScheme()
 	_ = apiextensionsv1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
@@ -172,15 +165,23 @@ func KubeClient(kubeConfigPath string, kubeContext string) (client.Client, error
 	_ = notificationv1.AddToScheme(scheme)
 	_ = imagereflectv1.AddToScheme(scheme)
 	_ = imageautov1.AddToScheme(scheme)
+	return scheme
+}
+
+func KubeClient(kubeConfigPath string, kubeContext string, opts KubeConfigOptions) (client.Client, error) {
+	cfg, err := KubeConfig(kubeConfigPath, kubeContext, opts)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
+	}
 
 	kubeClient, err := client.New(cfg, client.Options{
-		Scheme: scheme,
+		Scheme: NewScheme(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
 	}
 
-	return kubeClient, nil
+	return NewRetryClient(kubeClient), nil
 }
 
 // SplitKubeConfigPath splits the given KUBECONFIG path based on the runtime OS
@@ -226,22 +227,31 @@ func ParseObjectKindName(input string) (string, string) {
 	return kind, name
 }
 
+// MakeDependsOn returns the references of the given dependencies, in
+// the '<name>' or '<namespace>/<name>' format, each of which may hold
+// a comma-separated list.
 func MakeDependsOn(deps []string) []dependency.CrossNamespaceDependencyReference {
 	refs := []dependency.CrossNamespaceDependencyReference{}
-	for _, dep := range deps {
-		parts := strings.Split(dep, "/")
-		depNamespace := ""
-		depName := ""
-		if len(parts) > 1 {
-			depNamespace = parts[0]
-			depName = parts[1]
-		} else {
-			depName = parts[0]
+	for _, arg := range deps {
+		for _, dep := range strings.Split(arg, ",") {
+			dep = strings.TrimSpace(dep)
+			if dep == "" {
+				continue
+			}
+			parts := strings.Split(dep, "/")
+			depNamespace := ""
+			depName := ""
+			if len(parts) > 1 {
+				depNamespace = parts[0]
+				depName = parts[1]
+			} else {
+				depName = parts[0]
+			}
+			refs = append(refs, dependency.CrossNamespaceDependencyReference{
+				Namespace: depNamespace,
+				Name:      depName,
+			})
 		}
-		refs = append(refs, dependency.CrossNamespaceDependencyReference{
-			Namespace: depNamespace,
-			Name:      depName,
-		})
 	}
 	return refs
 }
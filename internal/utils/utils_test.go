@@ -16,7 +16,12 @@ limitations under the License.
 
 package utils
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fluxcd/pkg/runtime/dependency"
+)
 
 func TestCompatibleVersion(t *testing.T) {
 	tests := []struct {
@@ -40,3 +45,28 @@ func TestCompatibleVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeDependsOn(t *testing.T) {
+	tests := []struct {
+		name string
+		deps []string
+		want []dependency.CrossNamespaceDependencyReference
+	}{
+		{"none", nil, []dependency.CrossNamespaceDependencyReference{}},
+		{"name", []string{"infra"}, []dependency.CrossNamespaceDependencyReference{{Name: "infra"}}},
+		{"namespaced name", []string{"ops/infra"}, []dependency.CrossNamespaceDependencyReference{{Namespace: "ops", Name: "infra"}}},
+		{"comma-separated", []string{"infra, ops/crds,"}, []dependency.CrossNamespaceDependencyReference{
+			{Name: "infra"}, {Namespace: "ops", Name: "crds"},
+		}},
+		{"repeated and comma-separated", []string{"infra", "ops/crds,ops/policies"}, []dependency.CrossNamespaceDependencyReference{
+			{Name: "infra"}, {Namespace: "ops", Name: "crds"}, {Namespace: "ops", Name: "policies"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MakeDependsOn(tt.deps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeDependsOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
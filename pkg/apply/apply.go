@@ -0,0 +1,246 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apply applies Kubernetes manifests with server-side apply,
+// as flux install and flux bootstrap do.
+package apply
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// Action is the change made to an object by applying it.
+type Action string
+
+const (
+	CreatedAction    Action = "created"
+	ConfiguredAction Action = "configured"
+	UnchangedAction  Action = "unchanged"
+)
+
+// Change is the change made to an object.
+type Change struct {
+	// Subject is the kind, namespace and name of the object.
+	Subject string
+	Action  Action
+	DryRun  bool
+}
+
+func (c Change) String() string {
+	if c.DryRun {
+		return fmt.Sprintf("%s %s (dry run)", c.Subject, c.Action)
+	}
+	return fmt.Sprintf("%s %s", c.Subject, c.Action)
+}
+
+// Path applies the objects of a multi-document YAML file, or of the
+// kustomize build of a directory, and returns the change made to each
+// of them, in order. The namespaces and the custom resource definitions
+// are applied first, and the latter are waited for, so that the
+// objects of the kinds they define can be applied next. On error, the
+// changes made until then are returned.
+func Path(ctx context.Context, kubeClient client.Client, path string, opts Options) ([]Change, error) {
+	data, err := ReadPath(path)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := DecodeObjects(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the manifests in %s: %w", path, err)
+	}
+	return Objects(ctx, kubeClient, objects, opts)
+}
+
+// Objects applies the objects, the namespaces and the custom resource
+// definitions first, and returns the change made to each of them.
+func Objects(ctx context.Context, kubeClient client.Client, objects []*unstructured.Unstructured, opts Options) ([]Change, error) {
+	var definitions, resources []*unstructured.Unstructured
+	for _, obj := range objects {
+		switch obj.GetKind() {
+		case "Namespace", "CustomResourceDefinition":
+			definitions = append(definitions, obj)
+		default:
+			resources = append(resources, obj)
+		}
+	}
+
+	changes, err := applyObjects(ctx, kubeClient, definitions, opts)
+	if err != nil {
+		return changes, err
+	}
+	if !opts.DryRun {
+		for _, obj := range definitions {
+			if obj.GetKind() != "CustomResourceDefinition" {
+				continue
+			}
+			if err := wait.PollImmediate(opts.PollInterval, opts.Timeout,
+				isCRDEstablished(ctx, kubeClient, obj.GetName())); err != nil {
+				return changes, fmt.Errorf("CustomResourceDefinition %s is not established: %w", obj.GetName(), err)
+			}
+		}
+	}
+	resourceChanges, err := applyObjects(ctx, kubeClient, resources, opts)
+	return append(changes, resourceChanges...), err
+}
+
+// ReadPath returns the content of a manifest file, or the kustomize
+// build of a directory.
+func ReadPath(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return ioutil.ReadFile(path)
+	}
+
+	m, err := krusty.MakeKustomizer(filesys.MakeFsOnDisk(), krusty.MakeDefaultOptions()).Run(path)
+	if err != nil {
+		return nil, fmt.Errorf("kustomize build of %s failed: %w", path, err)
+	}
+	return m.AsYaml()
+}
+
+// DecodeObjects decodes the objects of a multi-document YAML or JSON
+// stream, skipping the empty documents.
+func DecodeObjects(data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 2048)
+	for {
+		var obj unstructured.Unstructured
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objects = append(objects, &obj)
+	}
+	return objects, nil
+}
+
+// ObjectName returns the kind, namespace and name of an object.
+func ObjectName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+	}
+	return fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+func applyObjects(ctx context.Context, kubeClient client.Client, objects []*unstructured.Unstructured, opts Options) ([]Change, error) {
+	var changes []Change
+	for _, obj := range objects {
+		action, err := applyObject(ctx, kubeClient, obj, opts)
+		if err != nil {
+			return changes, fmt.Errorf("failed to apply %s: %w", ObjectName(obj), err)
+		}
+		changes = append(changes, Change{Subject: ObjectName(obj), Action: action, DryRun: opts.DryRun})
+	}
+	return changes, nil
+}
+
+func applyObject(ctx context.Context, kubeClient client.Client, obj *unstructured.Unstructured, opts Options) (Action, error) {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		existing = nil
+	case apimeta.IsNoMatchError(err) && opts.DryRun:
+		// the kind is defined by a CRD applied in the same dry run
+		return CreatedAction, nil
+	default:
+		return "", err
+	}
+
+	if existing == nil && opts.DryRun {
+		// the server can't dry run objects in namespaces that are
+		// only created by the same dry run
+		return CreatedAction, nil
+	}
+
+	patchOpts := []client.PatchOption{client.ForceOwnership, client.FieldOwner(opts.FieldOwner)}
+	if opts.DryRun {
+		patchOpts = append(patchOpts, client.DryRunAll)
+	}
+	applied := obj.DeepCopy()
+	if err := kubeClient.Patch(ctx, applied, client.Apply, patchOpts...); err != nil {
+		return "", err
+	}
+
+	switch {
+	case existing == nil:
+		return CreatedAction, nil
+	case opts.DryRun:
+		if reflect.DeepEqual(desiredState(existing), desiredState(applied)) {
+			return UnchangedAction, nil
+		}
+		return ConfiguredAction, nil
+	case applied.GetResourceVersion() == existing.GetResourceVersion():
+		return UnchangedAction, nil
+	default:
+		return ConfiguredAction, nil
+	}
+}
+
+// desiredState returns the content of an object without its status
+// and the metadata set by the API server, so that the dry run of an
+// object can be compared with its live state.
+func desiredState(obj *unstructured.Unstructured) map[string]interface{} {
+	state := obj.DeepCopy().Object
+	delete(state, "status")
+	if metadata, ok := state["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"creationTimestamp", "uid", "resourceVersion", "generation", "managedFields", "selfLink"} {
+			delete(metadata, field)
+		}
+	}
+	return state
+}
+
+func isCRDEstablished(ctx context.Context, kubeClient client.Client, name string) wait.ConditionFunc {
+	return func() (bool, error) {
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := kubeClient.Get(ctx, client.ObjectKey{Name: name}, &crd); err != nil {
+			return false, err
+		}
+		for _, condition := range crd.Status.Conditions {
+			if condition.Type == apiextensionsv1.Established {
+				return condition.Status == apiextensionsv1.ConditionTrue, nil
+			}
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import (
+	"testing"
+)
+
+func TestDecodeObjects(t *testing.T) {
+	data := []byte(`---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: flux-system
+---
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: source-controller
+  namespace: flux-system
+`)
+	objects, err := DecodeObjects(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"Namespace/flux-system", "ServiceAccount/flux-system/source-controller"}
+	if len(objects) != len(expected) {
+		t.Fatalf("expected %d objects, got %d", len(expected), len(objects))
+	}
+	for i, obj := range objects {
+		if name := ObjectName(obj); name != expected[i] {
+			t.Errorf("expected object %s, got %s", expected[i], name)
+		}
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	tests := []struct {
+		change   Change
+		expected string
+	}{
+		{Change{Subject: "Namespace/flux-system", Action: CreatedAction}, "Namespace/flux-system created"},
+		{Change{Subject: "Namespace/flux-system", Action: UnchangedAction, DryRun: true}, "Namespace/flux-system unchanged (dry run)"},
+	}
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apply
+
+import "time"
+
+// Options configures how the manifests are applied.
+type Options struct {
+	// FieldOwner is the field manager of the applied objects.
+	FieldOwner string
+	// DryRun applies the objects without persisting them.
+	DryRun bool
+	// PollInterval is the interval at which the applied
+	// CustomResourceDefinitions are checked for being established.
+	PollInterval time.Duration
+	// Timeout is how long to wait for the applied
+	// CustomResourceDefinitions to be established.
+	Timeout time.Duration
+}
+
+func MakeDefaultOptions() Options {
+	return Options{
+		FieldOwner:   "flux",
+		DryRun:       false,
+		PollInterval: 2 * time.Second,
+		Timeout:      5 * time.Minute,
+	}
+}
@@ -18,6 +18,9 @@ package install
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -43,3 +46,71 @@ func TestGenerate(t *testing.T) {
 
 	fmt.Println(output)
 }
+
+func TestComponentImages(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.ComponentImages = map[string]string{
+		"source-controller": "ghcr.io/mirror/source-controller:v0.9.0-hotfix.1",
+		"helm-controller":   "registry.example.com:5000/helm-controller@sha256:abcdef",
+	}
+	images, err := componentImages(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]kustomizationImage{
+		"fluxcd/source-controller": {
+			Name:    "fluxcd/source-controller",
+			NewName: "ghcr.io/mirror/source-controller",
+			NewTag:  "v0.9.0-hotfix.1",
+		},
+		"fluxcd/kustomize-controller": {
+			Name:    "fluxcd/kustomize-controller",
+			NewName: "ghcr.io/fluxcd/kustomize-controller",
+		},
+		"fluxcd/helm-controller": {
+			Name:    "fluxcd/helm-controller",
+			NewName: "registry.example.com:5000/helm-controller",
+			Digest:  "sha256:abcdef",
+		},
+	}
+	for _, image := range images {
+		if want, ok := expected[image.Name]; ok && image != want {
+			t.Errorf("image %s: expected %+v, got %+v", image.Name, want, image)
+		}
+	}
+
+	opts.ComponentImages = map[string]string{"image-reflector-controller": "ghcr.io/mirror/image-reflector-controller:v0.7.0"}
+	if _, err := componentImages(opts); err == nil {
+		t.Error("expected an error for an override of a component not installed")
+	}
+}
+
+func TestProxyPatch(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.HTTPSProxy = "http://proxy.example.com:3128"
+	opts.NoProxy = append(defaultNoProxy(opts.ClusterDomain), "git.internal.example.com")
+
+	dir, err := ioutil.TempDir("", "flux-install")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "node-selector.yaml")
+	if err := execTemplate(opts, nodeSelectorTmpl, file); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`value: "http://proxy.example.com:3128"`,
+		`value: "$(KUBERNETES_SERVICE_HOST),.cluster.local.,.cluster.local,.svc,git.internal.example.com"`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("'%s' not found in:\n%s", expected, data)
+		}
+	}
+}
@@ -77,11 +77,20 @@ func generate(base string, options Options) error {
 		return fmt.Errorf("generate labels failed: %w", err)
 	}
 
+	if options.HTTPSProxy != "" {
+		options.NoProxy = append(defaultNoProxy(options.ClusterDomain), options.NoProxy...)
+	}
+
 	if err := execTemplate(options, nodeSelectorTmpl, path.Join(base, "node-selector.yaml")); err != nil {
 		return fmt.Errorf("generate node selector failed: %w", err)
 	}
 
-	if err := execTemplate(options, kustomizationTmpl, path.Join(base, "kustomization.yaml")); err != nil {
+	images, err := componentImages(options)
+	if err != nil {
+		return err
+	}
+	data := kustomizationData{Options: options, Images: images}
+	if err := execTemplate(data, kustomizationTmpl, path.Join(base, "kustomization.yaml")); err != nil {
 		return fmt.Errorf("generate kustomization failed: %w", err)
 	}
 
@@ -113,6 +122,53 @@ func generate(base string, options Options) error {
 	return nil
 }
 
+// defaultNoProxy returns the hosts the controllers reach without the
+// proxy, which are the Kubernetes API and the services of the cluster.
+func defaultNoProxy(clusterDomain string) []string {
+	return []string{
+		"$(KUBERNETES_SERVICE_HOST)",
+		fmt.Sprintf(".%s.", clusterDomain),
+		"." + clusterDomain,
+		".svc",
+	}
+}
+
+// componentImages returns the images of the kustomization, which point
+// the components to the registry or to the image overrides.
+func componentImages(options Options) ([]kustomizationImage, error) {
+	for component := range options.ComponentImages {
+		if !containsItemString(options.Components, component) {
+			return nil, fmt.Errorf("image override for %s, which is not one of the components", component)
+		}
+	}
+
+	var images []kustomizationImage
+	for _, component := range options.Components {
+		image := kustomizationImage{Name: "fluxcd/" + component}
+		if ref, ok := options.ComponentImages[component]; ok {
+			image.NewName, image.NewTag, image.Digest = splitImageRef(ref)
+		} else if options.Registry != "" {
+			image.NewName = fmt.Sprintf("%s/%s", options.Registry, component)
+		} else {
+			continue
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// splitImageRef splits an image reference into its name, tag and digest,
+// e.g. ghcr.io/mirror/source-controller:v0.9.0-hotfix.1.
+func splitImageRef(ref string) (name, tag, digest string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		ref, digest = ref[:i], ref[i+1:]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref, tag = ref[:i], ref[i+1:]
+	}
+	return ref, tag, digest
+}
+
 func build(base, output string) error {
 	kfile := filepath.Join(base, "kustomization.yaml")
 
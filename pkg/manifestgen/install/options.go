@@ -36,6 +36,9 @@ type Options struct {
 	TargetPath             string
 	ClusterDomain          string
 	TolerationKeys         []string
+	ComponentImages        map[string]string
+	HTTPSProxy             string
+	NoProxy                []string
 }
 
 func MakeDefaultOptions() Options {
@@ -24,10 +24,24 @@ import (
 	"text/template"
 )
 
+// kustomizationImage is an entry of the images of the kustomization of
+// the components.
+type kustomizationImage struct {
+	Name    string
+	NewName string
+	NewTag  string
+	Digest  string
+}
+
+// kustomizationData is the data of the kustomization template.
+type kustomizationData struct {
+	Options
+	Images []kustomizationImage
+}
+
 var kustomizationTmpl = `---
 {{- $eventsAddr := .EventsAddr }}
 {{- $watchAllNamespaces := .WatchAllNamespaces }}
-{{- $registry := .Registry }}
 {{- $logLevel := .LogLevel }}
 {{- $clusterDomain := .ClusterDomain }}
 apiVersion: kustomize.config.k8s.io/v1beta1
@@ -105,11 +119,19 @@ patchesJson6902:
 {{- end }}
 {{- end }}
 
-{{- if $registry }}
+{{- if .Images }}
 images:
-{{- range $i, $component := .Components }}
-  - name: fluxcd/{{$component}}
-    newName: {{$registry}}/{{$component}}
+{{- range .Images }}
+  - name: {{.Name}}
+{{- if .NewName }}
+    newName: {{.NewName}}
+{{- end }}
+{{- if .NewTag }}
+    newTag: {{.NewTag}}
+{{- end }}
+{{- if .Digest }}
+    digest: {{.Digest}}
+{{- end }}
 {{- end }}
 {{- end }}
 `
@@ -144,6 +166,15 @@ spec:
          operator: "Exists"
 {{- end }}
 {{- end }}
+{{- if .HTTPSProxy }}
+      containers:
+       - name: manager
+         env:
+          - name: HTTPS_PROXY
+            value: "{{.HTTPSProxy}}"
+          - name: NO_PROXY
+            value: "{{- range $i, $host := .NoProxy }}{{if $i}},{{end}}{{$host}}{{- end }}"
+{{- end }}
 `
 
 var labelsTmpl = `---
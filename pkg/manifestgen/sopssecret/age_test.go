@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sopssecret
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBech32(t *testing.T) {
+	// valid strings from BIP 173
+	for _, s := range []string{
+		"A12UEL5L",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	} {
+		hrp, data, err := bech32Decode(s)
+		if err != nil {
+			t.Fatalf("decoding %s failed: %v", s, err)
+		}
+		if encoded := bech32Encode(hrp, data); encoded != strings.ToLower(s) {
+			t.Errorf("expected %s, got %s", strings.ToLower(s), encoded)
+		}
+	}
+
+	if _, _, err := bech32Decode("a12uel5m"); err == nil {
+		t.Error("expected an invalid checksum error")
+	}
+}
+
+func TestAgeRecipient(t *testing.T) {
+	// the example key pair of the age documentation
+	identity := "AGE-SECRET-KEY-1N9JEPW6DWJ0ZQUDX63F5A03GX8QUW7PXDE39N8UYF82VZ9PC8UFS3M7XA9"
+	want := "age1lvyvwawkr0mcnnnncaghunadrqkmuf9e6507x9y920xxpp866cnql7dp2z"
+
+	recipient, err := AgeRecipient(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipient != want {
+		t.Errorf("expected recipient %s, got %s", want, recipient)
+	}
+}
+
+func TestGenerateAgeKey(t *testing.T) {
+	identity, recipient, err := GenerateAgeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(identity, "AGE-SECRET-KEY-1") {
+		t.Errorf("unexpected identity %s", identity)
+	}
+	if !strings.HasPrefix(recipient, "age1") {
+		t.Errorf("unexpected recipient %s", recipient)
+	}
+
+	file := "# created: 2021-03-01T10:00:00Z\n# public key: " + recipient + "\n" + identity + "\n"
+	if r, err := AgeRecipient(file); err != nil || r != recipient {
+		t.Errorf("expected recipient %s, got %s (%v)", recipient, r, err)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sopssecret
+
+const (
+	// AgeKeySecretKey is the key of the age identities in the secret,
+	// the kustomize-controller imports the keys ending in .agekey.
+	AgeKeySecretKey = "identity.agekey"
+	// GPGKeySecretKey is the key of the OpenPGP private keys in the
+	// secret, the kustomize-controller imports the keys ending in .asc.
+	GPGKeySecretKey = "identity.asc"
+)
+
+type Options struct {
+	Name         string
+	Namespace    string
+	Labels       map[string]string
+	AgeKey       string
+	AgeKeyPath   string
+	GPGKeyPath   string
+	TargetPath   string
+	ManifestFile string
+}
+
+func MakeDefaultOptions() Options {
+	return Options{
+		Name:         "sops-keys",
+		Namespace:    "flux-system",
+		Labels:       map[string]string{},
+		AgeKey:       "",
+		AgeKeyPath:   "",
+		GPGKeyPath:   "",
+		ManifestFile: "sops-keys.yaml",
+	}
+}
@@ -49,9 +49,7 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 			Interval: metav1.Duration{
 				Duration: options.Interval,
 			},
-			Reference: &sourcev1.GitRepositoryRef{
-				Branch: options.Branch,
-			},
+			Reference: &sourcev1.GitRepositoryRef{},
 			SecretRef: &meta.LocalObjectReference{
 				Name: options.Secret,
 			},
@@ -59,6 +57,12 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 		},
 	}
 
+	if options.TagSemVer != "" {
+		gitRepository.Spec.Reference.SemVer = options.TagSemVer
+	} else {
+		gitRepository.Spec.Reference.Branch = options.Branch
+	}
+
 	gitData, err := yaml.Marshal(gitRepository)
 	if err != nil {
 		return nil, err
@@ -88,6 +92,17 @@ func Generate(options Options) (*manifestgen.Manifest, error) {
 		},
 	}
 
+	if options.DecryptionProvider != "" {
+		kustomization.Spec.Decryption = &kustomizev1.Decryption{
+			Provider: options.DecryptionProvider,
+		}
+		if options.DecryptionSecret != "" {
+			kustomization.Spec.Decryption.SecretRef = &meta.LocalObjectReference{
+				Name: options.DecryptionSecret,
+			}
+		}
+	}
+
 	ksData, err := yaml.Marshal(kustomization)
 	if err != nil {
 		return nil, err
@@ -39,3 +39,35 @@ func TestGenerate(t *testing.T) {
 
 	fmt.Println(output.Content)
 }
+
+func TestGenerateWithTagSemVer(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.TagSemVer = ">=1.0.0 <2.0.0"
+	output, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.Content, "semver: '>=1.0.0 <2.0.0'") {
+		t.Errorf("semver reference not found in:\n%s", output.Content)
+	}
+	if strings.Contains(output.Content, "branch: "+opts.Branch) {
+		t.Errorf("unexpected branch reference in:\n%s", output.Content)
+	}
+}
+
+func TestGenerateWithDecryption(t *testing.T) {
+	opts := MakeDefaultOptions()
+	opts.DecryptionProvider = "sops"
+	opts.DecryptionSecret = "sops-age"
+	output, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expect := range []string{"provider: sops", "name: sops-age"} {
+		if !strings.Contains(output.Content, expect) {
+			t.Errorf("'%s' not found in:\n%s", expect, output.Content)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	sourceDir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sourceDir)
+
+	files := map[string]string{
+		"kustomization.yaml":    "resources:\n- deployment.yaml\n",
+		"deployment.yaml":       "kind: Deployment\n",
+		"overlays/prod/kc.yaml": "kind: Kustomization\n",
+		".git/HEAD":             "ref: refs/heads/main\n",
+	}
+	for p, content := range files {
+		path := filepath.Join(sourceDir, p)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifactPath := filepath.Join(sourceDir, "..", filepath.Base(sourceDir)+".tgz")
+	defer os.Remove(artifactPath)
+	if err := Build(artifactPath, sourceDir); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(artifactPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	got := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[header.Name] = string(content)
+	}
+
+	delete(files, ".git/HEAD")
+	if !reflect.DeepEqual(got, files) {
+		var names []string
+		for name := range got {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t.Errorf("unexpected files in the artifact: %v", names)
+	}
+}
+
+func TestBuildNotADirectory(t *testing.T) {
+	f, err := ioutil.TempFile("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	if err := Build(f.Name()+".tgz", f.Name()); err == nil {
+		t.Error("expected an error for a file source")
+	}
+}
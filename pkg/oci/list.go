@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Artifact is a tagged artifact of a repository.
+type Artifact struct {
+	// URL is the address of the artifact, with its tag.
+	URL string
+	Tag string
+	Metadata
+}
+
+// List returns the tagged artifacts of the repository at the given
+// address, sorted by tag.
+func List(ctx context.Context, url string) ([]Artifact, error) {
+	repo, err := ParseRepositoryURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := remote.List(repo, remoteOptions(ctx)...)
+	if err != nil {
+		return nil, fmt.Errorf("listing the tags of %s failed: %w", url, err)
+	}
+	sort.Strings(tags)
+
+	artifacts := make([]Artifact, 0, len(tags))
+	for _, tag := range tags {
+		ref := repo.Tag(tag)
+		desc, err := remote.Get(ref, remoteOptions(ctx)...)
+		if err != nil {
+			return nil, fmt.Errorf("fetching the manifest of %s failed: %w", ref, err)
+		}
+		manifest, err := v1.ParseManifest(bytes.NewReader(desc.Manifest))
+		if err != nil {
+			return nil, fmt.Errorf("parsing the manifest of %s failed: %w", ref, err)
+		}
+		metadata := metadataFromAnnotations(manifest.Annotations)
+		metadata.Digest = desc.Digest.String()
+		artifacts = append(artifacts, Artifact{
+			URL:      URLPrefix + ref.String(),
+			Tag:      tag,
+			Metadata: metadata,
+		})
+	}
+	return artifacts, nil
+}
+
+// Tag adds a tag to the artifact at the given address, in the same
+// repository, and returns the address of the artifact with that tag.
+func Tag(ctx context.Context, url, tag string) (string, error) {
+	ref, err := ParseArtifactURL(url)
+	if err != nil {
+		return "", err
+	}
+
+	desc, err := remote.Get(ref, remoteOptions(ctx)...)
+	if err != nil {
+		return "", fmt.Errorf("fetching the manifest of %s failed: %w", url, err)
+	}
+
+	dst := ref.Context().Tag(tag)
+	if err := remote.Tag(dst, desc, remoteOptions(ctx)...); err != nil {
+		return "", fmt.Errorf("tagging the artifact failed: %w", err)
+	}
+	return URLPrefix + dst.String(), nil
+}
+
+// ResolveDigest returns the address of the artifact at the given
+// address, pinned to its digest, so that the artifact that is verified
+// is the one that is pulled.
+func ResolveDigest(ctx context.Context, url string) (string, error) {
+	ref, err := ParseArtifactURL(url)
+	if err != nil {
+		return "", err
+	}
+	desc, err := remote.Get(ref, remoteOptions(ctx)...)
+	if err != nil {
+		return "", fmt.Errorf("fetching the manifest of %s failed: %w", url, err)
+	}
+	return fmt.Sprintf("%s%s@%s", URLPrefix, ref.Context().Name(), desc.Digest), nil
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package oci pushes the manifests of a directory to a container
+// registry as an OCI artifact, which OCIRepository sources consume, and
+// pulls them back.
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+const (
+	// URLPrefix is the scheme of the artifact addresses.
+	URLPrefix = "oci://"
+
+	SourceAnnotation   = "org.opencontainers.image.source"
+	RevisionAnnotation = "org.opencontainers.image.revision"
+	CreatedAnnotation  = "org.opencontainers.image.created"
+)
+
+// Metadata is the provenance of an artifact, recorded in the
+// annotations of its manifest.
+type Metadata struct {
+	// Source is the address of the Git repository the manifests
+	// come from.
+	Source string
+	// Revision is the Git revision the manifests come from.
+	Revision string
+	// Created is the time the artifact was pushed, in RFC 3339 format.
+	Created string
+	// Digest is the digest of the artifact manifest, set when pushing
+	// and pulling.
+	Digest string
+}
+
+func (m Metadata) annotations() map[string]string {
+	annotations := map[string]string{
+		CreatedAnnotation: m.Created,
+	}
+	if m.Source != "" {
+		annotations[SourceAnnotation] = m.Source
+	}
+	if m.Revision != "" {
+		annotations[RevisionAnnotation] = m.Revision
+	}
+	return annotations
+}
+
+func metadataFromAnnotations(annotations map[string]string) Metadata {
+	return Metadata{
+		Source:   annotations[SourceAnnotation],
+		Revision: annotations[RevisionAnnotation],
+		Created:  annotations[CreatedAnnotation],
+	}
+}
+
+// ParseArtifactURL returns the reference of an artifact given as
+// oci://<host>/<org>/<repo>:<tag> or oci://<host>/<org>/<repo>@<digest>.
+func ParseArtifactURL(url string) (name.Reference, error) {
+	if !strings.HasPrefix(url, URLPrefix) {
+		return nil, fmt.Errorf("url must be in the format %s<host>/<org>/<repo>[:<tag>|@<digest>]", URLPrefix)
+	}
+	ref, err := name.ParseReference(strings.TrimPrefix(url, URLPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url '%s': %w", url, err)
+	}
+	return ref, nil
+}
+
+// ParseRepositoryURL returns the repository given as
+// oci://<host>/<org>/<repo>.
+func ParseRepositoryURL(url string) (name.Repository, error) {
+	if !strings.HasPrefix(url, URLPrefix) {
+		return name.Repository{}, fmt.Errorf("url must be in the format %s<host>/<org>/<repo>", URLPrefix)
+	}
+	repo, err := name.NewRepository(strings.TrimPrefix(url, URLPrefix))
+	if err != nil {
+		return name.Repository{}, fmt.Errorf("invalid url '%s': %w", url, err)
+	}
+	return repo, nil
+}
+
+// annotatedImage sets annotations on the manifest of an image, which
+// the mutate package of the go-containerregistry version in use can't.
+type annotatedImage struct {
+	v1.Image
+	annotations map[string]string
+}
+
+func (i annotatedImage) Manifest() (*v1.Manifest, error) {
+	m, err := i.Image.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	m = m.DeepCopy()
+	if m.Annotations == nil {
+		m.Annotations = map[string]string{}
+	}
+	for k, v := range i.annotations {
+		m.Annotations[k] = v
+	}
+	return m, nil
+}
+
+func (i annotatedImage) RawManifest() ([]byte, error) {
+	m, err := i.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+func (i annotatedImage) Digest() (v1.Hash, error) {
+	return partial.Digest(i)
+}
+
+func (i annotatedImage) Size() (int64, error) {
+	return partial.Size(i)
+}
+
+// remoteOptions authenticate with the credentials of the Docker
+// configuration, as docker login writes them.
+func remoteOptions(ctx context.Context) []remote.Option {
+	return []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+}
+
+// Push packages the files of sourceDir as a gzipped tarball, and
+// pushes it as the single layer of an artifact to the given address,
+// with the provenance of metadata. It returns the address of the
+// artifact, pinned to its digest.
+func Push(ctx context.Context, url, sourceDir string, metadata Metadata) (string, error) {
+	ref, err := ParseArtifactURL(url)
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "oci")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	artifactPath := filepath.Join(tmpDir, "artifact.tgz")
+	if err := Build(artifactPath, sourceDir); err != nil {
+		return "", err
+	}
+
+	layer, err := tarball.LayerFromFile(artifactPath)
+	if err != nil {
+		return "", fmt.Errorf("creating the artifact layer failed: %w", err)
+	}
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: layer})
+	if err != nil {
+		return "", fmt.Errorf("creating the artifact failed: %w", err)
+	}
+	if metadata.Created == "" {
+		metadata.Created = time.Now().UTC().Format(time.RFC3339)
+	}
+	img = annotatedImage{Image: img, annotations: metadata.annotations()}
+
+	if err := remote.Write(ref, img, remoteOptions(ctx)...); err != nil {
+		return "", fmt.Errorf("pushing the artifact failed: %w", err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s@%s", URLPrefix, ref.Context().Name(), digest), nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/fluxcd/pkg/untar"
+)
+
+// Pull downloads the artifact at the given address, extracts its
+// files to outDir, and returns its provenance.
+func Pull(ctx context.Context, url, outDir string) (Metadata, error) {
+	ref, err := ParseArtifactURL(url)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	img, err := remote.Image(ref, remoteOptions(ctx)...)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("pulling the artifact failed: %w", err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return Metadata{}, fmt.Errorf("reading the artifact manifest failed: %w", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return Metadata{}, err
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return Metadata{}, fmt.Errorf("reading the artifact layers failed: %w", err)
+	}
+	if len(layers) != 1 {
+		return Metadata{}, fmt.Errorf("expected an artifact with a single layer, found %d layers", len(layers))
+	}
+
+	blob, err := layers[0].Compressed()
+	if err != nil {
+		return Metadata{}, fmt.Errorf("downloading the artifact layer failed: %w", err)
+	}
+	defer blob.Close()
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return Metadata{}, err
+	}
+	if _, err := untar.Untar(blob, outDir); err != nil {
+		return Metadata{}, fmt.Errorf("extracting the artifact failed: %w", err)
+	}
+
+	metadata := metadataFromAnnotations(manifest.Annotations)
+	metadata.Digest = digest.String()
+	return metadata, nil
+}
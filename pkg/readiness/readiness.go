@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package readiness waits for the toolkit components and objects to be
+// ready, as flux install and flux bootstrap do.
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
+)
+
+// Check is a named condition to wait for.
+type Check struct {
+	Name      string
+	Condition wait.ConditionFunc
+}
+
+// Wait polls the checks until their conditions are met, running at
+// most concurrency of them at once, and returns the error of each
+// check, in order, nil for the ones that succeeded.
+func Wait(pollInterval, timeout time.Duration, concurrency int, checks []Check) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	errs := make([]error, len(checks))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, check Check) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = wait.PollImmediate(pollInterval, timeout, check.Condition)
+		}(i, check)
+	}
+	wg.Wait()
+	return errs
+}
+
+// DeploymentReady returns a condition met once a deployment is rolled
+// out, as computed by kstatus. A deployment which does not exist yet
+// is not ready.
+func DeploymentReady(ctx context.Context, kubeClient client.Client, namespacedName types.NamespacedName) wait.ConditionFunc {
+	return func() (bool, error) {
+		deployment := &unstructured.Unstructured{}
+		deployment.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+		if err := kubeClient.Get(ctx, namespacedName, deployment); err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			// the deployment may not have been created yet
+			return false, nil
+		}
+		result, err := status.Compute(deployment)
+		if err != nil {
+			return false, nil
+		}
+		return result.Status == status.CurrentStatus, nil
+	}
+}
+
+// ObjectReady returns a condition met once a toolkit object of the
+// given kind has been reconciled at its current generation, and its
+// Ready condition is true. It fails when the Ready condition is false.
+func ObjectReady(ctx context.Context, kubeClient client.Client, gvk schema.GroupVersionKind, namespacedName types.NamespacedName) wait.ConditionFunc {
+	return func() (bool, error) {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := kubeClient.Get(ctx, namespacedName, obj); err != nil {
+			return false, err
+		}
+
+		// confirm the state we are observing is for the current generation
+		observedGeneration, _, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+		if err != nil || obj.GetGeneration() != observedGeneration {
+			return false, nil
+		}
+
+		var conditions []metav1.Condition
+		if err := statusConditions(obj, &conditions); err != nil {
+			return false, nil
+		}
+		if c := apimeta.FindStatusCondition(conditions, meta.ReadyCondition); c != nil {
+			switch c.Status {
+			case metav1.ConditionTrue:
+				return true, nil
+			case metav1.ConditionFalse:
+				return false, errors.New(c.Message)
+			}
+		}
+		return false, nil
+	}
+}
+
+func statusConditions(obj *unstructured.Unstructured, conditions *[]metav1.Condition) error {
+	var s struct {
+		Status struct {
+			Conditions []metav1.Condition `json:"conditions,omitempty"`
+		} `json:"status,omitempty"`
+	}
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*conditions = s.Status.Conditions
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	var running, maxRunning int32
+	condition := func(ready bool) func() (bool, error) {
+		return func() (bool, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			if !ready {
+				return false, fmt.Errorf("not ready")
+			}
+			return true, nil
+		}
+	}
+
+	checks := []Check{
+		{Name: "a", Condition: condition(true)},
+		{Name: "b", Condition: condition(false)},
+		{Name: "c", Condition: condition(true)},
+		{Name: "d", Condition: condition(true)},
+	}
+	errs := Wait(time.Millisecond, time.Second, 2, checks)
+
+	for i, err := range errs {
+		if failed := err != nil; failed != (checks[i].Name == "b") {
+			t.Errorf("unexpected result for check %s: %v", checks[i].Name, err)
+		}
+	}
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 checks running at once, got %d", maxRunning)
+	}
+}